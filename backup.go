@@ -0,0 +1,254 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cwsaylor/gitmoni/internal/plugins"
+	"github.com/cwsaylor/gitmoni/pkg/config"
+)
+
+// archiveRoot is a place gitmoni keeps files, and the name it has in a
+// backup archive.
+type archiveRoot struct {
+	name string // "gitmoni.json" for the config file, else a directory such as "cache"
+	path string
+}
+
+// archiveRoots returns where the config, the cache of history, snapshots,
+// and other state, and the plugins are on this machine.
+func archiveRoots() ([]archiveRoot, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return []archiveRoot{
+		{name: "gitmoni.json", path: config.Path()},
+		{name: "cache", path: filepath.Join(cache, "gitmoni")},
+		{name: "plugins", path: plugins.Dir()},
+	}, nil
+}
+
+// skipCache lists what in the cache isn't backed up: logs, fetch locks, the
+// daemon's status snapshot, which it rewrites as it starts, and the backups
+// taken before restores.
+var skipCache = []string{"gitmoni.log", "crash.log", "fetch", "status.json", "restores"}
+
+// runBackup implements "gitmoni backup": it writes the config, the cache,
+// and the plugins to a gzipped tar archive, to move them to another machine
+// or go back to them after a bad edit.
+func runBackup(args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gitmoni backup [archive]")
+		fmt.Fprintln(fs.Output(), "Writes gitmoni-backup-<date>.tar.gz in the current directory if no archive is given.")
+	}
+	fs.Parse(args)
+	name := fs.Arg(0)
+	if name == "" {
+		name = "gitmoni-backup-" + time.Now().Format("2006-01-02") + ".tar.gz"
+	}
+	n, err := writeArchive(name)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Backed up %d files to %s.\n", n, name)
+	return nil
+}
+
+// runRestore implements "gitmoni restore": it puts the files in an archive
+// written by "gitmoni backup" back, after backing up the ones it replaces.
+// Files that aren't in the archive are left alone.
+func runRestore(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	list := fs.Bool("list", false, "List the files in the archive instead of restoring them")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gitmoni restore [--list] <archive>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("missing archive")
+	}
+	if *list {
+		return readArchive(fs.Arg(0), func(name string, hdr *tar.Header, _ io.Reader) error {
+			fmt.Printf("%s  %s\n", hdr.ModTime.Format("2006-01-02 15:04"), name)
+			return nil
+		})
+	}
+
+	// Keep what is about to be replaced, so a restore can be undone too
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return err
+	}
+	previous := filepath.Join(cache, "gitmoni", "restores", "before-"+time.Now().Format("2006-01-02-150405")+".tar.gz")
+	if err := os.MkdirAll(filepath.Dir(previous), 0o755); err != nil {
+		return err
+	}
+	if _, err := writeArchive(previous); err != nil {
+		return fmt.Errorf("backing up the current files: %w", err)
+	}
+
+	roots, err := archiveRoots()
+	if err != nil {
+		return err
+	}
+	restored := 0
+	err = readArchive(fs.Arg(0), func(name string, hdr *tar.Header, r io.Reader) error {
+		root, rel, _ := strings.Cut(name, "/")
+		i := slices.IndexFunc(roots, func(ar archiveRoot) bool { return ar.name == root })
+		if i < 0 {
+			return nil
+		}
+		dest := roots[i].path
+		if rel != "" {
+			dest = filepath.Join(dest, filepath.FromSlash(rel))
+		}
+		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, hdr.FileInfo().Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(f, r); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		os.Chtimes(dest, hdr.ModTime, hdr.ModTime)
+		restored++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Restored %d files. The files they replaced are in %s.\n", restored, previous)
+	fmt.Println("Restart gitmoni, and gitmoni daemon if it runs, to pick them up.")
+	return nil
+}
+
+// writeArchive writes the files under the archive roots to a gzipped tar
+// archive at name and returns how many it wrote. Roots that don't exist are
+// left out.
+func writeArchive(name string) (int, error) {
+	roots, err := archiveRoots()
+	if err != nil {
+		return 0, err
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	n := 0
+	for _, root := range roots {
+		err := filepath.WalkDir(root.path, func(p string, d fs.DirEntry, err error) error {
+			if errors.Is(err, fs.ErrNotExist) && p == root.path {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(root.path, p)
+			if err != nil {
+				return err
+			}
+			if root.name == "cache" && slices.Contains(skipCache, filepath.ToSlash(rel)) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			hdr, err := tar.FileInfoHeader(info, "")
+			if err != nil {
+				return err
+			}
+			hdr.Name = root.name
+			if rel != "." {
+				hdr.Name = path.Join(root.name, filepath.ToSlash(rel))
+			}
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			src, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+			defer src.Close()
+			if _, err := io.Copy(tw, src); err != nil {
+				return err
+			}
+			n++
+			return nil
+		})
+		if err != nil {
+			return n, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return n, err
+	}
+	if err := gz.Close(); err != nil {
+		return n, err
+	}
+	return n, f.Close()
+}
+
+// readArchive calls fn with each regular file in the gzipped tar archive at
+// name. Names that would be written outside the archive roots are refused.
+func readArchive(name string, fn func(name string, hdr *tar.Header, r io.Reader) error) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("%s isn't a gitmoni backup: %w", name, err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", name, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if !filepath.IsLocal(filepath.FromSlash(hdr.Name)) {
+			return fmt.Errorf("%s contains an unsafe path %q", name, hdr.Name)
+		}
+		if err := fn(hdr.Name, hdr, tr); err != nil {
+			return err
+		}
+	}
+}
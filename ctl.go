@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cwsaylor/gitmoni/internal/control"
+	"github.com/cwsaylor/gitmoni/internal/server"
+	"github.com/cwsaylor/gitmoni/pkg/config"
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// runCtl implements "gitmoni ctl": it sends a command to the running
+// instance's control socket and prints the response.
+func runCtl(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("ctl", flag.ExitOnError)
+	socket := fs.String("socket", "", "Control socket of the instance (overrides control_socket)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gitmoni ctl [--socket path] refresh|fetch [repo] | status [repo]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("missing command")
+	}
+
+	path := control.SocketPath(cfg.ControlSocket)
+	if *socket != "" {
+		path = *socket
+	}
+	if path == "" {
+		return errors.New("the control socket is disabled (control_socket is \"off\")")
+	}
+
+	req := control.Request{Command: fs.Arg(0), Repo: fs.Arg(1)}
+	// Paths such as "." are resolved here, as the instance has its own
+	// working directory
+	if req.Repo == "." || strings.ContainsRune(req.Repo, filepath.Separator) && !gitstatus.IsSSH(req.Repo) {
+		abs, err := filepath.Abs(req.Repo)
+		if err != nil {
+			return err
+		}
+		req.Repo = abs
+	}
+	resp, err := control.Send(path, req)
+	if err != nil {
+		return err
+	}
+	if req.Command == control.Status {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(resp.Repos)
+	}
+	fmt.Println(resp.Message)
+	return nil
+}
+
+// serveControl answers control requests for "gitmoni serve".
+func serveControl(store *gitstatus.Store, srv *server.Server) control.Handler {
+	return func(ctx context.Context, req control.Request) control.Response {
+		repos := srv.Repos
+		if req.Repo != "" {
+			repo, err := server.Lookup(repos, req.Repo)
+			if err != nil {
+				return control.Errorf("%v", err)
+			}
+			repos = []string{repo}
+		}
+
+		resp := control.Response{OK: true}
+		switch req.Command {
+		case control.Status:
+			for _, repo := range repos {
+				status, _ := store.Status(repo)
+				status.Path = repo
+				resp.Repos = append(resp.Repos, server.NewRepo(status))
+			}
+		case control.Refresh:
+			store.RefreshAll(repos)
+			resp.Message = "Refreshed " + repositories(len(repos))
+		case control.Fetch:
+			started := 0
+			for _, repo := range repos {
+				if srv.Fetch(repo) {
+					started++
+				}
+			}
+			resp.Message = "Fetching " + repositories(started)
+		default:
+			return control.Errorf("unknown command %q", req.Command)
+		}
+		return resp
+	}
+}
+
+func repositories(n int) string {
+	if n == 1 {
+		return "1 repository"
+	}
+	return fmt.Sprintf("%d repositories", n)
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/cwsaylor/gitmoni/internal/control"
+	"github.com/cwsaylor/gitmoni/internal/forges"
+	"github.com/cwsaylor/gitmoni/internal/mqtt"
+	"github.com/cwsaylor/gitmoni/internal/notify"
+	"github.com/cwsaylor/gitmoni/internal/repostore"
+	"github.com/cwsaylor/gitmoni/internal/script"
+	"github.com/cwsaylor/gitmoni/internal/server"
+	"github.com/cwsaylor/gitmoni/pkg/config"
+	"github.com/cwsaylor/gitmoni/pkg/forge"
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// runDaemon implements "gitmoni daemon": it fetches and checks the
+// configured repositories on a schedule without the TUI, sends the
+// configured notifications, and saves every status change to the snapshot
+// in the cache directory. "gitmoni daemon install" writes a service
+// definition that starts it at login.
+func runDaemon(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) > 0 && args[0] == "install" {
+		return installDaemon(args[1:])
+	}
+
+	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+	defaultInterval := cfg.DefaultFetchInterval()
+	if defaultInterval == 0 {
+		defaultInterval = 5 * time.Minute
+	}
+	interval := fs.Duration("interval", defaultInterval, "How often to fetch repositories without a fetch_interval of their own")
+	listen := fs.String("listen", "", "Receive push webhooks on this address, e.g. :8091")
+	fs.Parse(args)
+	if *interval < time.Minute {
+		return errors.New("--interval must be at least 1m")
+	}
+	if *listen != "" && hookSecret(cfg) == "" {
+		return errors.New("--listen needs hook_secret or $GITMONI_HOOK_SECRET to validate webhooks")
+	}
+
+	hooks, err := script.Load(cfg)
+	if err != nil {
+		return err
+	}
+	snapshot, err := gitstatus.DefaultSnapshotPath()
+	if err != nil {
+		return err
+	}
+	store := repostore.New(cfg)
+
+	if cfg.GitMaintenance {
+		registered, err := gitstatus.EnableMaintenance(ctx, cfg.Repositories)
+		if len(registered) > 0 {
+			slog.Info("registered repositories with git maintenance", "repos", registered)
+		}
+		if err != nil {
+			slog.Warn("registering repositories with git maintenance failed", "err", err)
+		}
+	}
+
+	// Record the initial statuses without notifying of changes, then report
+	// changes as they arrive. Alert rules that hold are reported as they
+	// start to, including at startup, as the TUI does.
+	notifier := notify.NewNotifier(cfg)
+	observe := func(status gitstatus.Status) []notify.Change {
+		changes := notifier.Observe(status)
+		return append(changes, notifier.Alerts(status.Path, hooks.AlertsFor(status))...)
+	}
+	store.RefreshAll(cfg.Repositories)
+	for _, status := range store.Statuses() {
+		notifier.Send(ctx, notifier.Relevant(observe(status)))
+	}
+	if err := gitstatus.WriteSnapshot(snapshot, store.Statuses()); err != nil {
+		return err
+	}
+	events := store.Subscribe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			batch := events.Next()
+			if batch == nil {
+				return
+			}
+			var changes []notify.Change
+			for _, e := range batch {
+				if e.Removed {
+					notifier.Forget(e.Repo)
+				} else {
+					changes = append(changes, observe(e.Status)...)
+				}
+			}
+			notifier.Send(ctx, notifier.Relevant(changes))
+			if err := gitstatus.WriteSnapshot(snapshot, store.Statuses()); err != nil {
+				slog.Warn("saving status snapshot failed", "path", snapshot, "err", err)
+			}
+		}
+	}()
+
+	mqtt.Start(ctx, cfg, store)
+	srv := server.New(ctx, store, cfg.Repositories, "")
+	srv.AutoPull = cfg.AutoPulls
+	srv.HookSecret = hookSecret(cfg)
+	if notifier.Wants(notify.CIFailed) {
+		// Query CI after each fetch, as the TUI does, so failures are
+		// reported without it
+		ci := &ciWatch{cfg: cfg, store: store, forges: forges.New(cfg), states: map[string]string{}}
+		srv.Fetched = func(ctx context.Context, repo string) {
+			notifier.Send(ctx, ci.check(ctx, repo))
+		}
+	}
+	if path := control.SocketPath(cfg.ControlSocket); path != "" {
+		ln, err := control.Listen(path)
+		if err != nil {
+			slog.Warn("control socket unavailable", "path", path, "err", err)
+		} else {
+			defer ln.Close()
+			go control.Serve(ctx, ln, serveControl(store, srv))
+		}
+	}
+
+	if *listen != "" {
+		ln, err := net.Listen("tcp", *listen)
+		if err != nil {
+			return err
+		}
+		hooks := &http.Server{Handler: srv.HooksHandler(), ReadHeaderTimeout: 10 * time.Second}
+		defer hooks.Close()
+		go hooks.Serve(ln)
+		slog.Info("receiving push webhooks", "addr", ln.Addr().String())
+	}
+
+	slog.Info("daemon started", "repos", len(cfg.Repositories), "interval", interval.String(), "snapshot", snapshot)
+	scheduler := gitstatus.NewFetchScheduler(*interval, func(repo string) gitstatus.FetchPlan {
+		interval, quiet := cfg.FetchSchedule(repo)
+		return gitstatus.FetchPlan{Interval: interval, Quiet: quiet.Contains}
+	})
+	ticker := time.NewTicker(gitstatus.SchedulerTick)
+	defer ticker.Stop()
+	for {
+		var due []string
+		for _, repo := range scheduler.Due(cfg.Repositories, time.Now()) {
+			if status, _ := store.Status(repo); hooks.AllowFetch(status) {
+				due = append(due, repo)
+			} else {
+				slog.Debug("fetch skipped by fetch_script", "repo", repo)
+			}
+		}
+		srv.FetchBatch(due)
+		select {
+		case <-ctx.Done():
+			// Save the results of fetches that were cancelled or finished
+			// after the last batch of events was handled
+			srv.Wait()
+			events.Close()
+			<-done
+			slog.Info("daemon stopped")
+			return gitstatus.WriteSnapshot(snapshot, store.Statuses())
+		case <-ticker.C:
+		}
+	}
+}
+
+// ciWatch queries the CI of each repository's current branch and reports
+// runs that start failing. It's used from the fetch goroutines, so its
+// states are guarded by mu.
+type ciWatch struct {
+	cfg    *config.Config
+	store  *gitstatus.Store
+	forges *forge.Resolver
+
+	mu     sync.Mutex
+	states map[string]string // the last CI state of each repo
+}
+
+// check queries repo's CI and returns a ci_failed change if it has started
+// failing. The first state seen for a repo is only recorded.
+func (w *ciWatch) check(ctx context.Context, repo string) []notify.Change {
+	status, ok := w.store.Status(repo)
+	if !ok || status.Branch == "" {
+		return nil
+	}
+	url, err := gitstatus.RemoteURL(repo, w.cfg.Remote(repo))
+	if err != nil {
+		return nil
+	}
+	remote, ok := forge.ParseRemote(url)
+	if !ok {
+		return nil
+	}
+	provider := w.forges.Provider(remote)
+	if provider == nil {
+		return nil
+	}
+	ci, err := provider.CI(ctx, remote, status.Branch)
+	if err != nil {
+		slog.Warn("forge query failed", "repo", repo, "err", err)
+		return nil
+	}
+
+	w.mu.Lock()
+	prev, seen := w.states[repo]
+	w.states[repo] = ci.State
+	w.mu.Unlock()
+	if !seen || prev == "failure" || ci.State != "failure" {
+		return nil
+	}
+	return []notify.Change{{
+		Kind:    notify.CIFailed,
+		Repo:    repo,
+		Message: fmt.Sprintf("%s failed on %s %s", ci.Name, status.Branch, ci.URL),
+	}}
+}
+
+// installDaemon writes a systemd user unit on Linux or a launchd agent on
+// macOS that runs "gitmoni daemon" at login.
+func installDaemon(args []string) error {
+	fs := flag.NewFlagSet("daemon install", flag.ExitOnError)
+	interval := fs.Duration("interval", 0, "How often the daemon fetches repositories without a fetch_interval of their own; 0 for fetch_interval, or 5m")
+	printOnly := fs.Bool("print", false, "Print the service definition instead of installing it")
+	fs.Parse(args)
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return err
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+
+	var path, next string
+	var tmpl *template.Template
+	switch runtime.GOOS {
+	case "linux":
+		configDir, err := os.UserConfigDir()
+		if err != nil {
+			return err
+		}
+		path = filepath.Join(configDir, "systemd", "user", "gitmoni.service")
+		tmpl = systemdUnit
+		next = "systemctl --user daemon-reload && systemctl --user enable --now gitmoni"
+	case "darwin":
+		path = filepath.Join(home, "Library", "LaunchAgents", launchdLabel+".plist")
+		tmpl = launchdPlist
+		next = "launchctl load -w " + path
+	default:
+		return fmt.Errorf("installing a service is not supported on %s; run \"gitmoni daemon\" at login instead", runtime.GOOS)
+	}
+
+	var def strings.Builder
+	// Without --interval the daemon follows fetch_interval as it changes
+	intervalArg := ""
+	if *interval > 0 {
+		intervalArg = interval.String()
+	}
+	if err := tmpl.Execute(&def, map[string]string{
+		"Exe":      exe,
+		"Interval": intervalArg,
+		"Label":    launchdLabel,
+		"Log":      filepath.Join(home, "Library", "Logs", "gitmoni.log"),
+	}); err != nil {
+		return err
+	}
+	if *printOnly {
+		fmt.Print(def.String())
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(def.String()), 0o644); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s\nStart it with: %s\n", path, next)
+	return nil
+}
+
+const launchdLabel = "com.github.cwsaylor.gitmoni"
+
+var systemdUnit = template.Must(template.New("systemd").Parse(`[Unit]
+Description=gitmoni repository monitor
+After=network-online.target
+
+[Service]
+ExecStart="{{.Exe}}" daemon{{if .Interval}} --interval {{.Interval}}{{end}}
+Restart=on-failure
+RestartSec=30
+
+[Install]
+WantedBy=default.target
+`))
+
+var launchdPlist = template.Must(template.New("launchd").Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>{{.Label}}</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>{{.Exe}}</string>
+		<string>daemon</string>
+		{{- if .Interval}}
+		<string>--interval</string>
+		<string>{{.Interval}}</string>
+		{{- end}}
+	</array>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+	<key>StandardErrorPath</key>
+	<string>{{.Log}}</string>
+</dict>
+</plist>
+`))
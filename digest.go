@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cwsaylor/gitmoni/internal/digest"
+	"github.com/cwsaylor/gitmoni/internal/repostore"
+	"github.com/cwsaylor/gitmoni/pkg/config"
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// runDigest implements "gitmoni digest": it writes an HTML summary of the
+// configured repositories to stdout or, with --email, mails it using the
+// SMTP settings in cfg. It is meant to be run from cron.
+func runDigest(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("digest", flag.ExitOnError)
+	email := fs.Bool("email", false, "Email the digest instead of printing it")
+	to := fs.String("to", "", "Comma-separated recipients (overrides email_to)")
+	fetch := fs.Bool("fetch", false, "Fetch every repository first")
+	fs.Parse(args)
+
+	if *fetch {
+		fetchAll(ctx, cfg)
+	}
+	staleAfter := time.Duration(cfg.StaleBranchDays) * 24 * time.Hour
+	repos := digest.Collect(cfg.Repositories, staleAfter, func(repo string) []string { return cfg.Settings(repo).Ignore }, cfg.HidesUntracked)
+
+	var body strings.Builder
+	if err := digest.WriteHTML(&body, repos); err != nil {
+		return err
+	}
+	if !*email {
+		fmt.Print(body.String())
+		return nil
+	}
+
+	server := digest.SMTP{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		From:     cfg.EmailFrom,
+		To:       cfg.EmailTo,
+	}
+	if server.Password == "" {
+		server.Password = os.Getenv("GITMONI_SMTP_PASSWORD")
+	}
+	if *to != "" {
+		server.To = strings.Split(*to, ",")
+	}
+
+	attention := 0
+	for _, r := range repos {
+		if r.NeedsAttention() {
+			attention++
+		}
+	}
+	subject := fmt.Sprintf("gitmoni digest: %d of %d repositories need attention", attention, len(repos))
+	if err := digest.Send(server, subject, body.String()); err != nil {
+		return err
+	}
+	slog.Info("digest sent", "to", server.To, "repos", len(repos))
+	return nil
+}
+
+// fetchAll fetches the configured repos concurrently, spread out by host,
+// logging failures; the digest then reports the repos' state as of the
+// failed fetch.
+func fetchAll(ctx context.Context, cfg *config.Config) {
+	limiter := gitstatus.NewFetchLimiter(cfg.FetchHostLimit, time.Duration(cfg.FetchJitterSeconds)*time.Second)
+	if r := repostore.Runner(cfg); r != nil {
+		ctx = gitstatus.WithRunner(ctx, r)
+	}
+	var wg sync.WaitGroup
+	for _, repo := range cfg.Repositories {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			done, err := limiter.Wait(ctx, repo)
+			if err == nil {
+				err = gitstatus.Fetch(ctx, repo)
+				done(err)
+			}
+			if err != nil && ctx.Err() == nil {
+				slog.Warn("fetch failed", "repo", repo, "err", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cwsaylor/gitmoni/internal/timefmt"
+	"github.com/cwsaylor/gitmoni/pkg/config"
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// runHistory implements "gitmoni history": it prints the state changes
+// recorded for every repository, or for one, oldest first.
+func runHistory(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	since := fs.String("since", "", "Only show events since this long ago (e.g. 7d, 12h) or this date (2006-01-02)")
+	kind := fs.String("kind", "", "Only show events of this kind, e.g. fetch_failed")
+	asJSON := fs.Bool("json", false, "Print the events as JSON lines")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gitmoni history [--since 7d] [--kind kind] [--json] [repo]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	var from time.Time
+	if *since != "" {
+		var err error
+		if from, err = parseSince(*since); err != nil {
+			return err
+		}
+	}
+	repo := ""
+	if fs.NArg() > 0 {
+		repo = historyRepo(cfg.Repositories, fs.Arg(0))
+	}
+
+	path, err := gitstatus.DefaultHistoryPath()
+	if err != nil {
+		return err
+	}
+	events, err := gitstatus.ReadHistory(path)
+	if err != nil {
+		return err
+	}
+
+	// Listings show dates and times in the configured style, but never
+	// relative ones, padded to the widest
+	tf := timefmt.New(cfg.TimeFormat, cfg.Clock)
+	width := len(tf.DateTime(time.Date(2000, 12, 30, 23, 59, 0, 0, time.Local)))
+	enc := json.NewEncoder(os.Stdout)
+	for _, e := range events {
+		if e.Time.Before(from) || repo != "" && e.Repo != repo || *kind != "" && e.Kind != *kind {
+			continue
+		}
+		if *asJSON {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+			continue
+		}
+		line := fmt.Sprintf("%-*s  %-20s  %-21s", width, tf.DateTime(e.Time), filepath.Base(e.Repo), gitstatus.DescribeHistoryKind(e.Kind))
+		if e.Detail != "" {
+			line += "  " + e.Detail
+		}
+		fmt.Println(strings.TrimRight(line, " "))
+	}
+	return nil
+}
+
+// historyRepo resolves the repository argument to the path events are
+// recorded under: a configured repository's name or path, or a path such
+// as ".", which needn't be configured anymore.
+func historyRepo(repos []string, arg string) string {
+	for _, repo := range repos {
+		if repo == arg || filepath.Base(repo) == arg {
+			return repo
+		}
+	}
+	if gitstatus.IsSSH(arg) {
+		return arg
+	}
+	if abs, err := filepath.Abs(arg); err == nil {
+		return abs
+	}
+	return arg
+}
+
+// parseSince parses a time given as a duration before now, such as "7d",
+// "2w", or "12h", or as a date such as "2006-01-02".
+func parseSince(s string) (time.Time, error) {
+	if t, err := time.ParseInLocation(time.DateOnly, s, time.Local); err == nil {
+		return t, nil
+	}
+	unit := map[byte]time.Duration{'d': 24 * time.Hour, 'w': 7 * 24 * time.Hour}[s[len(s)-1]]
+	if unit != 0 {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil || n < 0 {
+			return time.Time{}, fmt.Errorf("invalid --since %q", s)
+		}
+		return time.Now().Add(-time.Duration(n) * unit), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("invalid --since %q: use e.g. 7d, 12h, or 2006-01-02", s)
+	}
+	return time.Now().Add(-d), nil
+}
@@ -0,0 +1,156 @@
+// Package control lets scripts drive a running gitmoni instance through a
+// Unix socket, e.g. from an editor or a git hook. Each connection carries
+// one JSON request and its JSON response, each on a line of its own:
+//
+//	{"command": "refresh", "repo": "gitmoni"}
+//	{"ok": true, "message": "Refreshed 1 repository"}
+//
+// The commands are "refresh" and "fetch", for one repository or, without
+// repo, all of them, and "status", which returns every repository's status.
+package control
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cwsaylor/gitmoni/internal/crash"
+	"github.com/cwsaylor/gitmoni/internal/server"
+)
+
+// Commands understood by instances.
+const (
+	Refresh = "refresh"
+	Fetch   = "fetch"
+	Status  = "status"
+)
+
+// Timeout bounds a request, from connecting to reading the response.
+const Timeout = 10 * time.Second
+
+// ErrInUse is returned by Listen if another instance is listening on the
+// socket.
+var ErrInUse = errors.New("control socket is in use by another gitmoni instance")
+
+// Request is a command sent to an instance.
+type Request struct {
+	Command string `json:"command"`
+	Repo    string `json:"repo,omitempty"` // path or directory name; empty for all
+}
+
+// Response is an instance's answer to a request.
+type Response struct {
+	OK      bool          `json:"ok"`
+	Error   string        `json:"error,omitempty"`
+	Message string        `json:"message,omitempty"`
+	Repos   []server.Repo `json:"repos,omitempty"`
+}
+
+// Errorf returns a failed response.
+func Errorf(format string, args ...any) Response {
+	return Response{Error: fmt.Sprintf(format, args...)}
+}
+
+// Handler answers requests.
+type Handler func(ctx context.Context, req Request) Response
+
+// DefaultSocket returns the socket path used when none is configured:
+// gitmoni.sock in $XDG_RUNTIME_DIR, or a per-user file in the temporary
+// directory.
+func DefaultSocket() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "gitmoni.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("gitmoni-%d.sock", os.Getuid()))
+}
+
+// SocketPath returns the socket path for the control_socket setting:
+// DefaultSocket if it is empty, or "" if it is "off".
+func SocketPath(setting string) string {
+	switch setting {
+	case "":
+		return DefaultSocket()
+	case "off":
+		return ""
+	}
+	return setting
+}
+
+// Listen listens on the socket at path. A socket left behind by an instance
+// that exited is replaced; one that is still answering is not.
+func Listen(path string) (net.Listener, error) {
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return nil, ErrInUse
+	}
+	os.Remove(path)
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	// Only the user may control the instance
+	if err := os.Chmod(path, 0o600); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+// Serve answers requests on ln with h until ln is closed.
+func Serve(ctx context.Context, ln net.Listener, h Handler) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer crash.Capture()
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(Timeout))
+
+			var resp Response
+			var req Request
+			line, err := bufio.NewReader(conn).ReadBytes('\n')
+			if err == nil {
+				err = json.Unmarshal(line, &req)
+			}
+			if err != nil {
+				resp = Errorf("invalid request: %v", err)
+			} else {
+				slog.Debug("control request", "command", req.Command, "repo", req.Repo)
+				resp = h(ctx, req)
+			}
+			json.NewEncoder(conn).Encode(resp)
+		}()
+	}
+}
+
+// Send sends req to the instance listening at path and returns its
+// response. A response reporting failure is returned as an error.
+func Send(path string, req Request) (Response, error) {
+	conn, err := net.DialTimeout("unix", path, Timeout)
+	if err != nil {
+		return Response{}, fmt.Errorf("no running gitmoni instance at %s: %w", path, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(Timeout))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return Response{}, err
+	}
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return Response{}, err
+	}
+	if !resp.OK {
+		return resp, errors.New(resp.Error)
+	}
+	return resp, nil
+}
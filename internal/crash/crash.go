@@ -0,0 +1,74 @@
+// Package crash records panics to a log file, so the stack trace survives
+// even when the panic happens while the terminal is in raw mode and nothing
+// printed to it is visible.
+package crash
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+var (
+	mu       sync.Mutex
+	lastPath string
+)
+
+// Path returns the crash log location: gitmoni/crash.log in the user's cache
+// directory, or in the temporary directory if there is none.
+func Path() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "gitmoni", "crash.log")
+}
+
+// Capture records a panic in progress and then continues panicking, so the
+// usual recovery (such as Bubble Tea restoring the terminal) still runs. It
+// must be called directly by a deferred statement:
+//
+//	defer crash.Capture()
+func Capture() {
+	if r := recover(); r != nil {
+		Write(r, debug.Stack())
+		panic(r)
+	}
+}
+
+// Write appends a crash report for the panic value r with its stack trace to
+// the crash log. Only the first report is written, since a panic that is
+// re-raised passes through several recovery points.
+func Write(r any, stack []byte) {
+	mu.Lock()
+	defer mu.Unlock()
+	if lastPath != "" {
+		return
+	}
+
+	path := Path()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	fmt.Fprintf(f, "=== gitmoni crash at %s (%s %s/%s)\n", time.Now().Format(time.RFC3339), runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(f, "panic: %v\n\n%s\n", r, stack)
+	lastPath = path
+}
+
+// Written returns the path of the crash log if a crash has been recorded
+// during this run, or "" otherwise.
+func Written() string {
+	mu.Lock()
+	defer mu.Unlock()
+	return lastPath
+}
@@ -0,0 +1,201 @@
+// Package digest builds and emails a summary of the monitored repositories:
+// their uncommitted changes, how far they are ahead of or behind their
+// upstreams, and branches that look abandoned.
+package digest
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"html/template"
+	"io"
+	"net"
+	"net/smtp"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// Repo is a repository's entry in the digest.
+type Repo struct {
+	Path   string
+	Name   string
+	Branch string
+	Error  string
+	Files  []gitstatus.File
+	Ahead  int
+	Behind int
+	Stale  []gitstatus.LocalBranch // branches with a gone upstream or no recent commits
+}
+
+// NeedsAttention reports whether r has anything to act on.
+func (r Repo) NeedsAttention() bool {
+	return r.Error != "" || len(r.Files) > 0 || r.Ahead > 0 || r.Behind > 0 || len(r.Stale) > 0
+}
+
+// Collect checks each repository and summarises it, leaving out the
+// changed files that match the patterns ignore returns for it, and its
+// untracked files if untracked reports it. Branches
+// whose upstream was deleted, or without commits in staleAfter, are listed
+// as stale; the checked-out branch never is.
+func Collect(repos []string, staleAfter time.Duration, ignore func(repo string) []string, untracked func(repo string) bool) []Repo {
+	statuses := gitstatus.CheckAll(repos)
+	var summary []Repo
+	for _, path := range repos {
+		status := statuses[path]
+		status.Ignore(ignore(path))
+		if untracked(path) {
+			status.IgnoreUntracked()
+		}
+		r := Repo{Path: path, Name: filepath.Base(path), Branch: status.Branch, Files: status.Files}
+		if status.HasError {
+			r.Error = status.Error
+			summary = append(summary, r)
+			continue
+		}
+		branches, err := gitstatus.LocalBranches(path)
+		if err != nil {
+			r.Error = gitstatus.ErrorSummary(err)
+		}
+		for _, b := range branches {
+			switch {
+			case b.Name == status.Branch:
+				r.Ahead, r.Behind = b.Ahead, b.Behind
+			case b.Gone || time.Since(b.Date) > staleAfter:
+				r.Stale = append(r.Stale, b)
+			}
+		}
+		summary = append(summary, r)
+	}
+	return summary
+}
+
+// maxFiles bounds the changed files listed per repository.
+const maxFiles = 10
+
+var page = template.Must(template.New("digest").Funcs(template.FuncMap{
+	"age": func(t time.Time) string {
+		return strconv.Itoa(int(time.Since(t).Hours()/24)) + "d"
+	},
+	"head": func(files []gitstatus.File) []gitstatus.File {
+		return files[:min(len(files), maxFiles)]
+	},
+	"more": func(files []gitstatus.File) int {
+		return max(len(files)-maxFiles, 0)
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: -apple-system, Segoe UI, Helvetica, Arial, sans-serif; color: #303446; max-width: 760px;">
+<h2>gitmoni digest &mdash; {{.Date}}</h2>
+<p>{{.Attention}} of {{len .Repos}} repositories need attention.</p>
+<table cellpadding="6" style="border-collapse: collapse; width: 100%;">
+<tr style="background: #e5e8ef; text-align: left;"><th>Repository</th><th>Branch</th><th>Changes</th><th>Ahead</th><th>Behind</th></tr>
+{{range .Repos}}
+<tr style="border-top: 1px solid #c6d0f5;">
+<td><b>{{.Name}}</b><br><small style="color: #737994;">{{.Path}}</small></td>
+{{if .Error}}<td colspan="4" style="color: #e78284;">{{.Error}}</td>{{else}}
+<td>{{.Branch}}</td>
+<td{{if .Files}} style="color: #ef9f76;"{{end}}>{{len .Files}}</td>
+<td{{if .Ahead}} style="color: #8caaee;"{{end}}>{{.Ahead}}</td>
+<td{{if .Behind}} style="color: #e5c890;"{{end}}>{{.Behind}}</td>{{end}}
+</tr>
+{{if or .Files .Stale}}<tr><td colspan="5" style="padding-left: 24px;"><small>
+{{range head .Files}}<code>{{.Status}} {{.Path}}</code><br>{{end}}
+{{with more .Files}}&hellip; and {{.}} more<br>{{end}}
+{{if .Stale}}Stale branches: {{range $i, $b := .Stale}}{{if $i}}, {{end}}<code>{{$b.Name}}</code> ({{if $b.Gone}}upstream gone{{else}}{{age $b.Date}}{{end}}){{end}}{{end}}
+</small></td></tr>{{end}}
+{{end}}
+</table>
+</body>
+</html>
+`))
+
+// WriteHTML renders repos as an HTML page.
+func WriteHTML(w io.Writer, repos []Repo) error {
+	attention := 0
+	for _, r := range repos {
+		if r.NeedsAttention() {
+			attention++
+		}
+	}
+	return page.Execute(w, struct {
+		Date      string
+		Attention int
+		Repos     []Repo
+	}{time.Now().Format("Monday, January 2, 2006"), attention, repos})
+}
+
+// SMTP describes the mail server and addresses a digest is sent with.
+type SMTP struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// Send emails the HTML body to s.To. Port 465 uses implicit TLS; other
+// ports upgrade with STARTTLS when the server offers it. Without a username
+// no authentication is attempted.
+func Send(s SMTP, subject, body string) error {
+	if s.Host == "" || s.From == "" || len(s.To) == 0 {
+		return fmt.Errorf("smtp_host, email_from, and email_to must be set")
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", s.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(s.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/html; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+	if s.Port != 465 {
+		return smtp.SendMail(addr, auth, s.From, s.To, msg.Bytes())
+	}
+
+	conn, err := tls.Dial("tcp", addr, &tls.Config{ServerName: s.Host})
+	if err != nil {
+		return err
+	}
+	client, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if auth != nil {
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(s.From); err != nil {
+		return err
+	}
+	for _, to := range s.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg.Bytes()); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
@@ -0,0 +1,74 @@
+// Package forges sets up the hosting services that the TUI and the daemon
+// query for pull requests and CI, as the config asks.
+package forges
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/cwsaylor/gitmoni/pkg/config"
+	"github.com/cwsaylor/gitmoni/pkg/forge"
+)
+
+// New returns the resolver for the hosting services enabled in cfg.
+// Besides the configured instances, self-hosted ones are detected from
+// remote host names. GitHub is skipped if no credentials are available.
+func New(cfg *config.Config) *forge.Resolver {
+	// All providers share the transport, so the limits apply per host
+	// however many instances talk to it
+	client := &http.Client{Transport: forge.NewTransport(
+		cfg.ForgeRequestsPerMinute,
+		time.Duration(cfg.ForgeCacheSeconds)*time.Second,
+	)}
+
+	var github *forge.GitHub
+	if cfg.GitHubPullRequests {
+		if github, _ = forge.NewGitHub(cfg.GitHubToken); github != nil {
+			github.Client = client
+		}
+	}
+
+	res := &forge.Resolver{}
+	for _, h := range cfg.ForgeHosts {
+		res.Hosts = append(res.Hosts, forge.Host{Pattern: h.Host, Kind: h.Kind, BaseURL: h.URL, WebURL: h.WebURL})
+	}
+	if github != nil {
+		res.Providers = append(res.Providers, github)
+	}
+	if cfg.GitLabMergeRequests {
+		gitlab := forge.NewGitLab(cfg.GitLabURL, cfg.GitLabToken)
+		gitlab.Client = client
+		res.Providers = append(res.Providers, gitlab)
+	}
+	if cfg.BitbucketPullRequests {
+		bitbucket := forge.NewBitbucket(cfg.BitbucketToken)
+		bitbucket.Client = client
+		res.Providers = append(res.Providers, bitbucket)
+	}
+	if cfg.GiteaPullRequests {
+		gitea := forge.NewGitea(cfg.GiteaURL, cfg.GiteaToken)
+		gitea.Client = client
+		res.Providers = append(res.Providers, gitea)
+	}
+
+	res.New = func(kind, baseURL string) forge.Provider {
+		switch {
+		case kind == forge.KindGitHub && cfg.GitHubPullRequests:
+			// GitHub Enterprise Server
+			if github, ok := forge.NewGitHubEnterprise(baseURL, cfg.GitHubToken); ok {
+				github.Client = client
+				return github
+			}
+		case kind == forge.KindGitLab && cfg.GitLabMergeRequests:
+			gitlab := forge.NewGitLab(baseURL, cfg.GitLabToken)
+			gitlab.Client = client
+			return gitlab
+		case kind == forge.KindGitea && cfg.GiteaPullRequests:
+			gitea := forge.NewGitea(baseURL, cfg.GiteaToken)
+			gitea.Client = client
+			return gitea
+		}
+		return nil
+	}
+	return res
+}
@@ -0,0 +1,209 @@
+// Package logging sets up gitmoni's structured log: a log/slog logger that
+// writes to a size-rotated file, so the terminal UI is never written over.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Rotation limits: the log file is rotated when it reaches maxSize, and up
+// to keep of the rotated files (gitmoni.log.1, gitmoni.log.2, ...) are
+// kept.
+const (
+	maxSize = 5 << 20
+	keep    = 3
+)
+
+// DefaultFile returns gitmoni/gitmoni.log in the user's cache directory.
+func DefaultFile() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "gitmoni", "gitmoni.log")
+}
+
+// ParseLevel parses a level name: "debug", "info", "warn", "error", or
+// "off". It reports false for "off".
+func ParseLevel(name string) (slog.Level, bool, error) {
+	if strings.EqualFold(name, "off") {
+		return 0, false, nil
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, false, fmt.Errorf("invalid log level %q (want debug, info, warn, error, or off)", name)
+	}
+	return level, true, nil
+}
+
+// Hold makes the default slog logger keep records in memory until Setup
+// writes them to the log. It is for the records of what runs before the log
+// can be set up, such as loading the config that says where it goes, which
+// would otherwise be written to stderr, over the terminal UI.
+func Hold() {
+	slog.SetDefault(slog.New(heldHandler{held: &held{}}))
+}
+
+// Setup makes the default slog logger write records at level and above to
+// file, or DefaultFile if file is empty, starting with those kept since
+// Hold. A level of "off" discards all records. The returned closer closes
+// the log file.
+func Setup(level, file string) (io.Closer, error) {
+	lvl, enabled, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled {
+		slog.SetDefault(slog.New(slog.DiscardHandler))
+		return io.NopCloser(nil), nil
+	}
+
+	if file == "" {
+		file = DefaultFile()
+	}
+	w, err := openRotating(file)
+	if err != nil {
+		return nil, err
+	}
+	h := slog.NewTextHandler(w, &slog.HandlerOptions{Level: lvl})
+	if prev, ok := slog.Default().Handler().(heldHandler); ok {
+		prev.held.replay(h)
+	}
+	slog.SetDefault(slog.New(h))
+	return w, nil
+}
+
+// held is the records a heldHandler and those derived from it keep.
+type held struct {
+	mu      sync.Mutex
+	records []heldRecord
+}
+
+type heldRecord struct {
+	with   []func(slog.Handler) slog.Handler // the WithAttrs and WithGroup calls made on the handler
+	record slog.Record
+}
+
+// replay passes the records kept to h.
+func (k *held) replay(h slog.Handler) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for _, r := range k.records {
+		target := h
+		for _, with := range r.with {
+			target = with(target)
+		}
+		if target.Enabled(context.Background(), r.record.Level) {
+			target.Handle(context.Background(), r.record)
+		}
+	}
+	k.records = nil
+}
+
+// heldHandler is a slog.Handler that keeps records for Setup to replay.
+type heldHandler struct {
+	held *held
+	with []func(slog.Handler) slog.Handler
+}
+
+func (h heldHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h heldHandler) Handle(_ context.Context, r slog.Record) error {
+	h.held.mu.Lock()
+	defer h.held.mu.Unlock()
+	h.held.records = append(h.held.records, heldRecord{with: h.with, record: r.Clone()})
+	return nil
+}
+
+func (h heldHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.derive(func(t slog.Handler) slog.Handler { return t.WithAttrs(attrs) })
+}
+
+func (h heldHandler) WithGroup(name string) slog.Handler {
+	return h.derive(func(t slog.Handler) slog.Handler { return t.WithGroup(name) })
+}
+
+func (h heldHandler) derive(with func(slog.Handler) slog.Handler) heldHandler {
+	return heldHandler{held: h.held, with: append(slices.Clip(h.with), with)}
+}
+
+// rotatingFile is an append-only log file that is renamed aside once it
+// grows past maxSize.
+type rotatingFile struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+	size int64
+}
+
+func openRotating(path string) (*rotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	r := &rotatingFile{path: path}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.f, r.size = f, info.Size()
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return 0, os.ErrClosed
+	}
+	if r.size+int64(len(p)) > maxSize && r.size > 0 {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate shifts gitmoni.log.N to gitmoni.log.N+1, dropping the oldest, and
+// starts a new file.
+func (r *rotatingFile) rotate() error {
+	r.f.Close()
+	r.f = nil
+	for i := keep - 1; i > 0; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+	}
+	os.Rename(r.path, r.path+".1")
+	return r.open()
+}
+
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
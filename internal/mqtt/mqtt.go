@@ -0,0 +1,220 @@
+// Package mqtt publishes the state of each monitored repository to an MQTT
+// broker, one retained message per repository, so home automation and
+// dashboards can react to it. Only what publishing needs of MQTT 3.1.1 is
+// implemented: connecting, with a username and password or over TLS, and
+// QoS 0 publishing.
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// Timeout bounds connecting to the broker and each write.
+const Timeout = 10 * time.Second
+
+// Client publishes messages to a broker, reconnecting when the connection
+// is lost. A Client is safe for concurrent use.
+type Client struct {
+	URL      string // mqtt://[user:password@]host[:1883] or mqtts://...[:8883]
+	ClientID string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewClient returns a client for the broker at rawURL. It connects on the
+// first Publish.
+func NewClient(rawURL string) (*Client, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "mqtt" && u.Scheme != "mqtts" || u.Host == "" {
+		return nil, fmt.Errorf("invalid MQTT broker URL %q: want mqtt://host[:port] or mqtts://host[:port]", rawURL)
+	}
+	host, _ := os.Hostname()
+	return &Client{URL: rawURL, ClientID: fmt.Sprintf("gitmoni-%s-%d", host, os.Getpid())}, nil
+}
+
+// Publish sends payload to topic. Retained messages are kept by the broker
+// and delivered to clients that subscribe later.
+func (c *Client) Publish(ctx context.Context, topic string, payload []byte, retain bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	packet := publishPacket(topic, payload, retain)
+	// A connection the broker closed only fails on write, so retry once on
+	// a fresh one
+	for attempt := 0; ; attempt++ {
+		if c.conn == nil {
+			conn, err := c.connect(ctx)
+			if err != nil {
+				return err
+			}
+			c.conn = conn
+		}
+		c.conn.SetWriteDeadline(time.Now().Add(Timeout))
+		_, err := c.conn.Write(packet)
+		if err == nil {
+			return nil
+		}
+		c.conn.Close()
+		c.conn = nil
+		if attempt > 0 {
+			return err
+		}
+	}
+}
+
+// Close disconnects from the broker.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(Timeout))
+	c.conn.Write([]byte{0xe0, 0}) // DISCONNECT
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// connect dials the broker and completes the MQTT handshake.
+func (c *Client) connect(ctx context.Context) (net.Conn, error) {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return nil, err
+	}
+	port := u.Port()
+	if port == "" {
+		port = "1883"
+		if u.Scheme == "mqtts" {
+			port = "8883"
+		}
+	}
+	addr := net.JoinHostPort(u.Hostname(), port)
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+	var conn net.Conn
+	if u.Scheme == "mqtts" {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: u.Hostname()}}
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var username, password string
+	if u.User != nil {
+		username = u.User.Username()
+		password, _ = u.User.Password()
+	}
+	conn.SetDeadline(time.Now().Add(Timeout))
+	if _, err := conn.Write(connectPacket(c.ClientID, username, password)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	var ack [4]byte
+	if _, err := io.ReadFull(conn, ack[:]); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("mqtt: reading CONNACK: %w", err)
+	}
+	if ack[0] != 0x20 {
+		conn.Close()
+		return nil, errors.New("mqtt: unexpected response to CONNECT")
+	}
+	if ack[3] != 0 {
+		conn.Close()
+		return nil, fmt.Errorf("mqtt: connection refused: %s", refused(ack[3]))
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// refused describes a CONNACK return code.
+func refused(code byte) string {
+	switch code {
+	case 1:
+		return "unsupported protocol version"
+	case 2:
+		return "client identifier rejected"
+	case 3:
+		return "server unavailable"
+	case 4:
+		return "bad user name or password"
+	case 5:
+		return "not authorized"
+	}
+	return fmt.Sprintf("return code %d", code)
+}
+
+// connectPacket returns a CONNECT packet starting a clean session. The keep
+// alive is disabled, as lost connections are noticed when publishing.
+func connectPacket(clientID, username, password string) []byte {
+	var flags byte = 0x02 // clean session
+	body := appendString(nil, "MQTT")
+	body = append(body, 4) // protocol level 3.1.1
+	if username != "" {
+		flags |= 0x80
+		if password != "" {
+			flags |= 0x40
+		}
+	}
+	body = append(body, flags, 0, 0) // keep alive 0
+	body = appendString(body, clientID)
+	if username != "" {
+		body = appendString(body, username)
+		if password != "" {
+			body = appendString(body, password)
+		}
+	}
+	return packet(0x10, body)
+}
+
+// publishPacket returns a QoS 0 PUBLISH packet.
+func publishPacket(topic string, payload []byte, retain bool) []byte {
+	var header byte = 0x30
+	if retain {
+		header |= 0x01
+	}
+	return packet(header, append(appendString(nil, topic), payload...))
+}
+
+// packet prefixes body with the fixed header: the packet type and flags and
+// the body's length as a variable-length integer.
+func packet(header byte, body []byte) []byte {
+	p := []byte{header}
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		p = append(p, b)
+		if n == 0 {
+			break
+		}
+	}
+	return append(p, body...)
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
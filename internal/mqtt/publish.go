@@ -0,0 +1,131 @@
+package mqtt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"github.com/cwsaylor/gitmoni/internal/crash"
+	"github.com/cwsaylor/gitmoni/pkg/config"
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// DefaultPrefix is the topic prefix used when none is configured.
+const DefaultPrefix = "gitmoni"
+
+// State is the message published for a repository.
+type State struct {
+	Path    string `json:"path"`
+	Name    string `json:"name"`
+	Branch  string `json:"branch"`
+	State   string `json:"state"`   // "error", "dirty", "unpushed", "behind", or "clean"
+	Changed int    `json:"changed"` // changed files
+	Ahead   int    `json:"ahead"`
+	Behind  int    `json:"behind"`
+	Error   string `json:"error,omitempty"`
+}
+
+// NewState returns the message for status. Its State is the most pressing
+// thing about the repository, for automations that only look at one field.
+func NewState(status gitstatus.Status) State {
+	s := State{
+		Path:    status.Path,
+		Name:    filepath.Base(status.Path),
+		Branch:  status.Branch,
+		Changed: len(status.Files),
+		Ahead:   status.Ahead,
+		Behind:  status.Behind,
+		Error:   status.Error,
+	}
+	switch {
+	case status.HasError:
+		s.State = "error"
+	case len(status.Files) > 0:
+		s.State = "dirty"
+	case status.Ahead > 0:
+		s.State = "unpushed"
+	case status.NeedsPull:
+		s.State = "behind"
+	default:
+		s.State = "clean"
+	}
+	return s
+}
+
+// Topic returns the topic of repo's messages: prefix/name, with the
+// characters MQTT reserves for wildcards replaced.
+func Topic(prefix, repo string) string {
+	name := strings.NewReplacer("+", "_", "#", "_").Replace(filepath.Base(repo))
+	return strings.TrimSuffix(prefix, "/") + "/" + name
+}
+
+// Start publishes the statuses in store to the broker configured in cfg, if
+// any, in the background until ctx is done.
+func Start(ctx context.Context, cfg *config.Config, store *gitstatus.Store) {
+	if cfg.MQTTURL == "" {
+		return
+	}
+	c, err := NewClient(cfg.MQTTURL)
+	if err != nil {
+		slog.Warn("mqtt publishing disabled", "err", err)
+		return
+	}
+	prefix := cfg.MQTTTopic
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	go Run(ctx, c, prefix, store)
+}
+
+// Run publishes the state of every repository in store, then each change,
+// until ctx is done. A repository that stops being monitored has its
+// retained message cleared. Failures are logged, as there is nobody to show
+// them to.
+func Run(ctx context.Context, c *Client, prefix string, store *gitstatus.Store) {
+	defer crash.Capture()
+	defer c.Close()
+
+	events := store.Subscribe()
+	go func() {
+		<-ctx.Done()
+		events.Close()
+	}()
+
+	published := make(map[string][]byte)
+	publish := func(repo string, payload []byte) {
+		if last, ok := published[repo]; ok && bytes.Equal(last, payload) {
+			return
+		}
+		if err := c.Publish(ctx, Topic(prefix, repo), payload, true); err != nil {
+			slog.Warn("mqtt publish failed", "repo", repo, "err", err)
+			return
+		}
+		published[repo] = payload
+	}
+	state := func(status gitstatus.Status) []byte {
+		payload, _ := json.Marshal(NewState(status))
+		return payload
+	}
+
+	for repo, status := range store.Statuses() {
+		publish(repo, state(status))
+	}
+	for {
+		batch := events.Next()
+		if batch == nil {
+			return
+		}
+		for _, e := range batch {
+			if e.Removed {
+				// An empty retained message deletes the retained one
+				publish(e.Repo, []byte{})
+				delete(published, e.Repo)
+			} else {
+				publish(e.Repo, state(e.Status))
+			}
+		}
+	}
+}
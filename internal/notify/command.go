@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// CommandTimeout bounds each event command, which may do real work such as
+// pulling.
+const CommandTimeout = time.Minute
+
+// Command runs command with the shell, describing c in environment
+// variables: GITMONI_EVENT (the kind), GITMONI_REPO (the path),
+// GITMONI_REPO_NAME, GITMONI_BRANCH, and GITMONI_MESSAGE. It runs in the
+// repository, or the subdirectory it is scoped to, unless that is on another
+// machine.
+func Command(ctx context.Context, command string, c Change) error {
+	ctx, cancel := context.WithTimeout(ctx, CommandTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	if !gitstatus.IsSSH(c.Repo) {
+		cmd.Dir = gitstatus.WorkDir(c.Repo)
+	}
+	cmd.Env = append(os.Environ(),
+		"GITMONI_EVENT="+c.Kind,
+		"GITMONI_REPO="+gitstatus.WorkDir(c.Repo),
+		"GITMONI_REPO_NAME="+filepath.Base(c.Repo),
+		"GITMONI_BRANCH="+c.Branch,
+		"GITMONI_MESSAGE="+c.Message,
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/cwsaylor/gitmoni/pkg/config"
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// Notifier follows the statuses of a set of repositories and sends the
+// changes worth reporting to the channels configured for them. Observe and
+// Forget must be called from one goroutine; Send may run concurrently.
+type Notifier struct {
+	Desktop       []string          // kinds shown as desktop notifications
+	Webhook       *Webhook          // nil if no webhook is configured
+	WebhookEvents []string          // kinds posted to Webhook
+	Commands      map[string]string // shell command run for each kind
+	DirtyAfter    time.Duration
+
+	last   map[string]gitstatus.Status
+	dirty  map[string]bool     // repos already reported as dirty
+	alerts map[string][]string // alert rules holding for each repo
+}
+
+// NewNotifier returns a notifier for the notification settings in cfg.
+func NewNotifier(cfg *config.Config) *Notifier {
+	n := &Notifier{
+		Desktop:       cfg.DesktopNotifications,
+		WebhookEvents: cfg.WebhookEvents,
+		Commands:      cfg.EventCommands,
+		DirtyAfter:    time.Duration(cfg.DirtyDays) * 24 * time.Hour,
+		last:          make(map[string]gitstatus.Status),
+		dirty:         make(map[string]bool),
+		alerts:        make(map[string][]string),
+	}
+	if cfg.WebhookURL != "" {
+		n.Webhook = &Webhook{URL: cfg.WebhookURL}
+	}
+	if len(n.WebhookEvents) == 0 {
+		n.WebhookEvents = WebhookEvents
+	}
+	return n
+}
+
+// Wants reports whether changes of kind are sent anywhere.
+func (n *Notifier) Wants(kind string) bool {
+	return slices.Contains(n.Desktop, kind) || n.Webhook != nil && slices.Contains(n.WebhookEvents, kind) || n.Commands[kind] != ""
+}
+
+// Observe records status and returns the changes since the repository's
+// previous status, and uncommitted changes older than DirtyAfter. A repo's
+// first status is only recorded, so starting up doesn't report every repo
+// that is already behind.
+func (n *Notifier) Observe(status gitstatus.Status) []Change {
+	prev, seen := n.last[status.Path]
+	n.last[status.Path] = status
+
+	var changes []Change
+	if seen {
+		changes = Changes(prev, status)
+	}
+	if len(status.Files) == 0 {
+		delete(n.dirty, status.Path)
+	} else if n.DirtyAfter > 0 && !n.dirty[status.Path] && n.Wants(Dirty) {
+		if age := gitstatus.DirtyAge(status); age >= n.DirtyAfter {
+			n.dirty[status.Path] = true
+			changes = append(changes, Change{
+				Kind:    Dirty,
+				Repo:    status.Path,
+				Branch:  status.Branch,
+				Message: fmt.Sprintf("%d changed files uncommitted for %d days", len(status.Files), int(age.Hours()/24)),
+			})
+		}
+	}
+	return changes
+}
+
+// Alerts records the names of the alert rules that hold for repo and
+// returns the ones that didn't hold before. Unlike other changes, alerts
+// holding at startup are reported, as they are conditions the user asked
+// to hear about rather than transitions.
+func (n *Notifier) Alerts(repo string, names []string) []Change {
+	prev := n.alerts[repo]
+	if len(names) == 0 {
+		delete(n.alerts, repo)
+	} else {
+		n.alerts[repo] = names
+	}
+
+	var changes []Change
+	for _, name := range names {
+		if !slices.Contains(prev, name) {
+			changes = append(changes, Change{Kind: Alert, Repo: repo, Branch: n.last[repo].Branch, Message: name})
+		}
+	}
+	return changes
+}
+
+// Forget stops following repo.
+func (n *Notifier) Forget(repo string) {
+	delete(n.last, repo)
+	delete(n.dirty, repo)
+	delete(n.alerts, repo)
+}
+
+// Relevant returns the changes that Send would send somewhere.
+func (n *Notifier) Relevant(changes []Change) []Change {
+	var relevant []Change
+	for _, c := range changes {
+		if n.Wants(c.Kind) {
+			relevant = append(relevant, c)
+		}
+	}
+	return relevant
+}
+
+// Send sends each change to the channels configured for its kind. Failures
+// are logged rather than returned, as there is nobody to show them to.
+func (n *Notifier) Send(ctx context.Context, changes []Change) {
+	for _, c := range changes {
+		if slices.Contains(n.Desktop, c.Kind) {
+			if err := Desktop(ctx, c.Title(), c.Message); err != nil {
+				slog.Warn("desktop notification failed", "repo", c.Repo, "kind", c.Kind, "err", err)
+			}
+		}
+		if n.Webhook != nil && slices.Contains(n.WebhookEvents, c.Kind) {
+			if err := n.Webhook.Post(ctx, c); err != nil {
+				slog.Warn("webhook notification failed", "repo", c.Repo, "kind", c.Kind, "err", err)
+			}
+		}
+		if command := n.Commands[c.Kind]; command != "" {
+			if err := Command(ctx, command, c); err != nil {
+				slog.Warn("event command failed", "repo", c.Repo, "kind", c.Kind, "command", command, "err", err)
+			} else {
+				slog.Info("ran event command", "repo", c.Repo, "kind", c.Kind, "command", command)
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Package notify tells the user about repository state changes that need
+// their attention, such as a repository falling behind its upstream, through
+// the desktop's notification system or a chat webhook, or runs the user's
+// commands on them.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// Kinds of change, as used in the desktop_notifications, webhook_events, and
+// event_commands config settings.
+const (
+	Behind      = "behind"       // the repo became behind its upstream
+	Conflicts   = "conflicts"    // the working tree gained merge conflicts
+	FetchFailed = "fetch_failed" // fetching the repo started failing
+	Dirty       = "dirty"        // uncommitted changes have been left for a while
+	CIFailed    = "ci_failed"    // CI failed on the current branch
+	Alert       = "alert"        // one of the alert_rules started to hold
+	Clean       = "clean"        // the working tree's changes were all committed or discarded
+)
+
+// Timeout bounds each notification command.
+const Timeout = 5 * time.Second
+
+// Change is a state change of a repository worth notifying about.
+type Change struct {
+	Kind    string
+	Repo    string
+	Branch  string
+	Message string
+}
+
+// Title returns the notification title for c: the repository's name.
+func (c Change) Title() string {
+	return "gitmoni: " + filepath.Base(c.Repo)
+}
+
+// Changes compares a repository's previous and current status and returns
+// what changed. Only transitions are reported, so a repo that stays behind
+// is reported once, when it falls behind.
+func Changes(prev, cur gitstatus.Status) []Change {
+	var changes []Change
+	add := func(kind, message string) {
+		changes = append(changes, Change{Kind: kind, Repo: cur.Path, Branch: cur.Branch, Message: message})
+	}
+	if cur.NeedsPull && !prev.NeedsPull {
+		add(Behind, cur.RemoteStatus)
+	}
+	if n := conflicts(cur); n == 1 && conflicts(prev) == 0 {
+		add(Conflicts, "1 conflicted file")
+	} else if n > 1 && conflicts(prev) == 0 {
+		add(Conflicts, fmt.Sprintf("%d conflicted files", n))
+	}
+	if fetchFailed(cur) && !fetchFailed(prev) {
+		add(FetchFailed, cur.RemoteStatus)
+	}
+	if len(cur.Files) == 0 && len(prev.Files) > 0 && !cur.HasError {
+		add(Clean, "no uncommitted changes left")
+	}
+	return changes
+}
+
+// conflicts returns the number of unmerged files in status.
+func conflicts(status gitstatus.Status) int {
+	n := 0
+	for _, f := range status.Files {
+		switch f.Status {
+		case "DD", "AU", "UD", "UA", "DU", "AA", "UU":
+			n++
+		}
+	}
+	return n
+}
+
+func fetchFailed(status gitstatus.Status) bool {
+	return strings.HasPrefix(status.RemoteStatus, "Fetch failed")
+}
+
+// Desktop shows a desktop notification: with notify-send on Linux and BSD,
+// osascript on macOS, and a toast through PowerShell on Windows.
+func Desktop(ctx context.Context, title, message string) error {
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		// Pass the text as arguments rather than quoting it into the script
+		cmd = exec.CommandContext(ctx, "osascript",
+			"-e", "on run argv",
+			"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+			"-e", "end run",
+			title, message)
+	case "windows":
+		cmd = exec.CommandContext(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", windowsToast)
+		cmd.Env = append(os.Environ(), "GITMONI_TITLE="+title, "GITMONI_MESSAGE="+message)
+	default:
+		cmd = exec.CommandContext(ctx, "notify-send", "--app-name=gitmoni", title, message)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// windowsToast shows $GITMONI_TITLE and $GITMONI_MESSAGE as a toast
+// notification.
+const windowsToast = `
+[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $template.GetElementsByTagName('text')
+$text.Item(0).AppendChild($template.CreateTextNode($env:GITMONI_TITLE)) | Out-Null
+$text.Item(1).AppendChild($template.CreateTextNode($env:GITMONI_MESSAGE)) | Out-Null
+$toast = [Windows.UI.Notifications.ToastNotification]::new($template)
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('gitmoni').Show($toast)
+`
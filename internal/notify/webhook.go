@@ -0,0 +1,102 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// WebhookEvents are the kinds of change posted to a webhook unless
+// configured otherwise: those a team sharing a machine needs to act on.
+var WebhookEvents = []string{Behind, Dirty, CIFailed, Alert}
+
+// Webhook posts changes to a Slack or Discord incoming webhook. Discord
+// webhooks are recognised by their URL; any other URL is sent Slack's
+// payload, which Mattermost and Rocket.Chat also accept.
+type Webhook struct {
+	URL    string
+	Client *http.Client // defaults to http.DefaultClient
+}
+
+// Discord reports whether the webhook posts to Discord.
+func (w *Webhook) Discord() bool {
+	return strings.Contains(w.URL, "discord.com/api/webhooks/") || strings.Contains(w.URL, "discordapp.com/api/webhooks/")
+}
+
+// Post sends c as a chat message naming the repository, the kind of change,
+// and its details.
+func (w *Webhook) Post(ctx context.Context, c Change) error {
+	var payload any
+	if w.Discord() {
+		payload = map[string]string{
+			"username": "gitmoni",
+			"content":  fmt.Sprintf("%s **%s** %s: %s", emoji(c.Kind), filepath.Base(c.Repo), describe(c.Kind), c.Message),
+		}
+	} else {
+		payload = map[string]string{
+			"text": fmt.Sprintf("%s *%s* %s: %s\n`%s`", emoji(c.Kind), filepath.Base(c.Repo), describe(c.Kind), c.Message, c.Repo),
+		}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// describe phrases a kind of change for a message.
+func describe(kind string) string {
+	switch kind {
+	case Behind:
+		return "fell behind its upstream"
+	case Conflicts:
+		return "has merge conflicts"
+	case FetchFailed:
+		return "failed to fetch"
+	case Dirty:
+		return "has uncommitted changes"
+	case CIFailed:
+		return "CI failed"
+	case Clean:
+		return "is clean again"
+	}
+	return kind
+}
+
+func emoji(kind string) string {
+	switch kind {
+	case Behind:
+		return "⬇️"
+	case Dirty:
+		return "📝"
+	case Clean:
+		return "✅"
+	}
+	return "❌"
+}
@@ -0,0 +1,256 @@
+// Package otel exports gitmoni's spans (git commands, status checks,
+// fetches, refresh cycles, and fetch batches) to an OpenTelemetry collector
+// with OTLP over HTTP, using the JSON encoding, so latency can be traced
+// when gitmoni runs as a long-lived daemon.
+package otel
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cwsaylor/gitmoni/internal/crash"
+	"github.com/cwsaylor/gitmoni/pkg/config"
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+const (
+	// FlushInterval is how often queued spans are sent.
+	FlushInterval = 5 * time.Second
+	// maxQueue bounds the spans kept while the collector is unreachable;
+	// more are dropped.
+	maxQueue = 2048
+)
+
+// Exporter sends spans to a collector in batches. It implements
+// gitstatus.SpanExporter.
+type Exporter struct {
+	URL     string            // traces endpoint, e.g. http://localhost:4318/v1/traces
+	Headers map[string]string // sent with every request, e.g. for authentication
+	Service string            // service.name resource attribute
+	Client  *http.Client
+
+	mu      sync.Mutex
+	queue   []*gitstatus.Span
+	dropped int
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// Setup starts exporting spans to the collector configured in cfg or in the
+// standard OTEL_EXPORTER_OTLP_* environment variables. It returns nil if
+// none is configured. Call Shutdown before exiting to send the last spans.
+func Setup(cfg *config.Config) *Exporter {
+	var url string
+	switch {
+	case cfg.OTLPEndpoint != "":
+		url = strings.TrimSuffix(cfg.OTLPEndpoint, "/") + "/v1/traces"
+	case os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != "":
+		url = os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	case os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "":
+		url = strings.TrimSuffix(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "/") + "/v1/traces"
+	default:
+		return nil
+	}
+
+	service := os.Getenv("OTEL_SERVICE_NAME")
+	if service == "" {
+		service = "gitmoni"
+	}
+	e := &Exporter{
+		URL:     url,
+		Headers: parseHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS")),
+		Service: service,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go e.run()
+	gitstatus.SetSpanExporter(e)
+	slog.Info("exporting traces", "url", url)
+	return e
+}
+
+// parseHeaders parses "key1=value1,key2=value2".
+func parseHeaders(s string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if key, value, ok := strings.Cut(pair, "="); ok {
+			headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return headers
+}
+
+// ExportSpan queues s for the next batch.
+func (e *Exporter) ExportSpan(s *gitstatus.Span) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.queue) >= maxQueue {
+		e.dropped++
+		return
+	}
+	e.queue = append(e.queue, s)
+}
+
+// Shutdown stops exporting and sends the queued spans, giving up when ctx
+// is done.
+func (e *Exporter) Shutdown(ctx context.Context) {
+	gitstatus.SetSpanExporter(nil)
+	close(e.stop)
+	<-e.done
+	e.flush(ctx)
+}
+
+func (e *Exporter) run() {
+	defer crash.Capture()
+	defer close(e.done)
+	ticker := time.NewTicker(FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.stop:
+			return
+		case <-ticker.C:
+			e.flush(context.Background())
+		}
+	}
+}
+
+// flush sends the queued spans. They are dropped if the collector rejects
+// them, so a bad span can't block the queue.
+func (e *Exporter) flush(ctx context.Context) {
+	e.mu.Lock()
+	spans, dropped := e.queue, e.dropped
+	e.queue, e.dropped = nil, 0
+	e.mu.Unlock()
+	if dropped > 0 {
+		slog.Warn("trace queue full, spans dropped", "count", dropped)
+	}
+	if len(spans) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(e.request(spans))
+	if err != nil {
+		slog.Warn("encoding spans failed", "err", err)
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		slog.Warn("exporting spans failed", "err", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.Headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		slog.Warn("exporting spans failed", "spans", len(spans), "err", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		slog.Warn("exporting spans failed", "spans", len(spans), "status", resp.Status, "response", string(msg))
+		return
+	}
+	slog.Debug("exported spans", "spans", len(spans))
+}
+
+// The OTLP/JSON request, trimmed to the fields gitmoni sets.
+type (
+	exportRequest struct {
+		ResourceSpans []resourceSpans `json:"resourceSpans"`
+	}
+	resourceSpans struct {
+		Resource   resource     `json:"resource"`
+		ScopeSpans []scopeSpans `json:"scopeSpans"`
+	}
+	resource struct {
+		Attributes []keyValue `json:"attributes"`
+	}
+	scopeSpans struct {
+		Scope scope  `json:"scope"`
+		Spans []span `json:"spans"`
+	}
+	scope struct {
+		Name string `json:"name"`
+	}
+	span struct {
+		TraceID           string     `json:"traceId"`
+		SpanID            string     `json:"spanId"`
+		ParentSpanID      string     `json:"parentSpanId,omitempty"`
+		Name              string     `json:"name"`
+		Kind              int        `json:"kind"`
+		StartTimeUnixNano string     `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+		Attributes        []keyValue `json:"attributes,omitempty"`
+		Status            status     `json:"status"`
+	}
+	keyValue struct {
+		Key   string   `json:"key"`
+		Value anyValue `json:"value"`
+	}
+	anyValue struct {
+		StringValue string `json:"stringValue"`
+	}
+	status struct {
+		Code    int    `json:"code,omitempty"` // 2 for an error
+		Message string `json:"message,omitempty"`
+	}
+)
+
+// spanKindInternal is OTLP's SPAN_KIND_INTERNAL.
+const spanKindInternal = 1
+
+func (e *Exporter) request(spans []*gitstatus.Span) exportRequest {
+	out := make([]span, 0, len(spans))
+	for _, s := range spans {
+		o := span{
+			TraceID:           hex.EncodeToString(s.TraceID[:]),
+			SpanID:            hex.EncodeToString(s.ID[:]),
+			Name:              s.Name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+		}
+		if s.Parent != [8]byte{} {
+			o.ParentSpanID = hex.EncodeToString(s.Parent[:])
+		}
+		keys := make([]string, 0, len(s.Attrs))
+		for key := range s.Attrs {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			o.Attributes = append(o.Attributes, keyValue{Key: key, Value: anyValue{StringValue: s.Attrs[key]}})
+		}
+		if s.Err != nil {
+			o.Status = status{Code: 2, Message: s.Err.Error()}
+		}
+		out = append(out, o)
+	}
+
+	host, _ := os.Hostname()
+	return exportRequest{ResourceSpans: []resourceSpans{{
+		Resource: resource{Attributes: []keyValue{
+			{Key: "service.name", Value: anyValue{StringValue: e.Service}},
+			{Key: "host.name", Value: anyValue{StringValue: host}},
+			{Key: "process.pid", Value: anyValue{StringValue: fmt.Sprint(os.Getpid())}},
+		}},
+		ScopeSpans: []scopeSpans{{Scope: scope{Name: "github.com/cwsaylor/gitmoni"}, Spans: out}},
+	}}}
+}
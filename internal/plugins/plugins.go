@@ -0,0 +1,166 @@
+// Package plugins runs gitmoni plugins: executables in the plugins directory
+// (~/.config/gitmoni/plugins) that add status badges and actions to each
+// repository.
+//
+// A plugin is invoked with one of these subcommands and must exit within
+// the timeout:
+//
+//	<plugin> describe
+//		Print {"name": "...", "actions": [{"id": "...", "title": "..."}]}.
+//		Both fields are optional; the name defaults to the file name.
+//	<plugin> status <repo>
+//		Print {"text": "...", "level": "ok|info|warn|error", "detail": "..."}
+//		to show a badge next to the repository, or nothing for no badge.
+//	<plugin> run <action-id> <repo>
+//		Perform an action. Output is shown to the user; a non-zero exit
+//		status marks the action as failed.
+//
+// Plugins run with the repository as their working directory, and the
+// GITMONI_REPO environment variable is set to its path. They aren't run for
+// repositories on other hosts.
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// Timeout bounds each plugin invocation.
+const Timeout = 10 * time.Second
+
+// errRemoteRepo is the error of running a plugin for a repository on
+// another host.
+var errRemoteRepo = errors.New("plugins can't run for repositories on another host")
+
+// Plugin is an executable discovered in the plugins directory.
+type Plugin struct {
+	Name    string   `json:"name"`
+	Actions []Action `json:"actions"`
+	Path    string   `json:"-"`
+}
+
+// Action is a command a plugin offers to run on a repository.
+type Action struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// Badge is a plugin's status for a repository.
+type Badge struct {
+	Text   string `json:"text"`
+	Level  string `json:"level"` // "ok", "info", "warn", or "error"
+	Detail string `json:"detail"`
+}
+
+// Dir returns the plugins directory: $XDG_CONFIG_HOME/gitmoni/plugins, or
+// ~/.config/gitmoni/plugins.
+func Dir() string {
+	base := os.Getenv("XDG_CONFIG_HOME")
+	if base == "" {
+		base = filepath.Join(os.Getenv("HOME"), ".config")
+	}
+	return filepath.Join(base, "gitmoni", "plugins")
+}
+
+// Discover describes every executable in dir, sorted by file name. A
+// missing directory means no plugins. Plugins that fail to describe
+// themselves are skipped and reported in the returned error.
+func Discover(ctx context.Context, dir string) ([]Plugin, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var plugins []Plugin
+	var failed []string
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || info.IsDir() || info.Mode()&0o111 == 0 {
+			continue
+		}
+		p := Plugin{Path: filepath.Join(dir, e.Name())}
+		out, err := p.exec(ctx, "", "describe")
+		if err == nil && len(bytes.TrimSpace(out)) > 0 {
+			err = json.Unmarshal(out, &p)
+		}
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", e.Name(), err))
+			continue
+		}
+		if p.Name == "" {
+			p.Name = e.Name()
+		}
+		plugins = append(plugins, p)
+	}
+	slices.SortFunc(plugins, func(a, b Plugin) int { return strings.Compare(a.Path, b.Path) })
+	if len(failed) > 0 {
+		return plugins, fmt.Errorf("plugins failed to load: %s", strings.Join(failed, "; "))
+	}
+	return plugins, nil
+}
+
+// Status asks the plugin for repo's badge. It returns a zero Badge if the
+// plugin has nothing to show, or repo is on another host, where plugins
+// can't run.
+func (p Plugin) Status(ctx context.Context, repo string) (Badge, error) {
+	var badge Badge
+	if gitstatus.IsSSH(repo) {
+		return badge, nil
+	}
+	out, err := p.exec(ctx, repo, "status", repo)
+	if err != nil || len(bytes.TrimSpace(out)) == 0 {
+		return badge, err
+	}
+	if err := json.Unmarshal(out, &badge); err != nil {
+		return badge, fmt.Errorf("%s: invalid status output: %w", p.Name, err)
+	}
+	return badge, nil
+}
+
+// Run performs action on repo and returns its output.
+func (p Plugin) Run(ctx context.Context, action, repo string) (string, error) {
+	out, err := p.exec(ctx, repo, "run", action, repo)
+	return strings.TrimSpace(string(out)), err
+}
+
+// exec runs the plugin with args in repo (if set) and returns its stdout.
+// Failures include the plugin's stderr. Plugins run on this machine, so a
+// repository on another host is refused.
+func (p Plugin) exec(ctx context.Context, repo string, args ...string) ([]byte, error) {
+	if gitstatus.IsSSH(repo) {
+		return nil, errRemoteRepo
+	}
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.Path, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if repo != "" {
+		// A repository scoped to a subdirectory is run in the subdirectory
+		cmd.Dir = gitstatus.WorkDir(repo)
+		cmd.Env = append(os.Environ(), "GITMONI_REPO="+cmd.Dir)
+	}
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return stdout.Bytes(), fmt.Errorf("%s %s: %w: %s", filepath.Base(p.Path), args[0], err, msg)
+		}
+		return stdout.Bytes(), fmt.Errorf("%s %s: %w", filepath.Base(p.Path), args[0], err)
+	}
+	return stdout.Bytes(), nil
+}
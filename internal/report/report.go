@@ -0,0 +1,234 @@
+// Package report looks back over a period, such as the last week, and
+// summarises what happened to the monitored repositories: the commits made,
+// problems that started or were resolved according to gitmoni's recorded
+// history, new branches not pushed yet, and repositories that went stale.
+package report
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cwsaylor/gitmoni/internal/timefmt"
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// problems are the history event kinds that start a problem, in the order
+// they are reported.
+var problems = []string{gitstatus.HistoryFetchFailed, gitstatus.HistoryBehind, gitstatus.HistoryDirty}
+
+// Repo is a repository's entry in the report.
+type Repo struct {
+	Path        string    `json:"path"`
+	Error       string    `json:"error,omitempty"`
+	Commits     int       `json:"commits"`                // commits on local branches during the period
+	LastActive  time.Time `json:"last_active,omitzero"`   // newest commit on any local or remote-tracking branch
+	BecameStale bool      `json:"became_stale"`           // the last activity grew older than the stale period during the period
+	NewBranches []Branch  `json:"new_branches,omitempty"` // branches created during the period that aren't fully pushed
+	Started     []Problem `json:"started,omitempty"`      // problems that started during the period and remain
+	Ongoing     []Problem `json:"ongoing,omitempty"`      // problems that started before the period and remain
+	Resolved    []string  `json:"resolved,omitempty"`     // kinds of the problems at the start that are gone
+	Pushes      int       `json:"pushes"`                 // pushes recorded during the period
+}
+
+// Branch is a new branch that isn't fully pushed.
+type Branch struct {
+	Name     string    `json:"name"`
+	Created  time.Time `json:"created"`
+	Upstream string    `json:"upstream,omitempty"` // "" if it has none
+	Ahead    int       `json:"ahead"`
+}
+
+// Problem is a history event kind, such as fetch_failed, that describes a
+// problem, and when it started if that is known.
+type Problem struct {
+	Kind  string    `json:"kind"`
+	Since time.Time `json:"since,omitzero"`
+}
+
+// Active reports whether there is anything to say about r.
+func (r Repo) Active() bool {
+	return r.Error != "" || r.Commits > 0 || r.BecameStale || len(r.NewBranches) > 0 ||
+		len(r.Started) > 0 || len(r.Resolved) > 0 || r.Pushes > 0
+}
+
+// Options are what Collect looks at.
+type Options struct {
+	Since      time.Time
+	StaleAfter time.Duration // a repository without activity for this long is stale; 0 to not report it
+	OwnCommits bool          // only count commits by each repository's user.email, or all if it has none
+}
+
+// Collect compares each repository's current status with the history
+// events recorded up to opts.Since and with its git history since then.
+func Collect(statuses map[string]gitstatus.Status, repos []string, events []gitstatus.HistoryEvent, opts Options) []Repo {
+	now := time.Now()
+	var report []Repo
+	for _, path := range repos {
+		status := statuses[path]
+		r := Repo{Path: path}
+		if status.HasError {
+			r.Error = status.Error
+			report = append(report, r)
+			continue
+		}
+
+		author := ""
+		if opts.OwnCommits {
+			author = gitstatus.UserEmail(path)
+		}
+		var err error
+		if r.Commits, err = gitstatus.CommitCount(path, opts.Since, author); err != nil {
+			r.Error = gitstatus.ErrorSummary(err)
+		}
+		r.LastActive, _ = gitstatus.LastActivity(path)
+		if opts.StaleAfter > 0 && !r.LastActive.IsZero() {
+			// Stale now, but not yet at the start of the period
+			r.BecameStale = now.Sub(r.LastActive) > opts.StaleAfter && opts.Since.Sub(r.LastActive) <= opts.StaleAfter
+		}
+		branches, _ := gitstatus.LocalBranches(path)
+		for _, b := range branches {
+			if b.Upstream != "" && !b.Gone && b.Ahead == 0 {
+				continue
+			}
+			if created, ok := gitstatus.BranchCreated(path, b.Name); ok && created.After(opts.Since) {
+				r.NewBranches = append(r.NewBranches, Branch{Name: b.Name, Created: created, Upstream: b.Upstream, Ahead: b.Ahead})
+			}
+		}
+		compareHistory(&r, status, events, opts.Since)
+		report = append(report, r)
+	}
+	return report
+}
+
+// compareHistory fills in r's problems: those at opts.Since according to
+// events, and those now according to status and the latest events.
+func compareHistory(r *Repo, status gitstatus.Status, events []gitstatus.HistoryEvent, since time.Time) {
+	then := make(map[string]bool)         // problem kind → whether it held at since
+	started := make(map[string]time.Time) // problem kind → when it last started
+	current := make(map[string]bool)      // problem kind → whether it holds now, by the latest event
+	for _, e := range events {
+		if e.Repo != r.Path {
+			continue
+		}
+		if e.Kind == gitstatus.HistoryPushed {
+			if e.Time.After(since) {
+				r.Pushes++
+			}
+			continue
+		}
+		for _, p := range problems {
+			if e.Kind != p && e.Kind != gitstatus.HistoryOpposite(p) {
+				continue
+			}
+			if !e.Time.After(since) {
+				then[p] = e.Kind == p
+			}
+			current[p] = e.Kind == p
+			if e.Kind == p {
+				started[p] = e.Time
+			}
+		}
+	}
+	// The status is fresher than the events for what a check can see; only
+	// a fetch can tell whether fetching fails
+	current[gitstatus.HistoryDirty] = len(status.Files) > 0
+	current[gitstatus.HistoryBehind] = status.NeedsPull
+	if !status.DirtySince.IsZero() {
+		started[gitstatus.HistoryDirty] = status.DirtySince
+	}
+
+	for _, p := range problems {
+		switch {
+		case current[p] && started[p].After(since):
+			r.Started = append(r.Started, Problem{Kind: p, Since: started[p]})
+		case current[p]:
+			r.Ongoing = append(r.Ongoing, Problem{Kind: p, Since: started[p]})
+		case then[p]:
+			r.Resolved = append(r.Resolved, gitstatus.HistoryOpposite(p))
+		}
+	}
+}
+
+// WriteText writes the report as plain text, with dates and times in tf:
+// the repositories with something to report, then totals.
+func WriteText(w io.Writer, report []Repo, since time.Time, tf timefmt.Format) error {
+	now := time.Now()
+	fmt.Fprintf(w, "gitmoni report: %s %s to %s %s\n", since.Local().Format("Mon"), tf.DateTime(since), now.Local().Format("Mon"), tf.DateTime(now))
+
+	var commits, active, started, resolved, branches, stale int
+	for _, r := range report {
+		if !r.Active() {
+			continue
+		}
+		active++
+		commits += r.Commits
+		started += len(r.Started)
+		resolved += len(r.Resolved)
+		branches += len(r.NewBranches)
+		if r.BecameStale {
+			stale++
+		}
+
+		fmt.Fprintf(w, "\n%s (%s)\n", filepath.Base(r.Path), r.Path)
+		if r.Error != "" {
+			fmt.Fprintf(w, "  error: %s\n", r.Error)
+		}
+		if r.Commits > 0 || r.Pushes > 0 {
+			fmt.Fprintf(w, "  %s, %s\n", plural(r.Commits, "commit"), plural(r.Pushes, "push"))
+		}
+		for _, p := range r.Started {
+			fmt.Fprintf(w, "  new: %s %s\n", gitstatus.DescribeHistoryKind(p.Kind), formatDate(p.Since, tf))
+		}
+		for _, kind := range r.Resolved {
+			fmt.Fprintf(w, "  resolved: %s\n", gitstatus.DescribeHistoryKind(kind))
+		}
+		for _, p := range r.Ongoing {
+			when := "before the period"
+			if !p.Since.IsZero() {
+				when = formatDate(p.Since, tf)
+			}
+			fmt.Fprintf(w, "  still: %s %s\n", gitstatus.DescribeHistoryKind(p.Kind), when)
+		}
+		for _, b := range r.NewBranches {
+			state := "no upstream"
+			switch {
+			case b.Upstream != "" && b.Ahead > 0:
+				state = fmt.Sprintf("%s ahead of %s", plural(b.Ahead, "commit"), b.Upstream)
+			case b.Upstream != "":
+				state = "upstream " + b.Upstream + " deleted"
+			}
+			fmt.Fprintf(w, "  unpushed branch: %s, created %s (%s)\n", b.Name, formatDate(b.Created, tf), state)
+		}
+		if r.BecameStale {
+			fmt.Fprintf(w, "  went stale: last activity %s\n", formatDate(r.LastActive, tf))
+		}
+	}
+
+	fmt.Fprintf(w, "\n%s in %d of %s; %s, %d resolved; %s; %s went stale\n",
+		plural(commits, "commit"), active, plural(len(report), "repository"),
+		plural(started, "new problem"), resolved,
+		plural(branches, "unpushed new branch"), plural(stale, "repository"))
+	return nil
+}
+
+// formatDate renders t as e.g. "on Mon 2026-03-02", with the date in tf.
+func formatDate(t time.Time, tf timefmt.Format) string {
+	return "on " + t.Local().Format("Mon") + " " + tf.Date(t)
+}
+
+// plural renders n and noun, e.g. "1 commit" or "3 commits".
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	switch {
+	case strings.HasSuffix(noun, "y"):
+		noun = strings.TrimSuffix(noun, "y") + "ie"
+	case strings.HasSuffix(noun, "sh"), strings.HasSuffix(noun, "ch"):
+		noun += "e"
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
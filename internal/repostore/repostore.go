@@ -0,0 +1,51 @@
+// Package repostore sets up the gitstatus.Store through which the TUI and
+// each command that monitors repositories checks and fetches them, as the
+// config asks.
+package repostore
+
+import (
+	"time"
+
+	"github.com/cwsaylor/gitmoni/pkg/config"
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// New returns a store for cfg's repositories. It runs git through the
+// git_backend's runner, leaves out ignored and hidden untracked files,
+// tracks how long repositories have been dirty, records their history,
+// and shares and spreads out fetches. Further setup, such as a trace, must
+// be done before the store is used.
+func New(cfg *config.Config) *gitstatus.Store {
+	store := gitstatus.NewStore()
+	if r := Runner(cfg); r != nil {
+		store.UseRunner(r)
+	}
+	store.IgnoreFiles(func(repo string) []string { return cfg.Settings(repo).Ignore })
+	store.IgnoreUntracked(cfg.HidesUntracked)
+	if path, err := gitstatus.DefaultDirtyPath(); err == nil {
+		store.TrackDirty(gitstatus.NewDirtyTracker(path))
+	}
+	if path, err := gitstatus.DefaultHistoryPath(); err == nil {
+		store.RecordHistory(gitstatus.NewHistory(path))
+	}
+
+	// Share fetches with other gitmoni instances so several running at
+	// once don't each fetch every repository
+	if cfg.FetchShareSeconds > 0 {
+		if dir, err := gitstatus.DefaultLedgerDir(); err == nil {
+			window := time.Duration(cfg.FetchShareSeconds) * time.Second
+			store.ShareFetches(gitstatus.NewFetchLedger(dir, window))
+		}
+	}
+	// Spread fetches from the same server out, as it may limit connections
+	store.LimitFetches(gitstatus.NewFetchLimiter(cfg.FetchHostLimit, time.Duration(cfg.FetchJitterSeconds)*time.Second))
+	return store
+}
+
+// Runner returns the runner git_backend selects, or nil for ExecRunner.
+func Runner(cfg *config.Config) gitstatus.Runner {
+	if cfg.GitBackend == "go-git" {
+		return gitstatus.GoGitRunner{}
+	}
+	return nil
+}
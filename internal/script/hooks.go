@@ -0,0 +1,222 @@
+package script
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cwsaylor/gitmoni/pkg/config"
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// Hooks are the expressions configured in badge_scripts, sort_script,
+// fetch_script, and alert_rules. An expression that fails for a repository
+// is treated as if it wasn't configured for it, so one bad repository can't
+// break the others. Its first failure is logged as a warning and returned
+// by Failures, so it can be shown to the user.
+type Hooks struct {
+	Badges   []*Expr // each gives a badge or string; "" for none
+	Priority *Expr   // int; repositories with lower values sort first
+	Fetch    *Expr   // bool; false skips automatic fetches
+	Alerts   []Alert
+
+	mu       sync.Mutex
+	failed   map[*Expr]bool // expressions whose failure was reported
+	failures []error        // reported failures not yet returned by Failures
+}
+
+// Alert is a compiled alert rule.
+type Alert struct {
+	Name string
+	When *Expr // bool; true if the repository needs attention
+}
+
+// Load compiles the expressions in cfg. Expressions that don't compile, or
+// use variables or functions that don't exist, are left out and reported
+// in the returned error.
+func Load(cfg *config.Config) (*Hooks, error) {
+	h := &Hooks{}
+	vars := StatusVars(gitstatus.Status{})
+	var errs []error
+	compile := func(setting, src string) *Expr {
+		if src == "" {
+			return nil
+		}
+		e, err := Compile(src)
+		if err == nil {
+			err = e.Check(vars)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", setting, err))
+			return nil
+		}
+		return e
+	}
+	for _, src := range cfg.BadgeScripts {
+		if e := compile("badge_scripts", src); e != nil {
+			h.Badges = append(h.Badges, e)
+		}
+	}
+	h.Priority = compile("sort_script", cfg.SortScript)
+	h.Fetch = compile("fetch_script", cfg.FetchScript)
+	for _, rule := range cfg.AlertRules {
+		if e := compile("alert_rules", rule.When); e != nil {
+			name := rule.Name
+			if name == "" {
+				name = rule.When
+			}
+			h.Alerts = append(h.Alerts, Alert{Name: name, When: e})
+		}
+	}
+	return h, errors.Join(errs...)
+}
+
+// StatusVars returns the variables describing status.
+func StatusVars(status gitstatus.Status) Vars {
+	files := make([]string, len(status.Files))
+	untracked := 0
+	for i, f := range status.Files {
+		files[i] = f.Path
+		if f.Status == "??" {
+			untracked++
+		}
+	}
+	now := time.Now()
+	return Vars{
+		"name":          filepath.Base(status.Path),
+		"path":          status.Path,
+		"branch":        status.Branch,
+		"error":         status.Error,
+		"remote_status": status.RemoteStatus,
+		"changed":       len(status.Files),
+		"untracked":     untracked,
+		"ahead":         status.Ahead,
+		"behind":        status.Behind,
+		"needs_pull":    status.NeedsPull,
+		"has_remote":    status.HasRemote,
+		"dirty_hours": func() any {
+			// Only worth statting the changed files if an expression asks
+			return int(gitstatus.DirtyAge(status).Hours())
+		},
+		"files":   files,
+		"hour":    now.Hour(),
+		"weekday": now.Format("Mon"),
+	}
+}
+
+// BadgesFor returns the badges the badge scripts give status.
+func (h *Hooks) BadgesFor(status gitstatus.Status) []Badge {
+	if len(h.Badges) == 0 {
+		return nil
+	}
+	vars := StatusVars(status)
+	var badges []Badge
+	for _, e := range h.Badges {
+		v, err := e.Eval(vars)
+		if err != nil {
+			h.fail(e, "badge_scripts", status.Path, err)
+			continue
+		}
+		switch v := v.(type) {
+		case Badge:
+			if v.Text != "" {
+				badges = append(badges, v)
+			}
+		case string:
+			if v != "" {
+				badges = append(badges, Badge{Text: v, Level: "info"})
+			}
+		default:
+			h.fail(e, "badge_scripts", status.Path, fmt.Errorf("%q gave %T, not a badge or string", e, v))
+		}
+	}
+	return badges
+}
+
+// PriorityOf returns status's sort priority, or 0 if there is no sort
+// script.
+func (h *Hooks) PriorityOf(status gitstatus.Status) int {
+	if h.Priority == nil {
+		return 0
+	}
+	v, err := h.Priority.Eval(StatusVars(status))
+	if err == nil {
+		if p, ok := v.(int); ok {
+			return p
+		}
+		err = fmt.Errorf("%q gave %T, not an int", h.Priority, v)
+	}
+	h.fail(h.Priority, "sort_script", status.Path, err)
+	return 0
+}
+
+// AllowFetch reports whether the repository with status may be fetched
+// automatically.
+func (h *Hooks) AllowFetch(status gitstatus.Status) bool {
+	if h.Fetch == nil {
+		return true
+	}
+	v, err := h.Fetch.Eval(StatusVars(status))
+	if err == nil {
+		if allow, ok := v.(bool); ok {
+			return allow
+		}
+		err = fmt.Errorf("%q gave %T, not a bool", h.Fetch, v)
+	}
+	h.fail(h.Fetch, "fetch_script", status.Path, err)
+	return true
+}
+
+// AlertsFor returns the names of the alert rules that hold for status.
+func (h *Hooks) AlertsFor(status gitstatus.Status) []string {
+	if len(h.Alerts) == 0 {
+		return nil
+	}
+	vars := StatusVars(status)
+	var names []string
+	for _, a := range h.Alerts {
+		v, err := a.When.Eval(vars)
+		if err != nil {
+			h.fail(a.When, "alert_rules", status.Path, err)
+			continue
+		}
+		alert, ok := v.(bool)
+		if !ok {
+			h.fail(a.When, "alert_rules", status.Path, fmt.Errorf("%q gave %T, not a bool", a.When, v))
+		}
+		if alert {
+			names = append(names, a.Name)
+		}
+	}
+	return names
+}
+
+// fail reports that e, configured in setting, failed for repo with err,
+// unless it has failed before.
+func (h *Hooks) fail(e *Expr, setting, repo string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.failed[e] {
+		slog.Debug("script failed", "setting", setting, "repo", repo, "err", err)
+		return
+	}
+	if h.failed == nil {
+		h.failed = make(map[*Expr]bool)
+	}
+	h.failed[e] = true
+	slog.Warn("script failed", "setting", setting, "repo", repo, "err", err)
+	h.failures = append(h.failures, fmt.Errorf("%s failed for %s: %w", setting, filepath.Base(repo), err))
+}
+
+// Failures returns the failures reported since it was last called: the
+// first of each expression that has failed.
+func (h *Hooks) Failures() []error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	failures := h.failures
+	h.failures = nil
+	return failures
+}
@@ -0,0 +1,438 @@
+// Package script evaluates the small expressions users write in the config
+// to customise gitmoni per repository: extra badges, a sort priority,
+// vetoes of automatic fetches, and alerts. Expressions use Go syntax and see
+// the repository's status as variables:
+//
+//	name, path, branch, error, remote_status   string
+//	changed, untracked, ahead, behind          int
+//	dirty_hours                                int, age of the oldest change
+//	needs_pull, has_remote                     bool
+//	files                                      list of changed paths
+//	hour, weekday                              local time, e.g. 14 and "Mon"
+//
+// Besides the usual operators, expressions can call:
+//
+//	len(x)                 length of a string or list
+//	contains(x, s)         whether string x contains s, or list x has s
+//	hasPrefix(s, p)        hasSuffix(s, p)
+//	matches(s, re)         whether s matches the regular expression re
+//	glob(files, pattern)   number of files whose path or name matches pattern
+//	cond(c, a, b)          a if c is true, else b
+//	min(a, b)              max(a, b)
+//	ok(s), info(s), warn(s), error(s)   s as a badge of that level
+package script
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Expr is a compiled expression.
+type Expr struct {
+	src  string
+	root ast.Expr
+}
+
+// Compile parses src.
+func Compile(src string) (*Expr, error) {
+	root, err := parser.ParseExpr(src)
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w", src, err)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// String returns the expression's source.
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Badge is the value of ok, info, warn, and error.
+type Badge struct {
+	Text  string
+	Level string // "ok", "info", "warn", or "error"
+}
+
+// Vars are the variables an expression can use. A value may be a func()
+// any that computes it, for variables that are costly and rarely used.
+type Vars map[string]any
+
+// Check reports the first part of e that can't be evaluated with vars: an
+// unknown variable or function, a function given the wrong number of
+// arguments, an invalid regular expression, or Go syntax expressions don't
+// support. Only the names in vars matter, not their values.
+func (e *Expr) Check(vars Vars) error {
+	if err := check(e.root, vars); err != nil {
+		return fmt.Errorf("%q: %w", e.src, err)
+	}
+	return nil
+}
+
+// functions are the built-in functions, with the number of arguments each
+// takes.
+var functions = map[string]int{
+	"len":       1,
+	"contains":  2,
+	"hasPrefix": 2,
+	"hasSuffix": 2,
+	"matches":   2,
+	"glob":      2,
+	"cond":      3,
+	"min":       2,
+	"max":       2,
+	"ok":        1,
+	"info":      1,
+	"warn":      1,
+	"error":     1,
+}
+
+func check(n ast.Expr, vars Vars) error {
+	switch n := n.(type) {
+	case *ast.ParenExpr:
+		return check(n.X, vars)
+	case *ast.BasicLit:
+		if n.Kind != token.INT && n.Kind != token.STRING {
+			return fmt.Errorf("unsupported literal %s", n.Value)
+		}
+		return nil
+	case *ast.Ident:
+		if _, ok := vars[n.Name]; !ok && n.Name != "true" && n.Name != "false" {
+			return fmt.Errorf("unknown variable %s", n.Name)
+		}
+		return nil
+	case *ast.UnaryExpr:
+		if n.Op != token.NOT && n.Op != token.SUB {
+			return fmt.Errorf("unsupported operator %s", n.Op)
+		}
+		return check(n.X, vars)
+	case *ast.BinaryExpr:
+		switch n.Op {
+		case token.ADD, token.SUB, token.MUL, token.QUO, token.REM,
+			token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ,
+			token.LAND, token.LOR:
+		default:
+			return fmt.Errorf("unsupported operator %s", n.Op)
+		}
+		if err := check(n.X, vars); err != nil {
+			return err
+		}
+		return check(n.Y, vars)
+	case *ast.CallExpr:
+		fn, ok := n.Fun.(*ast.Ident)
+		if !ok {
+			return errors.New("only built-in functions can be called")
+		}
+		count, ok := functions[fn.Name]
+		if !ok {
+			return fmt.Errorf("unknown function %s", fn.Name)
+		}
+		if len(n.Args) != count || n.Ellipsis.IsValid() {
+			return fmt.Errorf("%s needs %d arguments", fn.Name, count)
+		}
+		for _, arg := range n.Args {
+			if err := check(arg, vars); err != nil {
+				return err
+			}
+		}
+		// A constant pattern can be checked now rather than on every use
+		if lit, ok := n.Args[len(n.Args)-1].(*ast.BasicLit); ok && fn.Name == "matches" && lit.Kind == token.STRING {
+			re, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				return err
+			}
+			if _, err := regexp.Compile(re); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported expression %T", n)
+}
+
+// Eval evaluates e with vars. Values are strings, ints, bools, []string,
+// and Badges.
+func (e *Expr) Eval(vars Vars) (any, error) {
+	v, err := eval(e.root, vars)
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w", e.src, err)
+	}
+	return v, nil
+}
+
+func eval(n ast.Expr, vars Vars) (any, error) {
+	switch n := n.(type) {
+	case *ast.ParenExpr:
+		return eval(n.X, vars)
+	case *ast.BasicLit:
+		switch n.Kind {
+		case token.INT:
+			return strconv.Atoi(n.Value)
+		case token.STRING:
+			return strconv.Unquote(n.Value)
+		}
+		return nil, fmt.Errorf("unsupported literal %s", n.Value)
+	case *ast.Ident:
+		switch n.Name {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		v, ok := vars[n.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown variable %s", n.Name)
+		}
+		if f, ok := v.(func() any); ok {
+			return f(), nil
+		}
+		return v, nil
+	case *ast.UnaryExpr:
+		x, err := eval(n.X, vars)
+		if err != nil {
+			return nil, err
+		}
+		switch n.Op {
+		case token.NOT:
+			b, ok := x.(bool)
+			if !ok {
+				return nil, fmt.Errorf("! needs a bool, not %T", x)
+			}
+			return !b, nil
+		case token.SUB:
+			i, ok := x.(int)
+			if !ok {
+				return nil, fmt.Errorf("- needs an int, not %T", x)
+			}
+			return -i, nil
+		}
+		return nil, fmt.Errorf("unsupported operator %s", n.Op)
+	case *ast.BinaryExpr:
+		return binary(n, vars)
+	case *ast.CallExpr:
+		return call(n, vars)
+	}
+	return nil, fmt.Errorf("unsupported expression %T", n)
+}
+
+func binary(n *ast.BinaryExpr, vars Vars) (any, error) {
+	x, err := eval(n.X, vars)
+	if err != nil {
+		return nil, err
+	}
+	// && and || only evaluate their right side when needed
+	if n.Op == token.LAND || n.Op == token.LOR {
+		a, ok := x.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s needs bools, not %T", n.Op, x)
+		}
+		if a == (n.Op == token.LOR) {
+			return a, nil
+		}
+		y, err := eval(n.Y, vars)
+		if err != nil {
+			return nil, err
+		}
+		b, ok := y.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s needs bools, not %T", n.Op, y)
+		}
+		return b, nil
+	}
+	y, err := eval(n.Y, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	switch a := x.(type) {
+	case int:
+		b, ok := y.(int)
+		if !ok {
+			return nil, fmt.Errorf("mismatched types int and %T", y)
+		}
+		switch n.Op {
+		case token.ADD:
+			return a + b, nil
+		case token.SUB:
+			return a - b, nil
+		case token.MUL:
+			return a * b, nil
+		case token.QUO, token.REM:
+			if b == 0 {
+				return nil, errors.New("division by zero")
+			}
+			if n.Op == token.QUO {
+				return a / b, nil
+			}
+			return a % b, nil
+		case token.EQL:
+			return a == b, nil
+		case token.NEQ:
+			return a != b, nil
+		case token.LSS:
+			return a < b, nil
+		case token.LEQ:
+			return a <= b, nil
+		case token.GTR:
+			return a > b, nil
+		case token.GEQ:
+			return a >= b, nil
+		}
+	case string:
+		b, ok := y.(string)
+		if !ok {
+			return nil, fmt.Errorf("mismatched types string and %T", y)
+		}
+		switch n.Op {
+		case token.ADD:
+			return a + b, nil
+		case token.EQL:
+			return a == b, nil
+		case token.NEQ:
+			return a != b, nil
+		case token.LSS:
+			return a < b, nil
+		case token.LEQ:
+			return a <= b, nil
+		case token.GTR:
+			return a > b, nil
+		case token.GEQ:
+			return a >= b, nil
+		}
+	case bool:
+		b, ok := y.(bool)
+		if !ok {
+			return nil, fmt.Errorf("mismatched types bool and %T", y)
+		}
+		switch n.Op {
+		case token.EQL:
+			return a == b, nil
+		case token.NEQ:
+			return a != b, nil
+		}
+	}
+	return nil, fmt.Errorf("operator %s not defined on %T", n.Op, x)
+}
+
+func call(n *ast.CallExpr, vars Vars) (any, error) {
+	fn, ok := n.Fun.(*ast.Ident)
+	if !ok {
+		return nil, errors.New("only built-in functions can be called")
+	}
+	count, ok := functions[fn.Name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s", fn.Name)
+	}
+	if len(n.Args) != count {
+		return nil, fmt.Errorf("%s needs %d arguments", fn.Name, count)
+	}
+	// cond only evaluates the branch it returns
+	if fn.Name == "cond" {
+		c, err := eval(n.Args[0], vars)
+		if err != nil {
+			return nil, err
+		}
+		b, ok := c.(bool)
+		if !ok {
+			return nil, fmt.Errorf("cond needs a bool, not %T", c)
+		}
+		if b {
+			return eval(n.Args[1], vars)
+		}
+		return eval(n.Args[2], vars)
+	}
+
+	args := make([]any, len(n.Args))
+	for i, arg := range n.Args {
+		v, err := eval(arg, vars)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	switch fn.Name {
+	case "len":
+		switch x := args[0].(type) {
+		case string:
+			return len(x), nil
+		case []string:
+			return len(x), nil
+		}
+		return nil, fmt.Errorf("len needs a string or list, not %T", args[0])
+	case "contains":
+		s, ok := args[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("contains needs a string, not %T", args[1])
+		}
+		switch x := args[0].(type) {
+		case string:
+			return strings.Contains(x, s), nil
+		case []string:
+			for _, item := range x {
+				if item == s {
+					return true, nil
+				}
+			}
+			return false, nil
+		}
+		return nil, fmt.Errorf("contains needs a string or list, not %T", args[0])
+	case "hasPrefix", "hasSuffix", "matches":
+		s, ok1 := args[0].(string)
+		p, ok2 := args[1].(string)
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("%s needs strings", fn.Name)
+		}
+		switch fn.Name {
+		case "hasPrefix":
+			return strings.HasPrefix(s, p), nil
+		case "hasSuffix":
+			return strings.HasSuffix(s, p), nil
+		}
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString(s), nil
+	case "glob":
+		files, ok1 := args[0].([]string)
+		pattern, ok2 := args[1].(string)
+		if !ok1 || !ok2 {
+			return nil, errors.New("glob needs a list and a pattern")
+		}
+		count := 0
+		for _, f := range files {
+			full, err := path.Match(pattern, f)
+			if err != nil {
+				return nil, err
+			}
+			base, _ := path.Match(pattern, path.Base(f))
+			if full || base {
+				count++
+			}
+		}
+		return count, nil
+	case "min", "max":
+		a, ok1 := args[0].(int)
+		b, ok2 := args[1].(int)
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("%s needs ints", fn.Name)
+		}
+		if fn.Name == "min" {
+			return min(a, b), nil
+		}
+		return max(a, b), nil
+	case "ok", "info", "warn", "error":
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("%s needs a string, not %T", fn.Name, args[0])
+		}
+		return Badge{Text: s, Level: fn.Name}, nil
+	}
+	return nil, fmt.Errorf("unknown function %s", fn.Name)
+}
@@ -0,0 +1,161 @@
+package script
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cwsaylor/gitmoni/pkg/config"
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+var testVars = Vars{
+	"name":    "app",
+	"branch":  "main",
+	"changed": 3,
+	"behind":  0,
+	"files":   []string{"main.go", "docs/README.md"},
+	"costly":  func() any { return 7 },
+}
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		src  string
+		want any
+	}{
+		{`changed * 2 + 1`, 7},
+		{`-changed % 2`, -1},
+		{`(changed - 1) / 2`, 1},
+		{`changed >= 3 && behind <= 0`, true},
+		{`branch == "main" || missing`, true}, // the right side isn't evaluated
+		{`!(name != "app")`, true},
+		{`"a" < "b"`, true},
+		{`"b" <= "b"`, true},
+		{`"a" >= "b"`, false},
+		{`name + "/" + branch`, "app/main"},
+		{`costly`, 7},
+		{`len(files) + len(name)`, 5},
+		{`contains(files, "main.go") && contains(name, "pp")`, true},
+		{`hasPrefix(branch, "ma") && hasSuffix(name, "pp")`, true},
+		{`matches(branch, "^(main|master)$")`, true},
+		{`glob(files, "*.md")`, 1},
+		{`glob(files, "docs/*")`, 1},
+		{`cond(changed > 5, 1 / 0, min(changed, 2))`, 2}, // only the branch taken is evaluated
+		{`max(behind, 1)`, 1},
+		{`warn("dirty")`, Badge{Text: "dirty", Level: "warn"}},
+	}
+	for _, tt := range tests {
+		e, err := Compile(tt.src)
+		if err != nil {
+			t.Fatalf("Compile(%s): %v", tt.src, err)
+		}
+		got, err := e.Eval(testVars)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s = %v, %v, want %v", tt.src, got, err, tt.want)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`changed / behind`, "division by zero"},
+		{`changed == "3"`, "mismatched types int and string"},
+		{`name && true`, "&& needs bools, not string"},
+		{`true < false`, "operator < not defined on bool"},
+		{`-name`, "- needs an int, not string"},
+		{`len(changed)`, "len needs a string or list, not int"},
+		{`matches(name, "(")`, "missing closing )"},
+		{`cond(name, 1, 2)`, "cond needs a bool, not string"},
+		{`missing`, "unknown variable missing"},
+	}
+	for _, tt := range tests {
+		e, err := Compile(tt.src)
+		if err != nil {
+			t.Fatalf("Compile(%s): %v", tt.src, err)
+		}
+		if _, err := e.Eval(testVars); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want %q", tt.src, err, tt.want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string // "" if the expression is valid
+	}{
+		{`changed > 0 && contains(files, "go.mod")`, ""},
+		{`cond(true, ok(name), "")`, ""},
+		{`dirty > 0`, "unknown variable dirty"},
+		{`length(files)`, "unknown function length"},
+		{`min(changed)`, "min needs 2 arguments"},
+		{`len(files...)`, "len needs 1 arguments"},
+		{`strings.Contains(name, "x")`, "only built-in functions can be called"},
+		{`matches(branch, "[")`, "missing closing ]"},
+		{`changed << 1`, "unsupported operator <<"},
+		{`files[0]`, "unsupported expression"},
+		{`1.5`, "unsupported literal 1.5"},
+	}
+	for _, tt := range tests {
+		e, err := Compile(tt.src)
+		if err != nil {
+			t.Fatalf("Compile(%s): %v", tt.src, err)
+		}
+		err = e.Check(testVars)
+		if tt.want == "" && err != nil {
+			t.Errorf("%s: %v", tt.src, err)
+		}
+		if tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+			t.Errorf("%s: error = %v, want %q", tt.src, err, tt.want)
+		}
+	}
+}
+
+func TestLoadChecksScripts(t *testing.T) {
+	cfg := &config.Config{
+		BadgeScripts: []string{`cond(changed > 0, warn("dirty"), "")`, `cond(stale, "old", "")`},
+		SortScript:   `behind +`,
+		FetchScript:  `weekday != "Sun"`,
+	}
+	h, err := Load(cfg)
+	if err == nil || !strings.Contains(err.Error(), "unknown variable stale") || !strings.Contains(err.Error(), "sort_script") {
+		t.Errorf("Load error = %v", err)
+	}
+	if len(h.Badges) != 1 || h.Priority != nil || h.Fetch == nil {
+		t.Errorf("Badges, Priority, Fetch = %v, %v, %v, want the valid expressions only", h.Badges, h.Priority, h.Fetch)
+	}
+}
+
+func TestFailuresAreReportedOnce(t *testing.T) {
+	cfg := &config.Config{
+		SortScript: `cond(changed > 0, "first", 0)`,
+		AlertRules: []config.AlertRule{{Name: "behind", When: `behind > 0`}},
+	}
+	h, err := Load(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dirty := gitstatus.Status{Path: "/src/app", Files: []gitstatus.File{{Path: "main.go", Status: "M"}}, Behind: 1}
+
+	if p := h.PriorityOf(dirty); p != 0 {
+		t.Errorf("priority of a failing sort script = %d, want 0", p)
+	}
+	if p := h.PriorityOf(gitstatus.Status{Path: "/src/clean"}); p != 0 {
+		t.Errorf("priority = %d, want 0", p)
+	}
+	if alerts := h.AlertsFor(dirty); !reflect.DeepEqual(alerts, []string{"behind"}) {
+		t.Errorf("alerts = %v", alerts)
+	}
+	failures := h.Failures()
+	if len(failures) != 1 || !strings.Contains(failures[0].Error(), `sort_script failed for app: "cond(changed > 0, \"first\", 0)" gave string, not an int`) {
+		t.Errorf("failures = %v", failures)
+	}
+
+	h.PriorityOf(dirty)
+	if failures := h.Failures(); len(failures) != 0 {
+		t.Errorf("failure reported again: %v", failures)
+	}
+}
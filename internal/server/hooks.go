@@ -0,0 +1,149 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/cwsaylor/gitmoni/pkg/forge"
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// maxHookBody bounds the size of a webhook payload.
+const maxHookBody = 5 << 20
+
+// HooksHandler returns the handler for push webhooks from GitHub and GitLab:
+//
+//	POST /hooks/github   validated with X-Hub-Signature-256
+//	POST /hooks/gitlab   validated with X-Gitlab-Token
+//
+// Each push fetches the monitored repositories whose origin is the pushed
+// repository. Webhooks are rejected unless HookSecret is set.
+func (s *Server) HooksHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /hooks/github", s.handleGitHubHook)
+	mux.HandleFunc("POST /hooks/gitlab", s.handleGitLabHook)
+	return mux
+}
+
+func (s *Server) handleGitHubHook(w http.ResponseWriter, r *http.Request) {
+	body, ok := s.readHook(w, r)
+	if !ok {
+		return
+	}
+	sig, _ := strings.CutPrefix(r.Header.Get("X-Hub-Signature-256"), "sha256=")
+	mac := hmac.New(sha256.New, []byte(s.HookSecret))
+	mac.Write(body)
+	if want := hex.EncodeToString(mac.Sum(nil)); !hmac.Equal([]byte(sig), []byte(want)) {
+		writeError(w, http.StatusUnauthorized, "invalid signature")
+		return
+	}
+	if event := r.Header.Get("X-GitHub-Event"); event != "push" {
+		// GitHub sends "ping" when the webhook is created
+		writeJSON(w, http.StatusOK, map[string]string{"ignored": event})
+		return
+	}
+
+	var payload struct {
+		Repository struct {
+			CloneURL string `json:"clone_url"`
+			SSHURL   string `json:"ssh_url"`
+		} `json:"repository"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload: "+err.Error())
+		return
+	}
+	s.fetchPushed(w, payload.Repository.CloneURL, payload.Repository.SSHURL)
+}
+
+func (s *Server) handleGitLabHook(w http.ResponseWriter, r *http.Request) {
+	body, ok := s.readHook(w, r)
+	if !ok {
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Gitlab-Token")), []byte(s.HookSecret)) != 1 {
+		writeError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+	if event := r.Header.Get("X-Gitlab-Event"); event != "Push Hook" {
+		writeJSON(w, http.StatusOK, map[string]string{"ignored": event})
+		return
+	}
+
+	var payload struct {
+		Project struct {
+			HTTPURL string `json:"git_http_url"`
+			SSHURL  string `json:"git_ssh_url"`
+		} `json:"project"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid payload: "+err.Error())
+		return
+	}
+	s.fetchPushed(w, payload.Project.HTTPURL, payload.Project.SSHURL)
+}
+
+// readHook reads a webhook's body, rejecting it if no secret is set or the
+// server is read-only.
+func (s *Server) readHook(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	if s.ReadOnly {
+		writeError(w, http.StatusForbidden, "the server is read-only")
+		return nil, false
+	}
+	if s.HookSecret == "" {
+		writeError(w, http.StatusForbidden, "webhooks are disabled; set hook_secret to enable them")
+		return nil, false
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxHookBody))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	return body, true
+}
+
+// fetchPushed fetches the repositories whose origin is one of urls, the
+// pushed repository's clone URLs.
+func (s *Server) fetchPushed(w http.ResponseWriter, urls ...string) {
+	var pushed []forge.Remote
+	for _, u := range urls {
+		if remote, ok := forge.ParseRemote(u); ok {
+			pushed = append(pushed, remote)
+		}
+	}
+
+	started := []string{}
+	for _, path := range s.Repos {
+		origin, err := gitstatus.RemoteURL(path, "origin")
+		if err != nil {
+			continue
+		}
+		remote, ok := forge.ParseRemote(origin)
+		if !ok || !sameRemote(remote, pushed) {
+			continue
+		}
+		if s.Fetch(path) {
+			started = append(started, path)
+		}
+	}
+	slog.Info("push webhook", "repo", urls[0], "fetching", len(started))
+	writeJSON(w, http.StatusAccepted, map[string][]string{"fetching": started})
+}
+
+// sameRemote reports whether r is one of remotes. Hosts and paths are
+// compared case-insensitively, as GitHub and GitLab treat them so.
+func sameRemote(r forge.Remote, remotes []forge.Remote) bool {
+	for _, other := range remotes {
+		if strings.EqualFold(r.Host, other.Host) && strings.EqualFold(r.Owner, other.Owner) && strings.EqualFold(r.Name, other.Name) {
+			return true
+		}
+	}
+	return false
+}
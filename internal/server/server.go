@@ -0,0 +1,375 @@
+// Package server exposes the monitored repositories over an HTTP JSON API,
+// so other tools and dashboards can read their status and diffs and ask for
+// them to be refreshed or fetched.
+//
+// GET / serves a dashboard page that shows the repositories, their changed
+// files, and diffs in the TUI's three-pane layout. The API's endpoints are:
+//
+//	GET  /api/repos                  status of every repository
+//	GET  /api/status?repo=R          status of one repository
+//	GET  /api/diff?repo=R[&file=F]   diffs of a repository's changed files, or of F
+//	POST /api/refresh[?repo=R]       re-check one or every repository
+//	POST /api/fetch[?repo=R]         fetch one or every repository in the background
+//
+// R is a repository's path or, if unambiguous, its directory name. Push
+// webhooks from GitHub and GitLab are received under /hooks/; see
+// HooksHandler.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cwsaylor/gitmoni/internal/crash"
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+//go:embed web/index.html
+var dashboard []byte
+
+// Server serves the API for a set of repositories.
+type Server struct {
+	Store *gitstatus.Store
+	Repos []string
+	// Token, if set, must be sent as "Authorization: Bearer <token>".
+	Token string
+	// HookSecret validates push webhooks, which are rejected if it is empty.
+	HookSecret string
+	// ReadOnly refuses requests that fetch, through the API or webhooks, so
+	// clients can only look.
+	ReadOnly bool
+	// AutoPull, if set, says which repositories to fast-forward after a
+	// fetch leaves them behind with a clean working tree.
+	AutoPull func(repo string) bool
+	// Fetched, if set, is called after each successful fetch, before any
+	// auto-pull, on the goroutine that fetched.
+	Fetched func(ctx context.Context, repo string)
+
+	ctx      context.Context
+	workers  sync.WaitGroup
+	mu       sync.Mutex
+	fetching map[string]bool
+}
+
+// New returns a server for repos, whose statuses are kept in store.
+// Background fetches are cancelled when ctx is done.
+func New(ctx context.Context, store *gitstatus.Store, repos []string, token string) *Server {
+	return &Server{
+		Store:    store,
+		Repos:    repos,
+		Token:    token,
+		ctx:      ctx,
+		fetching: make(map[string]bool),
+	}
+}
+
+// Wait waits for background fetches to finish.
+func (s *Server) Wait() {
+	s.workers.Wait()
+}
+
+// Handler returns the HTTP handler for the dashboard, the API, and push
+// webhooks. The dashboard page holds no data, so it is served without the
+// token; it asks for one when the API requires it. Webhooks carry their own
+// proof of HookSecret instead of the token.
+func (s *Server) Handler() http.Handler {
+	api := http.NewServeMux()
+	api.HandleFunc("GET /api/repos", s.handleRepos)
+	api.HandleFunc("GET /api/status", s.handleStatus)
+	api.HandleFunc("GET /api/diff", s.handleDiff)
+	api.HandleFunc("POST /api/refresh", s.handleRefresh)
+	api.HandleFunc("POST /api/fetch", s.handleFetch)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", handleDashboard)
+	mux.Handle("/api/", s.authorize(api))
+	mux.Handle("/hooks/", s.HooksHandler())
+	return mux
+}
+
+func handleDashboard(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "default-src 'self'; script-src 'unsafe-inline'; style-src 'unsafe-inline'")
+	w.Write(dashboard)
+}
+
+// authorize rejects requests without the bearer token, if one is set. The
+// Gitmoni-Read-Only header tells the dashboard to hide what it can't do.
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.ReadOnly {
+			w.Header().Set("Gitmoni-Read-Only", "true")
+		}
+		if s.Token != "" {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.Token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="gitmoni"`)
+				writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Repo is the JSON form of a repository's status.
+type Repo struct {
+	Path         string `json:"path"`
+	Name         string `json:"name"`
+	Branch       string `json:"branch"`
+	Files        []File `json:"files"`
+	Error        string `json:"error,omitempty"`
+	HasRemote    bool   `json:"has_remote"`
+	NeedsPull    bool   `json:"needs_pull"`
+	RemoteStatus string `json:"remote_status"`
+	Fetching     bool   `json:"fetching"`
+}
+
+// File is a changed file, with its diff if requested.
+type File struct {
+	Path   string `json:"path"`
+	Status string `json:"status"`
+	Diff   string `json:"diff,omitempty"`
+}
+
+// NewRepo returns the JSON form of status.
+func NewRepo(status gitstatus.Status) Repo {
+	r := Repo{
+		Path:         status.Path,
+		Name:         filepath.Base(status.Path),
+		Branch:       status.Branch,
+		Files:        []File{},
+		Error:        status.Error,
+		HasRemote:    status.HasRemote,
+		NeedsPull:    status.NeedsPull,
+		RemoteStatus: status.RemoteStatus,
+	}
+	for _, f := range status.Files {
+		r.Files = append(r.Files, File{Path: f.Path, Status: f.Status})
+	}
+	return r
+}
+
+func (s *Server) repo(path string) Repo {
+	status, _ := s.Store.Status(path)
+	status.Path = path
+	r := NewRepo(status)
+	s.mu.Lock()
+	r.Fetching = s.fetching[path]
+	s.mu.Unlock()
+	return r
+}
+
+func (s *Server) handleRepos(w http.ResponseWriter, r *http.Request) {
+	repos := make([]Repo, 0, len(s.Repos))
+	for _, path := range s.Repos {
+		repos = append(repos, s.repo(path))
+	}
+	writeJSON(w, http.StatusOK, repos)
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	path, err := Lookup(s.Repos, r.URL.Query().Get("repo"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, s.repo(path))
+}
+
+func (s *Server) handleDiff(w http.ResponseWriter, r *http.Request) {
+	path, err := Lookup(s.Repos, r.URL.Query().Get("repo"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	repo := s.repo(path)
+	file := r.URL.Query().Get("file")
+
+	// Only changed files are diffed, so the API can't be used to read
+	// arbitrary files
+	var files []File
+	for _, f := range repo.Files {
+		if file != "" && f.Path != file {
+			continue
+		}
+		diff, err := s.Store.FileDiff(path, f.Path)
+		if err != nil {
+			diff = "Error getting diff: " + gitstatus.ErrorSummary(err)
+		}
+		f.Diff = diff
+		files = append(files, f)
+	}
+	if file != "" && len(files) == 0 {
+		writeError(w, http.StatusNotFound, "file has no changes: "+file)
+		return
+	}
+	repo.Files = files
+	writeJSON(w, http.StatusOK, repo)
+}
+
+func (s *Server) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	paths, err := s.targets(r)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	s.Store.RefreshAll(paths)
+	repos := make([]Repo, 0, len(paths))
+	for _, path := range paths {
+		repos = append(repos, s.repo(path))
+	}
+	writeJSON(w, http.StatusOK, repos)
+}
+
+// handleFetch starts fetching the requested repositories and returns
+// without waiting; clients poll the status, whose fetching field is set
+// until the fetch completes.
+func (s *Server) handleFetch(w http.ResponseWriter, r *http.Request) {
+	if s.ReadOnly {
+		writeError(w, http.StatusForbidden, "the server is read-only")
+		return
+	}
+	paths, err := s.targets(r)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string][]string{"fetching": s.FetchBatch(paths)})
+}
+
+// Fetch fetches repo in the background unless a fetch of it is already
+// running, and reports whether it started one.
+func (s *Server) Fetch(repo string) bool {
+	return s.fetch(s.ctx, repo, nil)
+}
+
+// FetchBatch fetches repos like Fetch, traced as one batch, and returns
+// the ones it started fetching.
+func (s *Server) FetchBatch(repos []string) []string {
+	ctx, span := gitstatus.StartSpan(s.ctx, "fetch batch")
+	var batch sync.WaitGroup
+	started := []string{}
+	for _, repo := range repos {
+		if s.fetch(ctx, repo, &batch) {
+			started = append(started, repo)
+		}
+	}
+	if span != nil {
+		span.SetAttr("repos", strconv.Itoa(len(started)))
+		go func() {
+			batch.Wait()
+			span.Finish(nil)
+		}()
+	}
+	return started
+}
+
+// autoPull fast-forwards repo if it is behind with a clean working tree.
+func (s *Server) autoPull(ctx context.Context, repo string) {
+	status, _ := s.Store.Status(repo)
+	if !status.CleanBehind() {
+		return
+	}
+	if err := s.Store.Run(ctx, repo, gitstatus.Pull); err != nil {
+		slog.Warn("auto-pull failed", "repo", repo, "err", err)
+		return
+	}
+	slog.Info("auto-pulled", "repo", repo, "branch", status.Branch, "commits", status.Behind)
+}
+
+// fetch starts fetching repo, adding it to batch if that isn't nil.
+func (s *Server) fetch(ctx context.Context, repo string, batch *sync.WaitGroup) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fetching[repo] {
+		return false
+	}
+	s.fetching[repo] = true
+	s.workers.Add(1)
+	if batch != nil {
+		batch.Add(1)
+	}
+	go func() {
+		defer s.workers.Done()
+		if batch != nil {
+			defer batch.Done()
+		}
+		defer crash.Capture()
+		if err := s.Store.Fetch(ctx, repo); gitstatus.IsSkipped(err) {
+			slog.Info("fetch skipped", "repo", repo, "reason", gitstatus.ErrorSummary(err))
+		} else if err != nil {
+			slog.Warn("fetch failed", "repo", repo, "err", err)
+		} else {
+			if s.Fetched != nil {
+				s.Fetched(ctx, repo)
+			}
+			if s.AutoPull != nil && s.AutoPull(repo) {
+				s.autoPull(ctx, repo)
+			}
+		}
+		s.mu.Lock()
+		delete(s.fetching, repo)
+		s.mu.Unlock()
+	}()
+	return true
+}
+
+// targets returns the repository named by the request's repo parameter, or
+// every repository if there is none.
+func (s *Server) targets(r *http.Request) ([]string, error) {
+	name := r.URL.Query().Get("repo")
+	if name == "" {
+		return s.Repos, nil
+	}
+	path, err := Lookup(s.Repos, name)
+	if err != nil {
+		return nil, err
+	}
+	return []string{path}, nil
+}
+
+// Lookup finds the repository in repos whose path is name or, failing that,
+// the only one whose directory is called name.
+func Lookup(repos []string, name string) (string, error) {
+	if name == "" {
+		return "", errors.New("missing repo parameter")
+	}
+	var matches []string
+	for _, path := range repos {
+		if path == name {
+			return path, nil
+		}
+		if filepath.Base(path) == name {
+			matches = append(matches, path)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", errors.New("unknown repository: " + name)
+	case 1:
+		return matches[0], nil
+	}
+	return "", errors.New("ambiguous repository name, use its path: " + name)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, map[string]string{"error": msg})
+}
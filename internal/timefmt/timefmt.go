@@ -0,0 +1,155 @@
+// Package timefmt formats timestamps for display as the time_format and
+// clock settings ask: how long ago ("5m ago"), as a date and time in the
+// style of the system locale, or as ISO 8601.
+package timefmt
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Format is a way of showing timestamps.
+type Format struct {
+	style  string // "relative", "absolute", or "iso"
+	hour12 bool
+	date   string // layout of dates
+}
+
+// New returns the Format for the time_format and clock settings. An empty
+// or unknown style is "relative", and an empty clock follows the locale.
+func New(style, clock string) Format {
+	lang, region := locale()
+	f := Format{style: style, date: dateLayout(lang, region)}
+	if style != "absolute" && style != "iso" {
+		f.style = "relative"
+	}
+	switch clock {
+	case "12h":
+		f.hour12 = true
+	case "24h":
+	default:
+		f.hour12 = slices.Contains(hour12Regions, region)
+	}
+	if f.style == "iso" {
+		f.date, f.hour12 = "2006-01-02", false
+	}
+	return f
+}
+
+// Relative reports whether timestamps are shown as how long ago they were.
+func (f Format) Relative() bool { return f.style == "relative" }
+
+// Age shows when t was: how long ago, compactly ("5m ago", "3d ago"), or
+// its date and time.
+func (f Format) Age(t time.Time) string {
+	if f.Relative() {
+		return ago(time.Since(t))
+	}
+	return f.DateTime(t)
+}
+
+// Short is Age in at most 10 columns, for tables: the date alone when it
+// isn't today.
+func (f Format) Short(t time.Time) string {
+	if f.Relative() {
+		return ago(time.Since(t))
+	}
+	t = t.Local()
+	if y, m, d := time.Now().Date(); t.Year() == y && t.Month() == m && t.Day() == d {
+		return f.Clock(t)
+	}
+	return t.Format(f.date)
+}
+
+// Date shows t's date, whatever the style.
+func (f Format) Date(t time.Time) string {
+	return t.Local().Format(f.date)
+}
+
+// DateTime shows t's date and time to the minute, whatever the style.
+func (f Format) DateTime(t time.Time) string {
+	t = t.Local()
+	return t.Format(f.date) + " " + f.Clock(t)
+}
+
+// When shows t's date and time, followed by how long ago it was in the
+// relative style, e.g. "2026-03-02 15:04 (5m ago)".
+func (f Format) When(t time.Time) string {
+	if f.Relative() {
+		return f.DateTime(t) + " (" + ago(time.Since(t)) + ")"
+	}
+	return f.DateTime(t)
+}
+
+// Clock shows t's time of day to the minute.
+func (f Format) Clock(t time.Time) string {
+	if f.hour12 {
+		return t.Local().Format("3:04 PM")
+	}
+	return t.Local().Format("15:04")
+}
+
+// ClockSeconds shows t's time of day to the second.
+func (f Format) ClockSeconds(t time.Time) string {
+	if f.hour12 {
+		return t.Local().Format("3:04:05 PM")
+	}
+	return t.Local().Format("15:04:05")
+}
+
+// ago renders d compactly, e.g. "5m ago".
+func ago(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	case d < 30*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	case d < 365*24*time.Hour:
+		return fmt.Sprintf("%dmo ago", int(d.Hours()/24/30))
+	default:
+		return fmt.Sprintf("%dy ago", int(d.Hours()/24/365))
+	}
+}
+
+// Regions where clocks usually show 12 hours with AM and PM.
+var hour12Regions = []string{"US", "CA", "AU", "NZ", "PH", "IN", "PK", "BD", "EG", "SA", "MY"}
+
+// locale returns the language and region of the locale times are shown
+// in, e.g. "en" and "US" for en_US.UTF-8. Both are empty for the C locale.
+func locale() (lang, region string) {
+	name := ""
+	for _, v := range []string{"LC_ALL", "LC_TIME", "LANG"} {
+		if name = os.Getenv(v); name != "" {
+			break
+		}
+	}
+	name, _, _ = strings.Cut(name, ".")
+	name, _, _ = strings.Cut(name, "@")
+	if name == "C" || name == "POSIX" {
+		return "", ""
+	}
+	lang, region, _ = strings.Cut(name, "_")
+	return lang, region
+}
+
+// dateLayout returns the usual numeric date layout of a locale.
+func dateLayout(lang, region string) string {
+	switch {
+	case lang == "":
+		return "2006-01-02"
+	case region == "US" || region == "PH":
+		return "1/2/2006"
+	case slices.Contains([]string{"zh", "ja", "ko", "sv", "lt", "hu"}, lang):
+		return "2006-01-02"
+	case slices.Contains([]string{"de", "ru", "pl", "cs", "sk", "fi", "nb", "nn", "da", "tr", "uk", "ro", "bg", "hr", "sl", "et", "lv"}, lang):
+		return "02.01.2006"
+	}
+	return "02/01/2006"
+}
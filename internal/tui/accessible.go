@@ -0,0 +1,86 @@
+package tui
+
+// The accessible setting makes the TUI easier to follow with a screen
+// reader or braille display: nothing animates, states are spelled out in
+// words rather than shown by icons or colour alone, and repositories keep
+// their place in the list as their states change.
+
+// iconStyle returns the icon style to draw with; accessible mode always
+// uses words.
+func (m *model) iconStyle() string {
+	if m.config.Accessible {
+		return "text"
+	}
+	return m.config.IconStyle
+}
+
+// focusName names the focused pane, which is otherwise only shown by the
+// colour of its border.
+func (m *model) focusName() string {
+	switch m.focused {
+	case focusFile:
+		if m.side != nil {
+			return m.side.title()
+		}
+		return "Changed Files"
+	case focusDiff:
+		return "Diff"
+	case focusActivity:
+		return "Activity Log"
+	}
+	return "Repositories"
+}
+
+// resultMarks returns the markers of a task's success and failure.
+func resultMarks(iconStyle string) (ok, failed string) {
+	switch iconStyle {
+	case "text":
+		return "done:", "failed:"
+	case "ascii":
+		return "ok:", "x:"
+	}
+	return "✓", "✗"
+}
+
+// ciMark returns the mark of a CI state, "success", "failure", or
+// "running", in the badge after a repo's name.
+func ciMark(state, iconStyle string) string {
+	marks := map[string]string{"success": "✓", "failure": "✗", "running": "…"}
+	switch iconStyle {
+	case "text":
+		marks = map[string]string{"success": "passed", "failure": "failed", "running": "running"}
+	case "ascii":
+		marks = map[string]string{"success": "ok", "failure": "x", "running": "..."}
+	}
+	return marks[state]
+}
+
+// dirMark returns the mark of an expanded or collapsed directory in the
+// file list.
+func dirMark(collapsed bool, iconStyle string) string {
+	switch iconStyle {
+	case "text":
+		if collapsed {
+			return "collapsed"
+		}
+		return "expanded"
+	case "ascii":
+		if collapsed {
+			return "+"
+		}
+		return "-"
+	}
+	if collapsed {
+		return "▸"
+	}
+	return "▾"
+}
+
+// wrapMark returns the mark starting each continuation of a wrapped diff
+// line, as wide as its indent.
+func wrapMark(iconStyle string) string {
+	if iconStyle == "text" || iconStyle == "ascii" {
+		return "> "
+	}
+	return "↪ "
+}
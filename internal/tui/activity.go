@@ -0,0 +1,106 @@
+package tui
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/cwsaylor/gitmoni/internal/timefmt"
+)
+
+// maxActivityEntries caps the activity log so long-running sessions don't
+// grow without bound. Oldest entries are dropped first.
+const maxActivityEntries = 500
+
+// activityPaneHeight is the number of content lines shown by the activity
+// log pane when it is visible.
+const activityPaneHeight = 8
+
+// activityEntry is a single line in the activity log.
+type activityEntry struct {
+	time    time.Time
+	repo    string
+	message string
+	isError bool
+}
+
+// activityLog records fetch results, action outcomes, and timings so they
+// remain visible after the repo that produced them is no longer selected.
+type activityLog struct {
+	entries []activityEntry
+}
+
+// add appends an informational entry. repo may be empty for global events.
+func (l *activityLog) add(repo, format string, args ...any) {
+	l.append(activityEntry{time: time.Now(), repo: repo, message: fmt.Sprintf(format, args...)})
+}
+
+// addError appends an error entry. repo may be empty for global events.
+func (l *activityLog) addError(repo, format string, args ...any) {
+	l.append(activityEntry{time: time.Now(), repo: repo, message: fmt.Sprintf(format, args...), isError: true})
+}
+
+// append records entry and mirrors it to the structured log.
+func (l *activityLog) append(entry activityEntry) {
+	if entry.isError {
+		slog.Warn(entry.message, "repo", entry.repo)
+	} else {
+		slog.Info(entry.message, "repo", entry.repo)
+	}
+	l.entries = append(l.entries, entry)
+	if len(l.entries) > maxActivityEntries {
+		l.entries = l.entries[len(l.entries)-maxActivityEntries:]
+	}
+}
+
+// render formats all entries oldest-first, one per line.
+func (l *activityLog) render() string {
+	if len(l.entries) == 0 {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("#737994")).Render("No activity yet")
+	}
+
+	timeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#737994"))  // Overlay0
+	repoStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#babbf1"))  // Lavender
+	errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#e78284")) // Red
+
+	lines := make([]string, 0, len(l.entries))
+	for _, e := range l.entries {
+		line := timeStyle.Render(timeFormat.ClockSeconds(e.time)) + " "
+		if e.repo != "" {
+			line += repoStyle.Render(filepath.Base(e.repo)) + ": "
+		}
+		if e.isError {
+			line += errorStyle.Render(e.message)
+		} else {
+			line += e.message
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatDuration renders a duration rounded to a precision that is useful
+// for humans reading the log (e.g. "850ms", "1.2s", "8s").
+func formatDuration(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return d.Round(time.Millisecond).String()
+	case d < 10*time.Second:
+		return d.Round(100 * time.Millisecond).String()
+	default:
+		return d.Round(time.Second).String()
+	}
+}
+
+// timeFormat is how timestamps are shown, as set by time_format and clock.
+var timeFormat = timefmt.New("", "")
+
+// formatAge renders when t was as configured, by default how long ago in a
+// compact form ("5m ago", "3d ago"), for list descriptions.
+func formatAge(t time.Time) string {
+	return timeFormat.Age(t)
+}
@@ -0,0 +1,121 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/cwsaylor/gitmoni/pkg/config"
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// changeItem is a changed file in the all-changes pane.
+type changeItem struct {
+	repo    string
+	file    gitstatus.File
+	modTime time.Time // zero if unknown, e.g. on another host
+}
+
+func (i changeItem) FilterValue() string { return filepath.Base(i.repo) + "/" + i.file.Path }
+
+func (i changeItem) Title() string {
+	name := lipgloss.NewStyle().Foreground(lipgloss.Color("#8caaee")).Render(filepath.Base(i.repo)) // Blue
+	return fmt.Sprintf("%s %s %s", name, i.file.Status, i.file.Path)
+}
+
+func (i changeItem) Description() string {
+	desc := getStatusDescription(i.file.Status)
+	if !i.modTime.IsZero() {
+		desc += " • modified " + formatAge(i.modTime)
+	}
+	return desc
+}
+
+// allChangesPane lists the changed files of every repository, in the order
+// of the repositories or newest first, so they can be reviewed in one
+// place. Enter goes to the selected file in its repository.
+type allChangesPane struct {
+	store  *gitstatus.Store
+	config *config.Config
+	byTime bool // newest first rather than by repository
+}
+
+func (allChangesPane) title() string { return "All Changes" }
+
+func (p allChangesPane) load(string) ([]list.Item, error) {
+	var items []changeItem
+	for _, repo := range p.config.Repositories {
+		status, ok := p.store.Status(repo)
+		if !ok || status.HasError {
+			continue
+		}
+		files := slices.Clone(status.Files)
+		slices.SortFunc(files, func(a, b gitstatus.File) int { return strings.Compare(a.Path, b.Path) })
+		for _, f := range files {
+			item := changeItem{repo: repo, file: f}
+			if !gitstatus.IsSSH(repo) {
+				path := filepath.Join(gitstatus.RepoDir(repo), filepath.FromSlash(strings.TrimSuffix(f.Path, "/")))
+				if info, err := os.Lstat(path); err == nil {
+					item.modTime = info.ModTime()
+				}
+			}
+			items = append(items, item)
+		}
+	}
+	if p.byTime {
+		// Deleted files and those on other hosts have no time and go last
+		slices.SortStableFunc(items, func(a, b changeItem) int { return b.modTime.Compare(a.modTime) })
+	}
+	result := make([]list.Item, len(items))
+	for i, item := range items {
+		result[i] = item
+	}
+	return result, nil
+}
+
+func (p allChangesPane) detail(_ string, item list.Item) string {
+	change := item.(changeItem)
+	diff, err := p.store.FileDiff(change.repo, change.file.Path)
+	if err != nil {
+		return fmt.Sprintf("Error getting diff: %s", err.Error())
+	}
+	if diff == "" {
+		return fmt.Sprintf("No diff available for: %s", change.file.Path)
+	}
+	return applySyntaxHighlighting(diff, change.file.Path)
+}
+
+func (p allChangesPane) handleKey(m *model, _ string, item list.Item, key string) (tea.Cmd, bool) {
+	switch key {
+	case "o":
+		// Switch between repository order and newest first
+		p.byTime = !p.byTime
+		m.side = p
+		m.loadSidePane(false)
+		return nil, true
+	case "enter":
+		if item == nil {
+			return nil, false
+		}
+		change := item.(changeItem)
+		for i, listed := range m.repoList.Items() {
+			if listed.(repoItem).path == change.repo {
+				m.side = nil
+				m.sideList.SetItems(nil)
+				m.selectRepo(i)
+				m.closeDiffModes()
+				m.selectFilePath(change.file.Path)
+				return nil, true
+			}
+		}
+		return m.notify(filepath.Base(change.repo)+" isn't in the list", true), true
+	}
+	return nil, false
+}
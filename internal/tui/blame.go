@@ -0,0 +1,170 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// blameView is the blame mode of the diff pane: a cursor over the blamed
+// lines of a file, with the option to open the commit behind a line.
+type blameView struct {
+	repo          string
+	file          string
+	lines         []gitstatus.BlameLine
+	cursor        int
+	showingCommit bool // the diff pane shows the commit under the cursor
+}
+
+// blameAgeColor maps a line's age to a heat-map colour: recent changes are
+// hot (red), old ones cool (blue).
+func blameAgeColor(date time.Time) lipgloss.Color {
+	age := time.Since(date)
+	switch {
+	case age < 7*24*time.Hour:
+		return lipgloss.Color("#e78284") // Red
+	case age < 30*24*time.Hour:
+		return lipgloss.Color("#ef9f76") // Peach
+	case age < 90*24*time.Hour:
+		return lipgloss.Color("#e5c890") // Yellow
+	case age < 365*24*time.Hour:
+		return lipgloss.Color("#a6d189") // Green
+	default:
+		return lipgloss.Color("#8caaee") // Blue
+	}
+}
+
+// render formats all blame lines, highlighting the one under the cursor.
+func (b *blameView) render() string {
+	gutterStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#737994")) // Overlay0
+	cursorStyle := lipgloss.NewStyle().Background(lipgloss.Color("#414559")) // Surface0
+	width := len(strconv.Itoa(len(b.lines)))
+	ageWidth := 8
+	if !timeFormat.Relative() {
+		ageWidth = 10
+	}
+
+	out := make([]string, 0, len(b.lines))
+	for i, l := range b.lines {
+		var info string
+		if l.IsUncommitted() {
+			info = fmt.Sprintf("%-7s %-12s %*s", "-------", "Not committed", ageWidth, "")
+		} else {
+			// Authors' names may hold wide characters, so fit them by cells
+			info = fmt.Sprintf("%.7s %s %*s", l.Hash, fitCells(l.Author, 12), ageWidth, timeFormat.Short(l.Date))
+		}
+		info = lipgloss.NewStyle().Foreground(blameAgeColor(l.Date)).Render(info)
+		line := fmt.Sprintf("%s %s %s", info, gutterStyle.Render(fmt.Sprintf("%*d │", width, l.Number)), l.Content)
+		if i == b.cursor {
+			line = cursorStyle.Render(ansi.Strip(line))
+		}
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
+
+// openBlame switches the diff pane into blame mode for the selected file.
+func (m *model) openBlame() error {
+	item, ok := m.fileList.SelectedItem().(fileItem)
+	if !ok {
+		return nil
+	}
+	repo := m.selectedRepoPath()
+	lines, err := gitstatus.Blame(repo, item.gitFile.Path)
+	if err != nil {
+		return err
+	}
+	m.blame = &blameView{repo: repo, file: item.gitFile.Path, lines: lines}
+	m.focused = focusDiff
+	m.renderBlame()
+	return nil
+}
+
+// reloadBlame blames the file in blame mode again, for when it or the
+// repository changed, keeping the cursor on the same line where it can. The
+// commit under the cursor is left open if it is shown. If the file can't be
+// blamed any more, its diff is shown instead.
+func (m *model) reloadBlame() {
+	b := m.blame
+	lines, err := gitstatus.Blame(b.repo, b.file)
+	if err != nil {
+		m.blame = nil
+		m.updateDiff()
+		return
+	}
+	b.lines = lines
+	b.cursor = max(min(b.cursor, len(lines)-1), 0)
+	if !b.showingCommit {
+		m.renderBlame()
+	}
+}
+
+// renderBlame redraws the blame view and keeps the cursor on screen.
+func (m *model) renderBlame() {
+	offset := m.diffView.YOffset
+	m.setDiffContent(m.blame.render())
+	height := max(m.diffView.Height, 1)
+	switch {
+	case m.blame.cursor < offset:
+		offset = m.blame.cursor
+	case m.blame.cursor >= offset+height:
+		offset = m.blame.cursor - height + 1
+	}
+	m.diffView.SetYOffset(offset)
+}
+
+// handleBlameKey handles keys while the diff pane is focused in blame mode.
+// It reports whether the key was consumed.
+func (m *model) handleBlameKey(key string) bool {
+	b := m.blame
+	if b.showingCommit {
+		if key == "esc" {
+			b.showingCommit = false
+			m.renderBlame()
+			return true
+		}
+		return false
+	}
+
+	page := max(m.diffView.Height-1, 1)
+	switch key {
+	case "up", "k":
+		b.cursor = max(b.cursor-1, 0)
+	case "down", "j":
+		b.cursor = min(b.cursor+1, len(b.lines)-1)
+	case "pgup":
+		b.cursor = max(b.cursor-page, 0)
+	case "pgdown":
+		b.cursor = min(b.cursor+page, len(b.lines)-1)
+	case "home", "g":
+		b.cursor = 0
+	case "end", "G":
+		b.cursor = len(b.lines) - 1
+	case "enter":
+		if b.cursor < len(b.lines) && !b.lines[b.cursor].IsUncommitted() {
+			b.showingCommit = true
+			m.setDiffContent(commitDetail(b.repo, b.lines[b.cursor].Hash))
+		}
+		return true
+	case "esc", "b":
+		m.closeBlame()
+		return true
+	default:
+		return false
+	}
+	b.cursor = max(b.cursor, 0)
+	m.renderBlame()
+	return true
+}
+
+// closeBlame leaves blame mode and restores the file's diff.
+func (m *model) closeBlame() {
+	m.blame = nil
+	m.updateDiff()
+}
@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+type remoteBranchItem struct {
+	branch gitstatus.RemoteBranch
+}
+
+func (i remoteBranchItem) FilterValue() string { return i.branch.Name }
+
+func (i remoteBranchItem) Title() string {
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("#a6d189")).Render(i.branch.Name) // Green
+}
+
+func (i remoteBranchItem) Description() string {
+	return fmt.Sprintf("%s • %s • %s", i.branch.Author, formatAge(i.branch.Date), i.branch.Subject)
+}
+
+// remoteBranchesPane lists remote-tracking branches so a teammate's branch
+// can be checked out locally.
+type remoteBranchesPane struct{}
+
+func (remoteBranchesPane) title() string { return "Remote branches" }
+
+func (remoteBranchesPane) load(repo string) ([]list.Item, error) {
+	branches, err := gitstatus.RemoteBranches(repo)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]list.Item, 0, len(branches))
+	for _, b := range branches {
+		items = append(items, remoteBranchItem{branch: b})
+	}
+	return items, nil
+}
+
+func (remoteBranchesPane) detail(repo string, item list.Item) string {
+	return commitDetail(repo, "refs/remotes/"+item.(remoteBranchItem).branch.Name)
+}
+
+func (remoteBranchesPane) handleKey(m *model, repo string, item list.Item, key string) (tea.Cmd, bool) {
+	if item == nil || key != "o" {
+		return nil, false
+	}
+	// Check out the branch as a new local tracking branch
+	branch := item.(remoteBranchItem).branch
+	err := gitstatus.CheckoutRemoteBranch(repo, branch.Name)
+	m.refreshRepoStatus(repo)
+	return m.actionResult(repo, fmt.Sprintf("Checked out %s as a local branch", branch.Name), "Checkout failed", err), true
+}
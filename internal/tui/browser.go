@@ -0,0 +1,230 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// dirBrowser is a modal for finding a repository to add by walking the
+// directory tree, opened from the add repository prompt with Ctrl+O. While
+// it is open it receives all key events; Esc goes back to the prompt.
+type dirBrowser struct {
+	dir     string
+	entries []browserEntry
+	cursor  int
+	offset  int // first visible entry
+	hidden  bool
+	err     error
+	prompt  *inputModal // the prompt to return to
+}
+
+// browserEntry is a subdirectory listed by the browser.
+type browserEntry struct {
+	name      string
+	repo      bool // contains a .git directory or file
+	monitored bool
+}
+
+// openBrowser replaces the open prompt with a directory browser starting at
+// the directory typed so far, or the home directory.
+func (m *model) openBrowser(value string) {
+	dir := os.Getenv("HOME")
+	if value = expandHome(strings.TrimSpace(value)); value != "" {
+		for _, candidate := range []string{value, filepath.Dir(value)} {
+			if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+				dir = candidate
+				break
+			}
+		}
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	m.browser = &dirBrowser{prompt: m.prompt}
+	m.prompt = nil
+	m.browser.load(dir, m.config.Repositories)
+}
+
+// load lists dir's subdirectories, placing the cursor on the first one.
+func (b *dirBrowser) load(dir string, monitored []string) {
+	b.dir, b.cursor, b.offset = dir, 0, 0
+	b.entries = nil
+	entries, err := os.ReadDir(dir)
+	b.err = err
+	for _, e := range entries {
+		if !e.IsDir() && e.Type()&os.ModeSymlink == 0 {
+			continue
+		}
+		if strings.HasPrefix(e.Name(), ".") && !b.hidden {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		if info, err := os.Stat(path); err != nil || !info.IsDir() {
+			continue
+		}
+		_, err := os.Lstat(filepath.Join(path, ".git"))
+		b.entries = append(b.entries, browserEntry{
+			name:      e.Name(),
+			repo:      err == nil,
+			monitored: slices.Contains(monitored, path),
+		})
+	}
+}
+
+// selected returns the path of the entry under the cursor, or "" if the
+// directory has no subdirectories.
+func (b *dirBrowser) selected() string {
+	if b.cursor >= len(b.entries) {
+		return ""
+	}
+	return filepath.Join(b.dir, b.entries[b.cursor].name)
+}
+
+// handleBrowserKey processes a key event while the directory browser is open.
+func (m *model) handleBrowserKey(msg tea.KeyMsg) tea.Cmd {
+	b := m.browser
+	switch msg.String() {
+	case "esc":
+		// Back to the prompt, with the directory browsed to filled in
+		m.browser = nil
+		m.prompt = b.prompt
+		value := displayPath(b.dir)
+		if !strings.HasSuffix(value, string(filepath.Separator)) {
+			value += string(filepath.Separator)
+		}
+		m.prompt.setValue(value)
+		m.prompt.err = ""
+	case "ctrl+c":
+		return tea.Quit
+	case "up", "k":
+		b.cursor = max(b.cursor-1, 0)
+	case "down", "j":
+		b.cursor = min(b.cursor+1, max(len(b.entries)-1, 0))
+	case "pgup":
+		b.cursor = max(b.cursor-m.browserHeight(), 0)
+	case "pgdown":
+		b.cursor = min(b.cursor+m.browserHeight(), max(len(b.entries)-1, 0))
+	case "home", "g":
+		b.cursor = 0
+	case "end", "G":
+		b.cursor = max(len(b.entries)-1, 0)
+	case "right", "l":
+		if path := b.selected(); path != "" {
+			b.load(path, m.config.Repositories)
+		}
+	case "left", "h", "backspace":
+		parent := filepath.Dir(b.dir)
+		if parent == b.dir {
+			return nil
+		}
+		from := filepath.Base(b.dir)
+		b.load(parent, m.config.Repositories)
+		for i, e := range b.entries {
+			if e.name == from {
+				b.cursor = i
+			}
+		}
+	case "~":
+		b.load(os.Getenv("HOME"), m.config.Repositories)
+	case ".":
+		b.hidden = !b.hidden
+		selected := b.selected()
+		b.load(b.dir, m.config.Repositories)
+		for i := range b.entries {
+			if filepath.Join(b.dir, b.entries[i].name) == selected {
+				b.cursor = i
+			}
+		}
+	case "enter":
+		// Add a repository, or descend into any other directory
+		path := b.selected()
+		if path == "" {
+			return nil
+		}
+		if e := b.entries[b.cursor]; !e.repo || e.monitored {
+			b.load(path, m.config.Repositories)
+			return nil
+		}
+		cmd, err := m.addRepository(path)
+		if err != nil {
+			b.err = err
+			return nil
+		}
+		m.browser = nil
+		return cmd
+	}
+
+	// Keep the cursor in view
+	height := m.browserHeight()
+	if b.cursor < b.offset {
+		b.offset = b.cursor
+	} else if b.cursor >= b.offset+height {
+		b.offset = b.cursor - height + 1
+	}
+	return nil
+}
+
+// browserHeight is how many entries the browser shows at once.
+func (m *model) browserHeight() int {
+	return max(m.height-14, 3)
+}
+
+// renderBrowser renders the browser box.
+func (m *model) renderBrowser() string {
+	b := m.browser
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#ca9ee6")).Bold(true)  // Mauve
+	hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#737994"))              // Overlay0
+	repoStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#a6d189"))              // Green
+	cursorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#8caaee")).Bold(true) // Blue
+	width := min(70, max(m.width-12, 20))
+
+	parts := []string{titleStyle.Render("Add repository"), "", hintStyle.Render(truncateLeft(displayPath(b.dir), width))}
+	var rows []string
+	height := m.browserHeight()
+	for i := b.offset; i < len(b.entries) && i < b.offset+height; i++ {
+		e := b.entries[i]
+		name := e.name + string(filepath.Separator)
+		switch {
+		case e.monitored:
+			name += hintStyle.Render("  monitored")
+		case e.repo:
+			name = repoStyle.Render(name) + hintStyle.Render("  repository")
+		}
+		if i == b.cursor {
+			rows = append(rows, cursorStyle.Render("> ")+name)
+		} else {
+			rows = append(rows, "  "+name)
+		}
+	}
+	if len(b.entries) == 0 && b.err == nil {
+		rows = append(rows, hintStyle.Render("  No subdirectories"))
+	}
+	if len(b.entries) > height {
+		rows = append(rows, hintStyle.Render(fmt.Sprintf("  %d-%d of %d", b.offset+1, min(b.offset+height, len(b.entries)), len(b.entries))))
+	}
+	parts = append(parts, rows...)
+	if b.err != nil {
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#e78284")) // Red
+		parts = append(parts, "", errorStyle.Width(width).Render(b.err.Error()))
+	}
+
+	hint := "Enter to add or open • →/← in/out • . hidden • Esc to type a path"
+	parts = append(parts, "", hintStyle.Width(width).Render(hint))
+	return modalStyle().Width(width + 4).Render(lipgloss.JoinVertical(lipgloss.Left, parts...))
+}
+
+// truncateLeft shortens s to width cells by cutting from the start, which
+// keeps the most specific end of a path visible.
+func truncateLeft(s string, width int) string {
+	if ansi.StringWidth(s) <= width {
+		return s
+	}
+	return ansi.TruncateLeft(s, ansi.StringWidth(s)-width+1, "…")
+}
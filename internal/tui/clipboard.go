@@ -0,0 +1,72 @@
+package tui
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// copyHead copies the hash of repo's HEAD commit to the clipboard,
+// abbreviated or in full.
+func (m *model) copyHead(repo string, full bool) tea.Cmd {
+	commits, err := gitstatus.Log(repo, 1)
+	if err != nil {
+		return m.actionResult(repo, "", "Reading HEAD failed", err)
+	}
+	if len(commits) == 0 {
+		return m.notify("No commits to copy", true)
+	}
+	hash := commits[0].Short
+	if full {
+		hash = commits[0].Hash
+	}
+	err = copyToClipboard(m.terminal, hash)
+	return m.actionResult(repo, "Copied "+hash, "Copying failed", err)
+}
+
+// copyToClipboard puts text on the clipboard with the platform's clipboard
+// tool. Over SSH, or without such a tool, it asks the terminal to with an
+// OSC 52 sequence written to term, which most terminals, and tmux with
+// set-clipboard on, honour.
+func copyToClipboard(term io.Writer, text string) error {
+	if os.Getenv("SSH_TTY") == "" {
+		if cmd := clipboardCommand(); cmd != nil {
+			cmd.Stdin = strings.NewReader(text)
+			return cmd.Run()
+		}
+	}
+	_, err := io.WriteString(term, ansi.SetSystemClipboard(text))
+	return err
+}
+
+// clipboardCommand returns the command that copies its input on this
+// platform, or nil if none is installed.
+func clipboardCommand() *exec.Cmd {
+	var candidates [][]string
+	switch runtime.GOOS {
+	case "darwin":
+		candidates = [][]string{{"pbcopy"}}
+	case "windows":
+		candidates = [][]string{{"clip"}}
+	default:
+		if os.Getenv("WAYLAND_DISPLAY") != "" {
+			candidates = append(candidates, []string{"wl-copy"})
+		}
+		if os.Getenv("DISPLAY") != "" {
+			candidates = append(candidates, []string{"xclip", "-selection", "clipboard"}, []string{"xsel", "--clipboard", "--input"})
+		}
+	}
+	for _, c := range candidates {
+		if _, err := exec.LookPath(c[0]); err == nil {
+			return exec.Command(c[0], c[1:]...)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/cwsaylor/gitmoni/pkg/config"
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// commitOutputMsg carries a line printed by a commit's hooks or by git.
+type commitOutputMsg struct {
+	repo  string
+	line  string
+	lines <-chan string
+}
+
+// openCommitPrompt asks for a message, starting with message, and commits
+// repo's changes with it. The options start out as repo_settings has them
+// for repo.
+func (m *model) openCommitPrompt(repo, message string) {
+	settings := m.config.Settings(repo)
+	noVerify := &promptToggle{key: "alt+v", label: "skip hooks (--no-verify)", on: config.On(settings.CommitNoVerify)}
+	signoff := &promptToggle{key: "alt+s", label: "add Signed-off-by (--signoff)", on: config.On(settings.CommitSignoff)}
+	sign := &promptToggle{key: "alt+g", label: "sign (-S)", on: config.On(settings.CommitSign)}
+	m.openPrompt(promptOptions{
+		title:       "Commit " + filepath.Base(repo),
+		placeholder: "Commit message",
+		value:       message,
+		multiline:   true,
+		historyKey:  "commit",
+		toggles:     []*promptToggle{noVerify, signoff, sign},
+		onSubmit: func(m *model, value string) (tea.Cmd, error) {
+			if strings.TrimSpace(value) == "" {
+				return nil, errors.New("commit message is empty")
+			}
+			opts := gitstatus.CommitOptions{NoVerify: noVerify.on, Signoff: signoff.on, Sign: sign.on}
+			return m.startCommit(repo, value, opts), nil
+		},
+		edit: func(m *model, value string) tea.Cmd {
+			opts := gitstatus.CommitOptions{NoVerify: noVerify.on, Signoff: signoff.on, Sign: sign.on}
+			return m.editCommitMessage(repo, value, opts)
+		},
+	})
+}
+
+// commitEditedMsg is sent when the editor a commit message was written in
+// exits.
+type commitEditedMsg struct {
+	repo  string
+	path  string // the edited file
+	draft string // the message the editor started with
+	opts  gitstatus.CommitOptions
+	err   error
+}
+
+// editCommitMessage suspends the TUI to write repo's commit message in the
+// editor git uses, starting with message, as git commit does: below it are
+// what will be committed and its diff, and saving an empty message aborts.
+func (m *model) editCommitMessage(repo, message string, opts gitstatus.CommitOptions) tea.Cmd {
+	path, err := gitstatus.PrepareCommitMessage(repo, message)
+	if err != nil {
+		return m.actionResult(repo, "", "Opening the editor failed", err)
+	}
+	editor, err := gitstatus.EditorCommand(repo)
+	if err != nil {
+		return m.actionResult(repo, "", "Opening the editor failed", err)
+	}
+	// Like git, run the editor with the shell so it may have arguments
+	cmd := exec.Command("sh", "-c", editor+` "$@"`, editor, path)
+	if runtime.GOOS == "windows" {
+		parts := strings.Fields(editor)
+		cmd = exec.Command(parts[0], append(parts[1:], path)...)
+	}
+	cmd.Dir = gitstatus.RepoDir(repo)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return commitEditedMsg{repo: repo, path: path, draft: message, opts: opts, err: err}
+	})
+}
+
+// finishCommitEdit commits with the edited message. If the editor failed,
+// the commit prompt reopens with the draft so it isn't lost.
+func (m *model) finishCommitEdit(msg commitEditedMsg) tea.Cmd {
+	if msg.err != nil {
+		m.openCommitPrompt(msg.repo, msg.draft)
+		return m.actionResult(msg.repo, "", "The editor failed", msg.err)
+	}
+	message, err := gitstatus.ReadCommitMessage(msg.path)
+	if err != nil {
+		return m.actionResult(msg.repo, "", "Reading the commit message failed", err)
+	}
+	if message == "" {
+		return m.notify("Commit aborted: the message is empty", true)
+	}
+	return m.startCommit(msg.repo, message, msg.opts)
+}
+
+// ticketPattern matches an issue key such as "PROJ-123" in a branch name.
+var ticketPattern = regexp.MustCompile(`[A-Z][A-Z0-9]+-[0-9]+`)
+
+// commitTemplate returns the message a commit of repo starts with: the
+// commit_template of its repo_settings, or else the file named by git's
+// commit.template. {branch} and {ticket} in commit_template are replaced by
+// the current branch and the issue key in its name.
+func (m *model) commitTemplate(status gitstatus.Status) string {
+	template := m.config.Settings(status.Path).CommitTemplate
+	if template == "" {
+		return gitstatus.CommitTemplate(status.Path)
+	}
+	return strings.NewReplacer(
+		"{branch}", status.Branch,
+		"{ticket}", ticketPattern.FindString(status.Branch),
+	).Replace(template)
+}
+
+// startCommit commits repo's changes in the background. What the hooks and
+// git print is added to the activity log as it arrives, so a failing hook
+// can be read without leaving the TUI.
+func (m *model) startCommit(repo, message string, opts gitstatus.CommitOptions) tea.Cmd {
+	lines := make(chan string)
+	task := m.startAction(repo, "commit", "Committing", "Committed", func(ctx context.Context, repo string) error {
+		defer close(lines)
+		w := &lineWriter{ctx: ctx, lines: lines}
+		err := gitstatus.CreateCommit(ctx, repo, message, opts, w)
+		w.Flush()
+		return err
+	})
+	if task == nil {
+		return m.notify("A task is already running for "+filepath.Base(repo), true)
+	}
+	m.noteRecent(repo, "committed")
+	return tea.Batch(task, waitForOutput(repo, lines))
+}
+
+// waitForOutput returns the next line from lines as a commitOutputMsg.
+func waitForOutput(repo string, lines <-chan string) tea.Cmd {
+	return func() tea.Msg {
+		line, ok := <-lines
+		if !ok {
+			return nil
+		}
+		return commitOutputMsg{repo: repo, line: line, lines: lines}
+	}
+}
+
+// showCommitOutput adds a line of commit output to the activity log,
+// opening the log pane so it can be followed.
+func (m *model) showCommitOutput(msg commitOutputMsg) tea.Cmd {
+	m.activity.add(msg.repo, "%s", msg.line)
+	if !m.showActivity {
+		m.showActivity = true
+		m.resize()
+	}
+	m.refreshActivityView()
+	return waitForOutput(msg.repo, msg.lines)
+}
+
+// lineWriter sends what is written to it to lines one line at a time,
+// dropping blank lines and terminal carriage returns. It stops sending when
+// ctx is done, so a writer nobody reads from anymore can't block.
+type lineWriter struct {
+	ctx   context.Context
+	lines chan<- string
+	mu    sync.Mutex
+	buf   bytes.Buffer
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf.Write(p)
+	for {
+		line, err := w.buf.ReadString('\n')
+		if err != nil {
+			// Keep the partial line for the next write
+			w.buf.Reset()
+			w.buf.WriteString(line)
+			return len(p), nil
+		}
+		w.send(line)
+	}
+}
+
+// Flush sends a final line that wasn't terminated by a newline.
+func (w *lineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.send(w.buf.String())
+	w.buf.Reset()
+}
+
+func (w *lineWriter) send(line string) {
+	// Progress output redraws the line with \r; only its last state matters
+	if i := strings.LastIndex(strings.TrimRight(line, "\r\n"), "\r"); i >= 0 {
+		line = line[i+1:]
+	}
+	line = strings.TrimRight(line, " \t\r\n")
+	if line == "" {
+		return
+	}
+	select {
+	case w.lines <- line:
+	case <-w.ctx.Done():
+	}
+}
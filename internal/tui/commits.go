@@ -0,0 +1,59 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// commitLogLimit is how many commits the log pane loads.
+const commitLogLimit = 200
+
+type commitItem struct {
+	commit gitstatus.Commit
+}
+
+func (i commitItem) FilterValue() string { return i.commit.Subject }
+
+func (i commitItem) Title() string {
+	hash := lipgloss.NewStyle().Foreground(lipgloss.Color("#e5c890")).Render(i.commit.Short) // Yellow
+	return hash + " " + i.commit.Subject
+}
+
+func (i commitItem) Description() string {
+	return fmt.Sprintf("%s • %s", i.commit.Author, formatAge(i.commit.Date))
+}
+
+// commitLogPane lists recent commits on the selected repo's current branch.
+type commitLogPane struct{}
+
+func (commitLogPane) title() string { return "Commits" }
+
+func (commitLogPane) load(repo string) ([]list.Item, error) {
+	commits, err := gitstatus.Log(repo, commitLogLimit)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]list.Item, 0, len(commits))
+	for _, c := range commits {
+		items = append(items, commitItem{commit: c})
+	}
+	return items, nil
+}
+
+func (commitLogPane) detail(repo string, item list.Item) string {
+	return commitDetail(repo, item.(commitItem).commit.Hash)
+}
+
+// commitDetail renders a commit's full message, stat, and highlighted patch
+// for the diff pane.
+func commitDetail(repo, hash string) string {
+	show, err := gitstatus.Show(repo, hash)
+	if err != nil {
+		return fmt.Sprintf("Error loading commit %s: %s", hash, gitstatus.ErrorSummary(err))
+	}
+	return applySyntaxHighlighting(show, "")
+}
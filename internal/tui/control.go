@@ -0,0 +1,91 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/cwsaylor/gitmoni/internal/control"
+	"github.com/cwsaylor/gitmoni/internal/server"
+)
+
+// controlMsg is a request received on the control socket. The model sends
+// its response on reply, which must be buffered.
+type controlMsg struct {
+	req   control.Request
+	reply chan<- control.Response
+}
+
+// forwardControl returns a control handler that passes requests to the
+// program's model and waits for its response.
+func forwardControl(p *tea.Program) control.Handler {
+	return func(ctx context.Context, req control.Request) control.Response {
+		reply := make(chan control.Response, 1)
+		go p.Send(controlMsg{req: req, reply: reply})
+		select {
+		case resp := <-reply:
+			return resp
+		case <-ctx.Done():
+			return control.Errorf("gitmoni is shutting down")
+		case <-time.After(control.Timeout):
+			return control.Errorf("gitmoni did not respond")
+		}
+	}
+}
+
+// handleControl carries out a control request and replies to it.
+func (m *model) handleControl(msg controlMsg) tea.Cmd {
+	resp, cmd := m.control(msg.req)
+	if resp.Error == "" {
+		resp.OK = true
+	}
+	msg.reply <- resp
+	return cmd
+}
+
+func (m *model) control(req control.Request) (control.Response, tea.Cmd) {
+	repos, target := m.config.Repositories, ""
+	if req.Repo != "" {
+		repo, err := server.Lookup(repos, req.Repo)
+		if err != nil {
+			return control.Errorf("%v", err), nil
+		}
+		repos, target = []string{repo}, repo
+	}
+
+	switch req.Command {
+	case control.Status:
+		var resp control.Response
+		for _, repo := range repos {
+			status, _ := m.store.Status(repo)
+			status.Path = repo
+			r := server.NewRepo(status)
+			if t, ok := m.tasks[repo]; ok && t.kind == "fetch" {
+				r.Fetching = true
+			}
+			resp.Repos = append(resp.Repos, r)
+		}
+		return resp, nil
+	case control.Refresh:
+		m.store.RefreshAll(repos)
+		message := fmt.Sprintf("Refreshed %s", countRepos(len(repos)))
+		m.activity.add(target, "%s on request", message)
+		m.refreshActivityView()
+		return control.Response{Message: message}, nil
+	case control.Fetch:
+		before := m.runningTasks("fetch")
+		cmd := m.startFetch(repos)
+		started := m.runningTasks("fetch") - before
+		return control.Response{Message: fmt.Sprintf("Fetching %s", countRepos(started))}, cmd
+	}
+	return control.Errorf("unknown command %q", req.Command), nil
+}
+
+func countRepos(n int) string {
+	if n == 1 {
+		return "1 repository"
+	}
+	return fmt.Sprintf("%d repositories", n)
+}
@@ -0,0 +1,133 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// commitTypes are the Conventional Commits types offered by the composer,
+// in the order Tab cycles through them.
+var commitTypes = []string{"feat", "fix", "chore", "docs", "refactor", "test", "perf", "build", "ci", "style", "revert"}
+
+const (
+	// subjectTarget is the header length that reads well in one-line logs.
+	subjectTarget = 50
+	// subjectLimit is the longest header that isn't truncated by most tools.
+	subjectLimit = 72
+	// scopeHistory is how many commits are searched for scopes to complete.
+	scopeHistory = 200
+)
+
+var (
+	commitTypePattern  = regexp.MustCompile(`^[a-z]+!?$`)
+	commitScopePattern = regexp.MustCompile(`^[^()\s]*$`)
+)
+
+// openCommitComposer builds a Conventional Commits header for repo one part
+// at a time (type, scope, subject), then opens the commit prompt with it so
+// a body can be added.
+func (m *model) openCommitComposer(repo string) {
+	m.openPrompt(promptOptions{
+		title:       "Commit type (append ! for a breaking change)",
+		placeholder: "feat",
+		historyKey:  "commit-type",
+		complete:    cycleCompletion(commitTypes),
+		onSubmit: func(m *model, value string) (tea.Cmd, error) {
+			typ := strings.TrimSpace(value)
+			if !commitTypePattern.MatchString(typ) {
+				return nil, errors.New("type must be a lower-case word, e.g. feat or fix")
+			}
+			m.openScopePrompt(repo, typ)
+			return nil, nil
+		},
+	})
+}
+
+func (m *model) openScopePrompt(repo, typ string) {
+	scopes, _ := gitstatus.CommitScopes(repo, scopeHistory)
+	m.openPrompt(promptOptions{
+		title:       "Scope (optional)",
+		placeholder: strings.Join(scopes[:min(len(scopes), 3)], ", "),
+		historyKey:  "commit-scope",
+		complete:    cycleCompletion(scopes),
+		onSubmit: func(m *model, value string) (tea.Cmd, error) {
+			scope := strings.TrimSpace(value)
+			if !commitScopePattern.MatchString(scope) {
+				return nil, errors.New("scope can't contain spaces or parentheses")
+			}
+			prefix := typ
+			if scope != "" {
+				// A breaking change's ! goes after the scope
+				prefix = fmt.Sprintf("%s(%s)", strings.TrimSuffix(typ, "!"), scope)
+				if strings.HasSuffix(typ, "!") {
+					prefix += "!"
+				}
+			}
+			m.openSubjectPrompt(repo, prefix+": ")
+			return nil, nil
+		},
+	})
+}
+
+func (m *model) openSubjectPrompt(repo, prefix string) {
+	m.openPrompt(promptOptions{
+		title:       "Subject: " + prefix,
+		placeholder: "describe the change in the imperative",
+		status: func(value string) string {
+			return subjectGauge(len([]rune(prefix + value)))
+		},
+		onSubmit: func(m *model, value string) (tea.Cmd, error) {
+			subject := strings.TrimSpace(value)
+			if subject == "" {
+				return nil, errors.New("subject is empty")
+			}
+			m.openCommitPrompt(repo, prefix+subject+"\n\n")
+			return nil, nil
+		},
+	})
+}
+
+// subjectGauge shows how long a commit header of length n is compared to
+// the usual limits.
+func subjectGauge(n int) string {
+	color := "#737994" // Overlay0
+	switch {
+	case n > subjectLimit:
+		color = "#e78284" // Red
+	case n > subjectTarget:
+		color = "#e5c890" // Yellow
+	}
+	width := 20
+	filled := min(n*width/subjectLimit, width)
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(color)).
+		Render(fmt.Sprintf("%s %d/%d", bar, n, subjectTarget))
+}
+
+// cycleCompletion returns a Tab completion function over options: an
+// unfinished value completes to the first option it starts, and a finished
+// one moves on to the next option.
+func cycleCompletion(options []string) func(string) string {
+	return func(value string) string {
+		if len(options) == 0 {
+			return value
+		}
+		if i := slices.Index(options, value); i >= 0 {
+			return options[(i+1)%len(options)]
+		}
+		for _, option := range options {
+			if strings.HasPrefix(option, value) {
+				return option
+			}
+		}
+		return value
+	}
+}
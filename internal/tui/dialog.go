@@ -0,0 +1,129 @@
+package tui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// confirmDialog is a modal yes/no prompt that guards a destructive action.
+// While a dialog is open it receives all key events.
+type confirmDialog struct {
+	title     string
+	message   string
+	yes       bool // true when the "Yes" button is selected; defaults to No
+	onConfirm func(m *model) tea.Cmd
+}
+
+// confirm opens a dialog that runs onConfirm if the user accepts.
+func (m *model) confirm(title, message string, onConfirm func(m *model) tea.Cmd) {
+	m.dialog = &confirmDialog{title: title, message: message, onConfirm: onConfirm}
+}
+
+// handleDialogKey processes a key event while the confirmation dialog is open.
+func (m *model) handleDialogKey(msg tea.KeyMsg) tea.Cmd {
+	d := m.dialog
+	switch msg.String() {
+	case "y", "Y":
+		m.dialog = nil
+		return d.onConfirm(m)
+	case "n", "N", "esc", "q":
+		m.dialog = nil
+	case "left", "right", "h", "l", "tab", "shift+tab":
+		d.yes = !d.yes
+	case "enter":
+		m.dialog = nil
+		if d.yes {
+			return d.onConfirm(m)
+		}
+	case "ctrl+c":
+		return tea.Quit
+	}
+	return nil
+}
+
+// modalStyle is the frame shared by all modal popups.
+func modalStyle() lipgloss.Style {
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#ca9ee6")). // Mauve
+		Padding(1, 2)
+}
+
+// view renders the dialog box; width is the maximum width available.
+func (d *confirmDialog) view(width int) string {
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#e78284")).Bold(true) // Red
+	buttonStyle := lipgloss.NewStyle().Padding(0, 2).Foreground(lipgloss.Color("#c6d0f5"))
+	activeStyle := buttonStyle.
+		Foreground(lipgloss.Color("#303446")). // Base
+		Background(lipgloss.Color("#ca9ee6"))  // Mauve
+
+	yes, no := buttonStyle.Render("Yes"), activeStyle.Render("No")
+	if d.yes {
+		yes, no = activeStyle.Render("Yes"), buttonStyle.Render("No")
+	}
+
+	maxWidth := min(60, width-8)
+	body := lipgloss.NewStyle().Width(maxWidth).Render(d.message)
+	buttons := lipgloss.JoinHorizontal(lipgloss.Top, yes, "  ", no)
+	hint := lipgloss.NewStyle().Foreground(lipgloss.Color("#737994")).Render("y/n or ←/→ and Enter")
+
+	return modalStyle().Render(lipgloss.JoinVertical(lipgloss.Left,
+		titleStyle.Render(d.title),
+		"",
+		body,
+		"",
+		buttons,
+		"",
+		hint,
+	))
+}
+
+// placeCentered draws fg centered on top of bg.
+func (m *model) placeCentered(fg, bg string) string {
+	x := (m.width - lipgloss.Width(fg)) / 2
+	y := (m.height - lipgloss.Height(fg)) / 2
+	return placeOverlay(max(x, 0), max(y, 0), fg, bg)
+}
+
+// infoPopup is a read-only modal that shows details until dismissed with
+// Esc, Enter, or q.
+type infoPopup struct {
+	title string
+	body  string
+}
+
+// showInfo opens a read-only popup.
+func (m *model) showInfo(title, body string) {
+	m.popup = &infoPopup{title: title, body: body}
+}
+
+// handlePopupKey processes a key event while an info popup is open.
+func (m *model) handlePopupKey(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc", "enter", "q", "e":
+		m.popup = nil
+	case "ctrl+c":
+		return tea.Quit
+	}
+	return nil
+}
+
+// view renders the popup within the given terminal dimensions, truncating
+// the body if it is too tall to fit.
+func (p *infoPopup) view(width, height int) string {
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#ca9ee6")).Bold(true) // Mauve
+	hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#737994"))             // Overlay0
+
+	body := lipgloss.NewStyle().
+		Width(min(90, max(width-10, 20))).
+		MaxHeight(max(height-12, 3)).
+		Render(p.body)
+
+	return modalStyle().Render(lipgloss.JoinVertical(lipgloss.Left,
+		titleStyle.Render(p.title),
+		"",
+		body,
+		"",
+		hintStyle.Render("Esc to close"),
+	))
+}
@@ -0,0 +1,93 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// setDiffContent replaces the diff pane content and scrolls to the top.
+func (m *model) setDiffContent(content string) {
+	m.currentDiff = content
+	m.renderDiff()
+	m.diffView.GotoTop()
+	m.diffView.SetXOffset(0)
+}
+
+// renderDiff lays out the diff pane's content, wrapping long lines if
+// wrap_diff is set, and indexes hunk headers for [ / ] navigation. Blame
+// isn't wrapped, as its cursor moves a line at a time.
+func (m *model) renderDiff() {
+	lines := strings.Split(m.currentDiff, "\n")
+	if m.config.WrapDiff && (m.blame == nil || m.blame.showingCommit) {
+		lines = wrapLines(lines, m.diffView.Width, m.iconStyle())
+	}
+	m.hunkLines = m.hunkLines[:0]
+	for i, line := range lines {
+		if strings.HasPrefix(ansi.Strip(line), "@@") {
+			m.hunkLines = append(m.hunkLines, i)
+		}
+	}
+	m.diffView.SetContent(strings.Join(lines, "\n"))
+}
+
+// wrapLines wraps lines wider than width at spaces where it can, marking
+// each continuation with iconStyle's wrapMark.
+func wrapLines(lines []string, width int, iconStyle string) []string {
+	if width < 10 {
+		return lines
+	}
+	marker := lipgloss.NewStyle().Foreground(lipgloss.Color("#737994")).Render(wrapMark(iconStyle)) // Overlay0
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if ansi.StringWidth(line) <= width {
+			out = append(out, line)
+			continue
+		}
+		for i, part := range strings.Split(ansi.Wrap(line, width-2, ""), "\n") {
+			if i > 0 {
+				part = marker + part
+			}
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// toggleWrapDiff switches the diff pane between wrapping long lines and
+// cutting them off, with ←/→ to scroll sideways, and remembers the choice in
+// the config.
+func (m *model) toggleWrapDiff() tea.Cmd {
+	m.config.WrapDiff = !m.config.WrapDiff
+	top := m.diffView.YOffset
+	m.renderDiff()
+	m.diffView.SetXOffset(0)
+	m.diffView.SetYOffset(top)
+	if err := m.config.Save(); err != nil {
+		return m.actionResult("", "", "Failed to save wrap_diff", err)
+	}
+	return nil
+}
+
+// jumpHunk scrolls the diff pane to the next (delta 1) or previous (delta -1)
+// hunk header relative to the top of the view.
+func (m *model) jumpHunk(delta int) {
+	top := m.diffView.YOffset
+	if delta > 0 {
+		for _, line := range m.hunkLines {
+			if line > top {
+				m.diffView.SetYOffset(line)
+				return
+			}
+		}
+		return
+	}
+	for i := len(m.hunkLines) - 1; i >= 0; i-- {
+		if m.hunkLines[i] < top {
+			m.diffView.SetYOffset(m.hunkLines[i])
+			return
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// withChanges attaches each file's mode and size change to its item.
+func withChanges(items []list.Item, changes map[string]gitstatus.FileChange) []list.Item {
+	for i, item := range items {
+		if fi, ok := item.(fileItem); ok {
+			if change, ok := changes[strings.TrimSuffix(fi.gitFile.Path, "/")]; ok {
+				fi.change = &change
+				items[i] = fi
+			}
+		}
+	}
+	return items
+}
+
+// describeChange summarizes a file's mode and size change, e.g.
+// "+1.2 KB (14.0 KB) • mode 100644 → 100755", or "" if there is nothing to
+// add to its status.
+func describeChange(c *gitstatus.FileChange) string {
+	if c == nil {
+		return ""
+	}
+	var parts []string
+	oldLink, newLink := c.OldMode == gitstatus.ModeSymlink, c.NewMode == gitstatus.ModeSymlink
+	switch {
+	case oldLink && newLink:
+		parts = append(parts, "symlink")
+	case newLink:
+		if c.OldMode != "" {
+			parts = append(parts, "replaced by a symlink")
+		} else {
+			parts = append(parts, "symlink")
+		}
+	case oldLink:
+		if c.NewMode != "" {
+			parts = append(parts, "symlink replaced by a file")
+		} else {
+			parts = append(parts, "symlink")
+		}
+	}
+
+	// The size of a symlink is the length of its target, which says little
+	if !oldLink && !newLink {
+		switch {
+		case c.OldMode == "" && c.NewSize >= 0:
+			parts = append(parts, formatSize(c.NewSize))
+		case c.NewMode == "" && c.OldSize >= 0:
+			parts = append(parts, "-"+formatSize(c.OldSize))
+		case c.OldSize >= 0 && c.NewSize >= 0 && c.OldSize != c.NewSize:
+			delta := c.NewSize - c.OldSize
+			sign := "+"
+			if delta < 0 {
+				sign, delta = "-", -delta
+			}
+			parts = append(parts, fmt.Sprintf("%s%s (%s)", sign, formatSize(delta), formatSize(c.NewSize)))
+		}
+	}
+	if c.ModeChanged() && !(oldLink || newLink) {
+		parts = append(parts, fmt.Sprintf("mode %s → %s", c.OldMode, c.NewMode))
+	}
+	return strings.Join(parts, " • ")
+}
+
+// formatSize formats a size in bytes, e.g. "512 B" or "1.2 KB".
+func formatSize(n int64) string {
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	size := float64(n)
+	for _, unit := range []string{"KB", "MB", "GB"} {
+		size /= 1024
+		if size < 1024 || unit == "GB" {
+			return fmt.Sprintf("%.1f %s", size, unit)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,311 @@
+package tui
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// dirItem is a directory in the tree view of the changed files pane, or a
+// group of files in the flat list.
+type dirItem struct {
+	path      string // relative to the repository
+	name      string // chains of directories holding nothing else are joined, e.g. "pkg/api"
+	depth     int
+	files     []gitstatus.File // changed files anywhere below the directory
+	collapsed bool
+	iconStyle string
+}
+
+func (i dirItem) FilterValue() string { return i.path }
+
+func (i dirItem) Title() string {
+	return fmt.Sprintf("%s%s %s/ (%d)", strings.Repeat("  ", i.depth), dirMark(i.collapsed, i.iconStyle), i.name, len(i.files))
+}
+
+func (i dirItem) Description() string { return summarizeFiles(i.files) }
+
+// summarizeFiles counts files by status, e.g. "3 Modified, 1 Added".
+func summarizeFiles(files []gitstatus.File) string {
+	var order []string
+	counts := map[string]int{}
+	for _, f := range files {
+		desc := getStatusDescription(f.Status)
+		if counts[desc] == 0 {
+			order = append(order, desc)
+		}
+		counts[desc]++
+	}
+	parts := make([]string, len(order))
+	for i, desc := range order {
+		parts[i] = fmt.Sprintf("%d %s", counts[desc], desc)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// fileTreeNode is a directory while building the tree view.
+type fileTreeNode struct {
+	dirs  map[string]*fileTreeNode
+	files []gitstatus.File // directly in the directory
+	all   []gitstatus.File // anywhere below it
+}
+
+// fileTreeItems lists files as a directory tree: each directory's
+// subdirectories, then its files. Directories for which collapsed returns
+// true are listed without their contents. Directories are marked in
+// iconStyle.
+func fileTreeItems(files []gitstatus.File, collapsed func(dir string) bool, iconStyle string) []list.Item {
+	root := &fileTreeNode{dirs: map[string]*fileTreeNode{}}
+	for _, f := range files {
+		// Untracked directories are listed with a trailing slash
+		parts := strings.Split(strings.TrimSuffix(f.Path, "/"), "/")
+		node := root
+		for _, part := range parts[:len(parts)-1] {
+			child := node.dirs[part]
+			if child == nil {
+				child = &fileTreeNode{dirs: map[string]*fileTreeNode{}}
+				node.dirs[part] = child
+			}
+			child.all = append(child.all, f)
+			node = child
+		}
+		node.files = append(node.files, f)
+	}
+
+	var items []list.Item
+	var walk func(node *fileTreeNode, dir string, depth int)
+	walk = func(node *fileTreeNode, dir string, depth int) {
+		names := make([]string, 0, len(node.dirs))
+		for name := range node.dirs {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			child := node.dirs[name]
+			for len(child.dirs) == 1 && len(child.files) == 0 {
+				for only, grandchild := range child.dirs {
+					name, child = name+"/"+only, grandchild
+				}
+			}
+			item := dirItem{path: path.Join(dir, name), name: name, depth: depth, files: child.all, iconStyle: iconStyle}
+			item.collapsed = collapsed(item.path)
+			items = append(items, item)
+			if !item.collapsed {
+				walk(child, item.path, depth+1)
+			}
+		}
+		slices.SortFunc(node.files, func(a, b gitstatus.File) int { return strings.Compare(a.Path, b.Path) })
+		for _, f := range node.files {
+			items = append(items, fileItem{gitFile: f, depth: depth, tree: true})
+		}
+	}
+	walk(root, "", 0)
+	return items
+}
+
+// groupThreshold is how many changed files a directory needs to be grouped
+// into one entry in the flat list.
+const groupThreshold = 10
+
+// groupedFileItems lists files in the order given, except that directories
+// with many changed files, such as vendor/ after a dependency update, are
+// grouped into one entry in place of their first file so they don't bury
+// the other changes. Groups for which expanded returns true are followed
+// by their files. Groups are marked in iconStyle.
+func groupedFileItems(files []gitstatus.File, expanded func(dir string) bool, iconStyle string) []list.Item {
+	groups := fileGroups(files, "")
+	inGroup := func(f gitstatus.File) string {
+		for _, g := range groups {
+			if strings.HasPrefix(f.Path, g+"/") {
+				return g
+			}
+		}
+		return ""
+	}
+
+	items := make([]list.Item, 0, len(files))
+	listed := map[string]bool{}
+	for _, f := range files {
+		g := inGroup(f)
+		if g == "" {
+			items = append(items, fileItem{gitFile: f})
+			continue
+		}
+		if listed[g] {
+			continue
+		}
+		listed[g] = true
+		var members []gitstatus.File
+		for _, other := range files {
+			if strings.HasPrefix(other.Path, g+"/") {
+				members = append(members, other)
+			}
+		}
+		item := dirItem{path: g, name: g, files: members, collapsed: !expanded(g), iconStyle: iconStyle}
+		items = append(items, item)
+		if !item.collapsed {
+			for _, member := range members {
+				items = append(items, fileItem{gitFile: member, depth: 1})
+			}
+		}
+	}
+	return items
+}
+
+// fileGroups returns the shallowest directories below prefix holding at
+// least groupThreshold of the files. A directory holding all of them isn't a
+// group, as grouping it would hide everything; its subdirectories are
+// considered instead.
+func fileGroups(files []gitstatus.File, prefix string) []string {
+	byDir := map[string][]gitstatus.File{}
+	for _, f := range files {
+		rest := strings.TrimPrefix(f.Path, prefix)
+		// Untracked directories end in a slash but aren't inside one
+		if i := strings.Index(rest, "/"); i >= 0 && i < len(rest)-1 {
+			dir := prefix + rest[:i]
+			byDir[dir] = append(byDir[dir], f)
+		}
+	}
+
+	var groups []string
+	for dir, inDir := range byDir {
+		switch {
+		case len(inDir) < groupThreshold:
+		case len(inDir) == len(files):
+			groups = append(groups, fileGroups(inDir, dir+"/")...)
+		default:
+			groups = append(groups, dir)
+		}
+	}
+	slices.Sort(groups)
+	return groups
+}
+
+// itemPath returns the path of a changed files pane item, for keeping it
+// selected when the list is rebuilt.
+func itemPath(item list.Item) string {
+	switch item := item.(type) {
+	case fileItem:
+		return item.gitFile.Path
+	case dirItem:
+		return item.path
+	}
+	return ""
+}
+
+// toggleFileTree switches the changed files pane between a flat list and a
+// directory tree, and remembers the choice in the config.
+func (m *model) toggleFileTree() tea.Cmd {
+	selected := itemPath(m.fileList.SelectedItem())
+	m.config.FileTree = !m.config.FileTree
+	m.setFileDelegate()
+	m.updateFileList()
+	m.selectFilePath(selected)
+	if err := m.config.Save(); err != nil {
+		return m.actionResult("", "", "Failed to save file_tree", err)
+	}
+	return nil
+}
+
+// setFileDelegate lists changed files compactly, one line each, in the
+// tree view.
+func (m *model) setFileDelegate() {
+	delegate := newStyledDelegate()
+	if m.config.FileTree {
+		delegate.ShowDescription = false
+		delegate.SetSpacing(0)
+	}
+	m.fileList.SetDelegate(delegate)
+}
+
+// selectFilePath selects the changed files pane item with path p, or the
+// directory it is collapsed into, falling back to the first item.
+func (m *model) selectFilePath(p string) {
+	index := 0
+	for i, item := range m.fileList.Items() {
+		ip := itemPath(item)
+		if ip == p {
+			index = i
+			break
+		}
+		if _, ok := item.(dirItem); ok && strings.HasPrefix(p, ip+"/") {
+			index = i
+		}
+	}
+	if len(m.fileList.Items()) > 0 {
+		m.selectFile(index)
+	}
+}
+
+// handleDirKey expands and collapses directories in the tree view and
+// groups in the flat list. It reports false for keys it doesn't handle.
+func (m *model) handleDirKey(key string) bool {
+	item := m.fileList.SelectedItem()
+	dir, isDir := item.(dirItem)
+	switch key {
+	case " ":
+		if !isDir {
+			return false
+		}
+		m.setCollapsed(dir.path, !dir.collapsed)
+	case "right":
+		if !isDir || !dir.collapsed {
+			return isDir
+		}
+		m.setCollapsed(dir.path, false)
+	case "left":
+		if isDir && !dir.collapsed {
+			m.setCollapsed(dir.path, true)
+			return true
+		}
+		// Go to the enclosing directory
+		p := itemPath(item)
+		for i := m.fileList.Index() - 1; i >= 0; i-- {
+			if parent, ok := m.fileList.Items()[i].(dirItem); ok && strings.HasPrefix(p, parent.path+"/") {
+				m.closeDiffModes()
+				m.selectFile(i)
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+	return true
+}
+
+// setCollapsed collapses or expands dir in the selected repo's tree view
+// or flat list, keeping it selected. Directories in the tree start out
+// expanded, and groups in the list collapsed.
+func (m *model) setCollapsed(dir string, collapsed bool) {
+	repo := m.selectedRepoPath()
+	state, set := m.collapsedDirs, collapsed
+	if !m.config.FileTree {
+		state, set = m.expandedGroups, !collapsed
+	}
+	if state[repo] == nil {
+		state[repo] = map[string]bool{}
+	}
+	if set {
+		state[repo][dir] = true
+	} else {
+		delete(state[repo], dir)
+	}
+	m.updateFileList()
+	m.selectFilePath(dir)
+}
+
+// dirSummary is shown in the diff pane for a directory in the tree view.
+func dirSummary(dir dirItem) string {
+	lines := []string{fmt.Sprintf("%s/: %d changed files (%s)", dir.path, len(dir.files), summarizeFiles(dir.files)), ""}
+	for _, f := range dir.files {
+		lines = append(lines, fmt.Sprintf("%-2s %s", f.Status, f.Path))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,207 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"runtime"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/cwsaylor/gitmoni/internal/crash"
+	"github.com/cwsaylor/gitmoni/internal/notify"
+	"github.com/cwsaylor/gitmoni/pkg/forge"
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// forgeState is what a repo's hosting service reports about its current
+// branch, and the repo's open issue and pull request counts as of countsAt.
+type forgeState struct {
+	pr       *forge.PullRequest
+	ci       forge.CI
+	counts   forge.Counts
+	countsAt time.Time
+}
+
+// forgeMsg carries the state of a repo's hosted origin. state is nil if the
+// repo isn't hosted on a configured forge.
+type forgeMsg struct {
+	repo  string
+	state *forgeState
+	web   string // the repo's web page, if its origin is hosted
+	err   error
+}
+
+// queryForge looks up repo's pull request and CI runs in the background.
+// Issue and pull request counts are only queried if enabled and older than
+// the configured TTL, as they change slowly and cost extra API calls. It
+// returns nil if no forge is configured, the repo has no branch checked out,
+// or a query for repo is in flight.
+func (m *model) queryForge(repo string) tea.Cmd {
+	status, ok := m.store.Status(repo)
+	if m.forges == nil || !ok || status.Branch == "" || m.forgeQueries[repo] {
+		return nil
+	}
+	m.forgeQueries[repo] = true
+	m.forgeBranch[repo] = status.Branch
+
+	prev := m.forgeStates[repo]
+	ttl := time.Duration(m.config.CountsTTLMinutes) * time.Minute
+	queryCounts := m.config.ShowCounts && time.Since(prev.countsAt) >= ttl
+
+	ctx, workers, forges, branch, remoteName := m.ctx, m.workers, m.forges, status.Branch, m.config.Remote(repo)
+	workers.Add(1)
+	return func() tea.Msg {
+		defer workers.Done()
+		defer crash.Capture()
+		msg := forgeMsg{repo: repo}
+		url, err := gitstatus.RemoteURL(repo, remoteName)
+		if err != nil {
+			return msg
+		}
+		remote, ok := forge.ParseRemote(url)
+		if !ok {
+			return msg
+		}
+		msg.web = forges.WebURL(remote, branch)
+		provider := forges.Provider(remote)
+		if provider == nil {
+			return msg
+		}
+		state := forgeState{counts: prev.counts, countsAt: prev.countsAt}
+		var prErr, ciErr, countsErr error
+		state.pr, prErr = provider.PullRequest(ctx, remote, branch)
+		state.ci, ciErr = provider.CI(ctx, remote, branch)
+		if queryCounts {
+			if state.counts, countsErr = provider.Counts(ctx, remote); countsErr == nil {
+				state.countsAt = time.Now()
+			}
+		}
+		msg.state, msg.err = &state, errors.Join(prErr, ciErr, countsErr)
+		return msg
+	}
+}
+
+// applyForge records the result of a forge query, and reports CI that has
+// started failing.
+func (m *model) applyForge(msg forgeMsg) tea.Cmd {
+	delete(m.forgeQueries, msg.repo)
+	m.webURLs[msg.repo] = msg.web
+	var cmd tea.Cmd
+	switch {
+	case msg.err != nil:
+		// Keep the last known state; the next fetch will retry
+		slog.Warn("forge query failed", "repo", msg.repo, "err", msg.err)
+		return nil
+	case msg.state == nil:
+		delete(m.forgeStates, msg.repo)
+	default:
+		prev, seen := m.forgeStates[msg.repo]
+		if seen && prev.ci.State != "failure" && msg.state.ci.State == "failure" {
+			cmd = m.sendNotifications([]notify.Change{{
+				Kind:    notify.CIFailed,
+				Repo:    msg.repo,
+				Message: fmt.Sprintf("%s failed on %s %s", msg.state.ci.Name, m.forgeBranch[msg.repo], msg.state.ci.URL),
+			}})
+		}
+		m.forgeStates[msg.repo] = *msg.state
+	}
+	m.updateRepoList()
+	return cmd
+}
+
+// forgeBadges describes a repo's hosted state: CI for the current branch,
+// the branch's pull request with its review and merge state, then the open
+// pull request and issue counts.
+func forgeBadges(state forgeState, iconStyle string) []badge {
+	var badges []badge
+	switch state.ci.State {
+	case "success":
+		badges = append(badges, badge{text: "CI " + ciMark(state.ci.State, iconStyle), level: "ok"})
+	case "failure":
+		badges = append(badges, badge{text: "CI " + ciMark(state.ci.State, iconStyle), level: "error"})
+	case "running":
+		badges = append(badges, badge{text: "CI " + ciMark(state.ci.State, iconStyle), level: "warn"})
+	}
+	if pr := state.pr; pr != nil {
+		b := badge{text: pr.Ref, level: "info"}
+		switch {
+		case pr.Draft:
+			b.text += " draft"
+		case pr.Merge == "conflicts":
+			b.text, b.level = b.text+" conflicts", "error"
+		case pr.Review == "changes requested":
+			b.text, b.level = b.text+" changes requested", "error"
+		case pr.Review == "review required":
+			b.text, b.level = b.text+" awaiting review", "warn"
+		case pr.Review == "approved":
+			b.text, b.level = b.text+" approved", "ok"
+		}
+		badges = append(badges, b)
+	}
+	if n := state.counts.PullRequests; n > 0 {
+		badges = append(badges, badge{text: fmt.Sprintf("%d open %ss", n, state.counts.Kind), level: "info"})
+	}
+	if n := state.counts.Issues; n > 0 {
+		badges = append(badges, badge{text: fmt.Sprintf("%d issues", n), level: "info"})
+	}
+	return badges
+}
+
+// openCIRun opens repo's failing (or latest) CI run in the browser, or the
+// repo's web page if no CI runs are known.
+func (m *model) openCIRun(repo string) tea.Cmd {
+	ci := m.forgeStates[repo].ci
+	if ci.URL == "" {
+		return m.openWebPage(repo)
+	}
+	err := openBrowser(ci.URL)
+	return m.actionResult(repo, fmt.Sprintf("Opened %s run in the browser", ci.Name), "Opening browser failed", err)
+}
+
+// openWebPage opens the web page of repo's remote, origin unless its
+// repo_settings say otherwise, in the browser.
+func (m *model) openWebPage(repo string) tea.Cmd {
+	name := m.config.Remote(repo)
+	url, err := gitstatus.RemoteURL(repo, name)
+	if err != nil {
+		return m.notify("No "+name+" remote to open", true)
+	}
+	remote, ok := forge.ParseRemote(url)
+	if !ok {
+		return m.notify("Can't tell the web page of "+url, true)
+	}
+	status, _ := m.store.Status(repo)
+	page := m.forges.WebURL(remote, status.Branch)
+	err = openBrowser(page)
+	return m.actionResult(repo, "Opened "+page+" in the browser", "Opening browser failed", err)
+}
+
+// repoLink returns the web page repo's name links to, or "" if hyperlinks
+// are off or its origin isn't hosted.
+func (m *model) repoLink(repo string) string {
+	if !m.links {
+		return ""
+	}
+	return m.webURLs[repo]
+}
+
+// openBrowser opens url with the platform's default handler.
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
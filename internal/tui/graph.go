@@ -0,0 +1,41 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// graphLimit is how many commits the graph view renders.
+const graphLimit = 300
+
+// toggleGraph switches the diff pane between the current diff and the
+// selected repo's commit graph. The graph stays open while moving between
+// repos, and closes when a file or side pane item is selected.
+func (m *model) toggleGraph() {
+	if m.graph {
+		m.graph = false
+		m.syncLowerPane(true)
+		return
+	}
+	m.blame = nil
+	m.readme = false
+	m.graph = true
+	m.focused = focusDiff
+	m.updateGraph()
+}
+
+// updateGraph shows the selected repo's commit graph in the diff pane.
+func (m *model) updateGraph() {
+	repo := m.selectedRepoPath()
+	if repo == "" {
+		m.setDiffContent("")
+		return
+	}
+	graph, err := gitstatus.Graph(repo, graphLimit)
+	if err != nil {
+		m.setDiffContent(fmt.Sprintf("Error loading commit graph: %s", gitstatus.ErrorSummary(err)))
+		return
+	}
+	m.setDiffContent(graph)
+}
@@ -0,0 +1,67 @@
+package tui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/cwsaylor/gitmoni/internal/crash"
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// healthColors are the colours of health grades.
+var healthColors = map[string]string{
+	"A": "#a6d189", // Green
+	"B": "#8caaee", // Blue
+	"C": "#e5c890", // Yellow
+	"D": "#ef9f76", // Peach
+	"F": "#e78284", // Red
+}
+
+// branchesMsg delivers a repo's local branches and last activity, looked
+// up in the background.
+type branchesMsg struct {
+	repo       string
+	branches   []gitstatus.LocalBranch
+	lastActive time.Time
+	err        error
+}
+
+// queryBranches lists repo's local branches and finds its last activity in
+// the background, for its health score, stale flag, and watched branches.
+// It returns nil if none are used, the repo is reached over SSH, or a query
+// is in flight.
+func (m *model) queryBranches(repo string) tea.Cmd {
+	used := m.config.ShowHealth || m.config.SortOrder == "health" || m.config.StaleRepoDays > 0 ||
+		len(m.config.Settings(repo).WatchBranches) > 0
+	if !used || gitstatus.IsSSH(repo) || m.branchQueries[repo] {
+		return nil
+	}
+	m.branchQueries[repo] = true
+
+	workers := m.workers
+	workers.Add(1)
+	return func() tea.Msg {
+		defer workers.Done()
+		defer crash.Capture()
+		branches, err := gitstatus.LocalBranches(repo)
+		if err != nil {
+			return branchesMsg{repo: repo, err: err}
+		}
+		lastActive, err := gitstatus.LastActivity(repo)
+		return branchesMsg{repo: repo, branches: branches, lastActive: lastActive, err: err}
+	}
+}
+
+// idleDays returns how many days repo has gone without activity if that
+// makes it stale, or else 0.
+func (m *model) idleDays(repo string) int {
+	last, ok := m.lastActive[repo]
+	if !ok || last.IsZero() || m.config.StaleRepoDays <= 0 {
+		return 0
+	}
+	if days := int(time.Since(last).Hours() / 24); days >= m.config.StaleRepoDays {
+		return days
+	}
+	return 0
+}
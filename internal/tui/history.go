@@ -0,0 +1,81 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// historyColors are the colours of history event kinds.
+var historyColors = map[string]string{
+	gitstatus.HistoryDirty:       "#a6d189", // Green, as dirty repos
+	gitstatus.HistoryBehind:      "#ef9f76", // Peach, as behind repos
+	gitstatus.HistoryFetchFailed: "#e78284", // Red
+	gitstatus.HistoryPushed:      "#8caaee", // Blue
+}
+
+type historyItem struct {
+	event gitstatus.HistoryEvent
+}
+
+func (i historyItem) FilterValue() string { return i.event.Kind + " " + i.event.Detail }
+
+func (i historyItem) Title() string {
+	color, ok := historyColors[i.event.Kind]
+	if !ok {
+		color = "#737994" // Overlay0 for the way back to normal
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(color)).Render(gitstatus.DescribeHistoryKind(i.event.Kind))
+}
+
+func (i historyItem) Description() string {
+	desc := timeFormat.DateTime(i.event.Time)
+	if timeFormat.Relative() {
+		desc += " • " + formatAge(i.event.Time)
+	}
+	if i.event.Detail != "" {
+		desc += " • " + i.event.Detail
+	}
+	return desc
+}
+
+// historyPane lists the state changes recorded for a repository, newest
+// first.
+type historyPane struct{}
+
+func (historyPane) title() string { return "History" }
+
+func (historyPane) load(repo string) ([]list.Item, error) {
+	path, err := gitstatus.DefaultHistoryPath()
+	if err != nil {
+		return nil, err
+	}
+	events, err := gitstatus.ReadHistory(path)
+	if err != nil {
+		return nil, err
+	}
+	var items []list.Item
+	for _, e := range slices.Backward(events) {
+		if e.Repo == repo {
+			items = append(items, historyItem{event: e})
+		}
+	}
+	return items, nil
+}
+
+func (historyPane) detail(repo string, item list.Item) string {
+	e := item.(historyItem).event
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", gitstatus.DescribeHistoryKind(e.Kind))
+	fmt.Fprintf(&b, "Time:   %s %s\n", e.Time.Local().Format("Mon"), timeFormat.When(e.Time))
+	fmt.Fprintf(&b, "Repo:   %s\n", e.Repo)
+	if e.Detail != "" {
+		fmt.Fprintf(&b, "Detail: %s\n", e.Detail)
+	}
+	return b.String()
+}
@@ -0,0 +1,66 @@
+package tui
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// hyperlinksEnabled reports whether repo and file names are shown as OSC 8
+// hyperlinks, as the hyperlinks setting asks: "always", "never", or by
+// default if the terminal is known to support them.
+func hyperlinksEnabled(setting string) bool {
+	switch setting {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "ghostty", "Hyper":
+		return true
+	}
+	if os.Getenv("KITTY_WINDOW_ID") != "" || os.Getenv("WT_SESSION") != "" {
+		return true
+	}
+	// GNOME Terminal, Tilix, and other VTE terminals since 0.50
+	if v, err := strconv.Atoi(os.Getenv("VTE_VERSION")); err == nil && v >= 5000 {
+		return true
+	}
+	term := os.Getenv("TERM")
+	return term == "foot" || strings.HasPrefix(term, "alacritty")
+}
+
+// hyperlink makes text a link to target, or returns it as is if target is
+// empty.
+func hyperlink(target, text string) string {
+	if target == "" {
+		return text
+	}
+	return ansi.SetHyperlink(target) + text + ansi.ResetHyperlink()
+}
+
+// withLinks links the changed files in items to their file:// URLs, for
+// repositories on this host.
+func withLinks(items []list.Item, repo string) []list.Item {
+	if gitstatus.IsSSH(repo) {
+		return items
+	}
+	host, _ := os.Hostname()
+	root := gitstatus.RepoDir(repo)
+	for i, item := range items {
+		if fi, ok := item.(fileItem); ok {
+			path := filepath.Join(root, filepath.FromSlash(strings.TrimSuffix(fi.gitFile.Path, "/")))
+			fi.link = (&url.URL{Scheme: "file", Host: host, Path: filepath.ToSlash(path)}).String()
+			items[i] = fi
+		}
+	}
+	return items
+}
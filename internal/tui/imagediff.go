@@ -0,0 +1,324 @@
+package tui
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/gif" // decoders for image.DecodeConfig and image.Decode
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// imageExts are the extensions of files shown as images in the diff pane.
+var imageExts = []string{".png", ".jpg", ".jpeg", ".gif", ".webp", ".bmp", ".ico", ".svg"}
+
+// thumbnailRows caps the height of thumbnails in the diff pane, in rows.
+const thumbnailRows = 16
+
+// kittyDiacritics mark the row of a kitty image placeholder cell, from the
+// kitty graphics protocol's list; there is one for each of the first
+// thumbnailRows rows.
+var kittyDiacritics = []rune{
+	0x0305, 0x030D, 0x030E, 0x0310, 0x0312, 0x033D, 0x033E, 0x033F,
+	0x0346, 0x034A, 0x034B, 0x034C, 0x0350, 0x0351, 0x0352, 0x0357,
+}
+
+// kittyPlaceholder is the character whose cells a kitty image is drawn in.
+const kittyPlaceholder = '\U0010EEEE'
+
+// isImage reports whether the file at p is shown as an image.
+func isImage(p string) bool {
+	return slices.Contains(imageExts, strings.ToLower(path.Ext(p)))
+}
+
+// graphicsProtocol returns how images are drawn in this terminal, as the
+// image_previews setting asks: "kitty", "iterm2", or "" for not at all.
+// "auto" picks one if the terminal is known to support it.
+func graphicsProtocol(setting string) string {
+	switch setting {
+	case "kitty", "iterm2":
+		return setting
+	case "never":
+		return ""
+	}
+	switch {
+	case os.Getenv("KITTY_WINDOW_ID") != "", os.Getenv("TERM") == "xterm-kitty", os.Getenv("TERM_PROGRAM") == "ghostty":
+		return "kitty"
+	case os.Getenv("TERM_PROGRAM") == "iTerm.app", os.Getenv("LC_TERMINAL") == "iTerm2", os.Getenv("TERM_PROGRAM") == "WezTerm":
+		return "iterm2"
+	}
+	return ""
+}
+
+// imageVersion is one side of an image diff.
+type imageVersion struct {
+	label         string
+	data          []byte // nil if the file doesn't exist on this side
+	format        string
+	width, height int // 0 if the image can't be decoded, e.g. an SVG
+}
+
+func newImageVersion(label string, data []byte) imageVersion {
+	v := imageVersion{label: label, data: data}
+	if cfg, format, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		v.format, v.width, v.height = format, cfg.Width, cfg.Height
+	}
+	return v
+}
+
+// describe gives the version's size and dimensions, e.g.
+// "After: 14.0 KB, 640×480 PNG".
+func (v imageVersion) describe() string {
+	if v.data == nil {
+		return v.label + ": none"
+	}
+	desc := v.label + ": " + formatSize(int64(len(v.data)))
+	if v.width > 0 {
+		desc += fmt.Sprintf(", %d×%d %s", v.width, v.height, strings.ToUpper(v.format))
+	}
+	return desc
+}
+
+// imageVersions reads a changed image as committed at HEAD and as it is in
+// the working tree.
+func imageVersions(repo string, f gitstatus.File) []imageVersion {
+	p := strings.TrimSuffix(f.Path, "/")
+	var before, after []byte
+	if f.Status != "??" && !strings.Contains(f.Status, "A") {
+		before, _ = gitstatus.FileAtHead(repo, p)
+	}
+	if !strings.Contains(f.Status, "D") {
+		after, _ = os.ReadFile(filepath.Join(gitstatus.RepoDir(repo), filepath.FromSlash(p)))
+	}
+	return []imageVersion{newImageVersion("Before", before), newImageVersion("After", after)}
+}
+
+// imageDiff shows a changed image in the diff pane: the size and dimensions
+// of both versions and, in terminals that can draw images, thumbnails of
+// them side by side. Images of repositories on other hosts can't be read.
+func (m *model) imageDiff(repo string, f gitstatus.File) string {
+	if gitstatus.IsSSH(repo) {
+		return fmt.Sprintf("Image: %s\n\nPreviews aren't available for repositories on other hosts.", f.Path)
+	}
+	versions := imageVersions(repo, f)
+	lines := []string{"Image: " + f.Path, ""}
+	for _, v := range versions {
+		lines = append(lines, v.describe())
+	}
+	switch m.graphics {
+	case "kitty":
+		lines = append(lines, "")
+		lines = append(lines, m.kittyThumbnails(versions)...)
+	case "iterm2":
+		lines = append(lines, "", "Press v to view the images")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// kittyThumbnails lays out thumbnails of the decodable versions side by
+// side, as kitty image placeholders: the images are sent to the terminal
+// once, and drawn wherever their placeholder cells are, so they scroll with
+// the diff pane like text.
+func (m *model) kittyThumbnails(versions []imageVersion) []string {
+	width := min(max((m.diffView.Width-4)/2, 8), 40)
+	var labels []string
+	var columns [][]string
+	for _, v := range versions {
+		if v.width == 0 {
+			continue
+		}
+		// Cells are about twice as tall as they are wide
+		cols := width
+		rows := max(cols*v.height/v.width/2, 1)
+		if rows > thumbnailRows {
+			rows = thumbnailRows
+			cols = max(rows*2*v.width/v.height, 1)
+		}
+		id, err := m.sendKittyImage(v.data, cols, rows)
+		if err != nil {
+			continue
+		}
+		color := fmt.Sprintf("\x1b[38;2;%d;%d;%dm", id>>16&0xff, id>>8&0xff, id&0xff)
+		var column []string
+		for r := range rows {
+			cells := string(kittyPlaceholder) + string(kittyDiacritics[r]) + string(kittyDiacritics[0]) + strings.Repeat(string(kittyPlaceholder), cols-1)
+			column = append(column, color+cells+"\x1b[39m"+strings.Repeat(" ", width-cols))
+		}
+		labels = append(labels, padCells(v.label, width))
+		columns = append(columns, column)
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+
+	lines := []string{strings.Join(labels, "  ")}
+	for r := 0; ; r++ {
+		var parts []string
+		done := true
+		for _, column := range columns {
+			if r < len(column) {
+				parts = append(parts, column[r])
+				done = false
+			} else {
+				parts = append(parts, strings.Repeat(" ", width))
+			}
+		}
+		if done {
+			return lines
+		}
+		lines = append(lines, strings.Join(parts, "  "))
+	}
+}
+
+// sendKittyImage sends an image to the terminal for placeholders of cols by
+// rows cells, unless it was already sent at that size, and returns its id.
+func (m *model) sendKittyImage(data []byte, cols, rows int) (uint32, error) {
+	key := fmt.Sprintf("%x %d %d", sha256.Sum256(data), cols, rows)
+	if id, ok := m.imageIDs[key]; ok {
+		return id, nil
+	}
+	data, err := asPNG(data)
+	if err != nil {
+		return 0, err
+	}
+	id := uint32(len(m.imageIDs) + 1)
+	params := fmt.Sprintf("a=T,U=1,f=100,q=2,i=%d,c=%d,r=%d", id, cols, rows)
+	if err := writeKittyImage(m.terminal, params, data); err != nil {
+		return 0, err
+	}
+	m.imageIDs[key] = id
+	return id, nil
+}
+
+// writeKittyImage writes a kitty graphics command transmitting data, in
+// chunks as the protocol requires, with a single Write so it can't be split
+// by a frame written to the same terminal. In tmux each chunk is passed
+// through to the terminal, which needs tmux's allow-passthrough option.
+func writeKittyImage(w io.Writer, params string, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	const chunk = 4096
+	var buf bytes.Buffer
+	for i := 0; i < len(encoded); i += chunk {
+		end := min(i+chunk, len(encoded))
+		more := 0
+		if end < len(encoded) {
+			more = 1
+		}
+		keys := fmt.Sprintf("m=%d", more)
+		if i == 0 {
+			keys = params + "," + keys
+		}
+		seq := "\x1b_G" + keys + ";" + encoded[i:end] + "\x1b\\"
+		if os.Getenv("TMUX") != "" {
+			seq = ansi.TmuxPassthrough(seq)
+		}
+		buf.WriteString(seq)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// terminal is the TUI's output. Bubble Tea writes each frame with a single
+// Write from its renderer's goroutine, so writes are serialised to let
+// images be sent from Update without landing in the middle of a frame. It
+// is still the terminal's file, so Bubble Tea can size and restore it.
+type terminal struct {
+	*os.File
+	mu sync.Mutex
+}
+
+func (t *terminal) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.File.Write(p)
+}
+
+// asPNG converts an image to PNG, which is what terminals decode, unless it
+// already is one.
+func asPNG(data []byte) ([]byte, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if format == "png" {
+		return data, nil
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// imageViewedMsg is sent when the image viewer returns to the TUI.
+type imageViewedMsg struct {
+	err error
+}
+
+// imageViewer shows the versions of an image full size while the TUI is
+// suspended, for terminals whose images can't be placed among its text.
+type imageViewer struct {
+	versions []imageVersion
+	protocol string
+	stdin    io.Reader
+	stdout   io.Writer
+}
+
+func (v *imageViewer) SetStdin(r io.Reader)  { v.stdin = r }
+func (v *imageViewer) SetStdout(w io.Writer) { v.stdout = w }
+func (v *imageViewer) SetStderr(io.Writer)   {}
+
+// Run draws the images one below the other and waits for Enter.
+func (v *imageViewer) Run() error {
+	fmt.Fprint(v.stdout, "\x1b[2J\x1b[H")
+	for _, version := range v.versions {
+		fmt.Fprintln(v.stdout, version.describe())
+		if version.width == 0 {
+			fmt.Fprintln(v.stdout)
+			continue
+		}
+		switch v.protocol {
+		case "kitty":
+			data, err := asPNG(version.data)
+			if err != nil {
+				return err
+			}
+			if err := writeKittyImage(v.stdout, "a=T,f=100,q=2", data); err != nil {
+				return err
+			}
+		default:
+			fmt.Fprintf(v.stdout, "\x1b]1337;File=inline=1;size=%d;height=40%%;preserveAspectRatio=1:%s\a", len(version.data), base64.StdEncoding.EncodeToString(version.data))
+		}
+		fmt.Fprint(v.stdout, "\n\n")
+	}
+	fmt.Fprint(v.stdout, "Press Enter to return to gitmoni")
+	_, err := bufio.NewReader(v.stdin).ReadString('\n')
+	return err
+}
+
+// viewImage suspends the TUI to show the selected image's versions full
+// size.
+func (m *model) viewImage() tea.Cmd {
+	item, ok := m.fileList.SelectedItem().(fileItem)
+	repo := m.selectedRepoPath()
+	if !ok || m.graphics == "" || !isImage(item.gitFile.Path) || gitstatus.IsSSH(repo) {
+		return nil
+	}
+	viewer := &imageViewer{versions: imageVersions(repo, item.gitFile), protocol: m.graphics}
+	return tea.Exec(viewer, func(err error) tea.Msg { return imageViewedMsg{err: err} })
+}
@@ -0,0 +1,268 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxInputHistory is how many previous values are remembered per prompt kind.
+const maxInputHistory = 50
+
+// inputModal is a modal text prompt. Single-line prompts submit on Enter and
+// recall earlier values with ↑/↓; multi-line prompts submit on Ctrl+S.
+// Esc always cancels. While a prompt is open it receives all key events.
+type inputModal struct {
+	title      string
+	multiline  bool
+	input      textinput.Model
+	area       textarea.Model
+	historyKey string // groups prompts that share history, e.g. "branch"
+	historyIdx int    // position while browsing history; len(history) means the draft
+	draft      string // value typed before browsing history
+	err        string // validation error from the last submit attempt
+
+	// onSubmit is called with the entered value. Returning an error keeps the
+	// prompt open and shows the error beneath the input.
+	onSubmit func(m *model, value string) (tea.Cmd, error)
+
+	// complete, if set, is called on Tab and returns the completed value.
+	complete func(value string) string
+
+	// browse, if set, is called on Ctrl+O to pick the value some other way,
+	// e.g. from a directory browser.
+	browse func(m *model, value string)
+
+	// edit, if set, is called on Ctrl+E to write the value in an external
+	// editor instead, closing the prompt.
+	edit func(m *model, value string) tea.Cmd
+
+	// status, if set, returns a line shown beneath the input that describes
+	// the current value, e.g. its length.
+	status func(value string) string
+
+	toggles []*promptToggle
+}
+
+// promptToggle is an on/off option of a prompt, flipped with its key while
+// the prompt is open. The submit handler reads it when the prompt is
+// submitted.
+type promptToggle struct {
+	key   string // e.g. "alt+v"
+	label string
+	on    bool
+}
+
+// promptOptions configures a prompt opened with openPrompt.
+type promptOptions struct {
+	title       string
+	placeholder string
+	value       string // initial value
+	multiline   bool
+	historyKey  string
+	onSubmit    func(m *model, value string) (tea.Cmd, error)
+	complete    func(value string) string
+	browse      func(m *model, value string)
+	edit        func(m *model, value string) tea.Cmd
+	status      func(value string) string
+	toggles     []*promptToggle
+}
+
+// openPrompt shows a text prompt configured by opts.
+func (m *model) openPrompt(opts promptOptions) {
+	p := &inputModal{
+		title:      opts.title,
+		multiline:  opts.multiline,
+		historyKey: opts.historyKey,
+		onSubmit:   opts.onSubmit,
+		complete:   opts.complete,
+		browse:     opts.browse,
+		edit:       opts.edit,
+		status:     opts.status,
+		toggles:    opts.toggles,
+	}
+	width := min(70, max(m.width-12, 20))
+
+	if opts.multiline {
+		p.area = textarea.New()
+		p.area.Placeholder = opts.placeholder
+		p.area.ShowLineNumbers = false
+		p.area.SetWidth(width)
+		p.area.SetHeight(8)
+		p.area.SetValue(opts.value)
+		p.area.Cursor.SetMode(cursor.CursorStatic)
+		p.area.Focus()
+	} else {
+		p.input = textinput.New()
+		p.input.Placeholder = opts.placeholder
+		p.input.Width = width
+		p.input.SetValue(opts.value)
+		p.input.CursorEnd()
+		p.input.Cursor.SetMode(cursor.CursorStatic)
+		p.input.Focus()
+	}
+	p.historyIdx = len(m.inputHistory[p.historyKey])
+	m.prompt = p
+}
+
+// value returns the current contents of the prompt.
+func (p *inputModal) value() string {
+	if p.multiline {
+		return p.area.Value()
+	}
+	return p.input.Value()
+}
+
+func (p *inputModal) setValue(v string) {
+	if p.multiline {
+		p.area.SetValue(v)
+		return
+	}
+	p.input.SetValue(v)
+	p.input.CursorEnd()
+}
+
+// handlePromptKey processes a key event while a prompt is open.
+func (m *model) handlePromptKey(msg tea.KeyMsg) tea.Cmd {
+	p := m.prompt
+	history := m.inputHistory[p.historyKey]
+
+	for _, t := range p.toggles {
+		if msg.String() == t.key {
+			t.on = !t.on
+			return nil
+		}
+	}
+
+	switch msg.String() {
+	case "esc":
+		m.prompt = nil
+		return nil
+	case "ctrl+c":
+		return tea.Quit
+	case "ctrl+s":
+		return m.submitPrompt()
+	case "enter":
+		if !p.multiline {
+			return m.submitPrompt()
+		}
+	case "tab":
+		if p.complete != nil {
+			p.setValue(p.complete(p.value()))
+			return nil
+		}
+	case "ctrl+e":
+		if p.edit != nil {
+			m.prompt = nil
+			return p.edit(m, p.value())
+		}
+	case "ctrl+o":
+		if p.browse != nil {
+			p.browse(m, p.value())
+			return nil
+		}
+	case "up":
+		if !p.multiline && p.historyIdx > 0 {
+			if p.historyIdx == len(history) {
+				p.draft = p.value()
+			}
+			p.historyIdx--
+			p.setValue(history[p.historyIdx])
+			return nil
+		}
+	case "down":
+		if !p.multiline && p.historyIdx < len(history) {
+			p.historyIdx++
+			if p.historyIdx == len(history) {
+				p.setValue(p.draft)
+			} else {
+				p.setValue(history[p.historyIdx])
+			}
+			return nil
+		}
+	}
+
+	var cmd tea.Cmd
+	if p.multiline {
+		p.area, cmd = p.area.Update(msg)
+	} else {
+		p.input, cmd = p.input.Update(msg)
+	}
+	p.err = ""
+	return cmd
+}
+
+// submitPrompt runs the prompt's submit handler and closes it on success.
+func (m *model) submitPrompt() tea.Cmd {
+	p := m.prompt
+	value := p.value()
+	cmd, err := p.onSubmit(m, value)
+	if err != nil {
+		p.err = err.Error()
+		return cmd
+	}
+	if value != "" && p.historyKey != "" {
+		history := m.inputHistory[p.historyKey]
+		if len(history) == 0 || history[len(history)-1] != value {
+			history = append(history, value)
+		}
+		if len(history) > maxInputHistory {
+			history = history[len(history)-maxInputHistory:]
+		}
+		m.inputHistory[p.historyKey] = history
+	}
+	// The submit handler may have opened a follow-up prompt
+	if m.prompt == p {
+		m.prompt = nil
+	}
+	return cmd
+}
+
+// view renders the prompt box.
+func (p *inputModal) view() string {
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#ca9ee6")).Bold(true) // Mauve
+	hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#737994"))             // Overlay0
+	errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#e78284"))            // Red
+
+	var field, hint string
+	if p.multiline {
+		field = p.area.View()
+		hint = "Ctrl+S to submit • Esc to cancel"
+		if p.edit != nil {
+			hint = "Ctrl+S to submit • Ctrl+E to open your editor • Esc to cancel"
+		}
+	} else {
+		field = p.input.View()
+		hint = "Enter to submit • ↑/↓ history • Esc to cancel"
+		if p.complete != nil {
+			hint = "Enter to submit • Tab to complete • ↑/↓ history • Esc to cancel"
+		}
+		if p.browse != nil {
+			hint = "Enter to submit • Tab to complete • Ctrl+O to browse • ↑/↓ history • Esc to cancel"
+		}
+	}
+
+	parts := []string{titleStyle.Render(p.title), "", field}
+	if p.status != nil {
+		parts = append(parts, p.status(p.value()))
+	}
+	if len(p.toggles) > 0 {
+		parts = append(parts, "")
+		for _, t := range p.toggles {
+			box := "[ ]"
+			if t.on {
+				box = "[x]"
+			}
+			parts = append(parts, fmt.Sprintf("%s %s %s", box, t.label, hintStyle.Render("("+t.key+")")))
+		}
+	}
+	if p.err != "" {
+		parts = append(parts, "", errorStyle.Render(p.err))
+	}
+	parts = append(parts, "", hintStyle.Render(hint))
+	return modalStyle().Render(lipgloss.JoinVertical(lipgloss.Left, parts...))
+}
@@ -0,0 +1,39 @@
+package tui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/cwsaylor/gitmoni/internal/crash"
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// maintenanceMsg reports the repositories registered with git maintenance.
+type maintenanceMsg struct {
+	registered []string
+	err        error
+}
+
+// enableMaintenance registers repos with git maintenance in the background,
+// for git_maintenance.
+func (m *model) enableMaintenance(repos []string) tea.Cmd {
+	ctx, workers := m.ctx, m.workers
+	workers.Add(1)
+	return func() tea.Msg {
+		defer workers.Done()
+		defer crash.Capture()
+		registered, err := gitstatus.EnableMaintenance(ctx, repos)
+		return maintenanceMsg{registered: registered, err: err}
+	}
+}
+
+// finishMaintenance records which repositories were registered with git
+// maintenance, and any failures.
+func (m *model) finishMaintenance(msg maintenanceMsg) {
+	for _, repo := range msg.registered {
+		m.activity.add(repo, "Registered with git maintenance")
+	}
+	if msg.err != nil {
+		m.activity.addError("", "Registering with git maintenance failed: %s", msg.err)
+	}
+	m.refreshActivityView()
+}
@@ -0,0 +1,196 @@
+package tui
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Inline markdown, in the order it is replaced. Code spans come first so
+// emphasis markers inside them are left alone.
+var (
+	mdBadge  = regexp.MustCompile(`\[(!\[[^\]]*\]\([^)]*\))\]\([^)]*\)`) // a linked image
+	mdImage  = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLink   = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)[^)]*\)`)
+	mdCode   = regexp.MustCompile("`([^`]+)`")
+	mdBold   = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	mdItalic = regexp.MustCompile(`\*([^*\s][^*]*)\*|\b_([^_\s][^_]*)_\b`)
+	mdHTML   = regexp.MustCompile(`<[^>]+>`)
+	mdList   = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+`)
+)
+
+// renderMarkdown renders the basics of markdown for the terminal, wrapped to
+// width: headings, emphasis, code, links, lists, quotes, and rules. Anything
+// else, such as tables, is shown as written.
+func renderMarkdown(src string, width int) string {
+	heading1 := lipgloss.NewStyle().Foreground(lipgloss.Color("#ca9ee6")).Bold(true) // Mauve
+	heading2 := lipgloss.NewStyle().Foreground(lipgloss.Color("#8caaee")).Bold(true) // Blue
+	heading := lipgloss.NewStyle().Bold(true)
+	codeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#ef9f76")) // Peach
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#737994"))  // Overlay0
+	width = max(width, 20)
+
+	var out []string
+	var para []string // lines of the paragraph being collected
+	prefix := ""      // of the paragraph's first line; following lines are indented to match
+	flush := func() {
+		if len(para) == 0 {
+			return
+		}
+		text := renderInline(strings.Join(para, " "), codeStyle, dimStyle)
+		indent := strings.Repeat(" ", ansi.StringWidth(prefix))
+		wrapped := ansi.Wrap(text, width-len(indent), "")
+		for i, line := range strings.Split(wrapped, "\n") {
+			if i == 0 {
+				out = append(out, prefix+line)
+			} else {
+				out = append(out, indent+line)
+			}
+		}
+		para, prefix = nil, ""
+	}
+	blank := func() {
+		if len(out) > 0 && out[len(out)-1] != "" {
+			out = append(out, "")
+		}
+	}
+
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			// Fenced code is shown as is, without wrapping
+			flush()
+			fence := trimmed[:3]
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				out = append(out, "    "+codeStyle.Render(strings.ReplaceAll(lines[i], "\t", "    ")))
+			}
+			blank()
+		case trimmed == "":
+			flush()
+			blank()
+		case strings.HasPrefix(trimmed, "#"):
+			flush()
+			level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+			text := renderInline(strings.TrimSpace(strings.Trim(trimmed, "#")), codeStyle, dimStyle)
+			switch level {
+			case 1:
+				out = append(out, heading1.Render(text), heading1.Render(strings.Repeat("═", min(ansi.StringWidth(text), width))))
+			case 2:
+				out = append(out, heading2.Render(text), heading2.Render(strings.Repeat("─", min(ansi.StringWidth(text), width))))
+			default:
+				out = append(out, heading.Render(text))
+			}
+			blank()
+		case isRule(trimmed):
+			flush()
+			out = append(out, dimStyle.Render(strings.Repeat("─", width)))
+		case strings.HasPrefix(trimmed, ">"):
+			flush()
+			text := renderInline(strings.TrimSpace(strings.TrimPrefix(trimmed, ">")), codeStyle, dimStyle)
+			for _, l := range strings.Split(ansi.Wrap(text, width-2, ""), "\n") {
+				out = append(out, dimStyle.Render("│ ")+l)
+			}
+		case strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t"):
+			if len(para) > 0 {
+				// A continuation line, not indented code
+				para = append(para, trimmed)
+				continue
+			}
+			out = append(out, "    "+codeStyle.Render(strings.TrimPrefix(strings.TrimPrefix(line, "\t"), "    ")))
+		case mdList.MatchString(line):
+			flush()
+			match := mdList.FindStringSubmatch(line)
+			marker := match[2]
+			if !strings.ContainsAny(marker[:1], "0123456789") {
+				marker = "•"
+			}
+			prefix = strings.Repeat(" ", len(match[1])) + dimStyle.Render(marker) + " "
+			para = []string{strings.TrimPrefix(line, match[0])}
+		case i+1 < len(lines) && len(para) == 0 && isSetextUnderline(lines[i+1]):
+			// A heading underlined with === or ---
+			text := renderInline(trimmed, codeStyle, dimStyle)
+			style := heading1
+			if strings.HasPrefix(strings.TrimSpace(lines[i+1]), "-") {
+				style = heading2
+			}
+			out = append(out, style.Render(text))
+			i++
+			blank()
+		case mdHTML.ReplaceAllString(trimmed, "") == "":
+			// Lines of nothing but HTML, such as centered logos and badges
+			continue
+		default:
+			para = append(para, trimmed)
+		}
+	}
+	flush()
+	return strings.TrimRight(strings.Join(out, "\n"), "\n")
+}
+
+// renderInline renders emphasis, code spans, links, and images in text.
+func renderInline(text string, codeStyle, dimStyle lipgloss.Style) string {
+	// Code spans are rendered last, so hide them from the other patterns
+	var spans []string
+	text = mdCode.ReplaceAllStringFunc(text, func(s string) string {
+		spans = append(spans, mdCode.FindStringSubmatch(s)[1])
+		return "\x00" + strconv.Itoa(len(spans)-1) + "\x00"
+	})
+
+	text = mdBadge.ReplaceAllString(text, "$1")
+	text = mdImage.ReplaceAllStringFunc(text, func(s string) string {
+		alt := mdImage.FindStringSubmatch(s)[1]
+		if alt == "" {
+			return ""
+		}
+		return dimStyle.Render("[" + alt + "]")
+	})
+	text = mdHTML.ReplaceAllString(text, "")
+	text = mdLink.ReplaceAllStringFunc(text, func(s string) string {
+		match := mdLink.FindStringSubmatch(s)
+		if strings.HasPrefix(match[2], "#") {
+			// Links within the README add nothing in a terminal
+			return lipgloss.NewStyle().Underline(true).Render(match[1])
+		}
+		return lipgloss.NewStyle().Underline(true).Render(match[1]) + dimStyle.Render(" ("+match[2]+")")
+	})
+	text = mdBold.ReplaceAllStringFunc(text, func(s string) string {
+		match := mdBold.FindStringSubmatch(s)
+		return lipgloss.NewStyle().Bold(true).Render(match[1] + match[2])
+	})
+	text = mdItalic.ReplaceAllStringFunc(text, func(s string) string {
+		match := mdItalic.FindStringSubmatch(s)
+		return lipgloss.NewStyle().Italic(true).Render(match[1] + match[2])
+	})
+
+	for i, span := range spans {
+		text = strings.Replace(text, "\x00"+strconv.Itoa(i)+"\x00", codeStyle.Render(span), 1)
+	}
+	return text
+}
+
+// isRule reports whether line is a horizontal rule such as --- or ***.
+func isRule(line string) bool {
+	line = strings.ReplaceAll(line, " ", "")
+	if len(line) < 3 {
+		return false
+	}
+	for _, c := range []string{"-", "*", "_"} {
+		if strings.Trim(line, c) == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// isSetextUnderline reports whether line underlines the heading above it.
+func isSetextUnderline(line string) bool {
+	line = strings.TrimSpace(line)
+	return line != "" && (strings.Trim(line, "=") == "" || strings.Trim(line, "-") == "")
+}
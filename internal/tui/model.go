@@ -0,0 +1,1926 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/cwsaylor/gitmoni/internal/crash"
+	"github.com/cwsaylor/gitmoni/internal/forges"
+	"github.com/cwsaylor/gitmoni/internal/notify"
+	"github.com/cwsaylor/gitmoni/internal/plugins"
+	"github.com/cwsaylor/gitmoni/internal/repostore"
+	"github.com/cwsaylor/gitmoni/internal/script"
+	"github.com/cwsaylor/gitmoni/internal/timefmt"
+	"github.com/cwsaylor/gitmoni/pkg/config"
+	"github.com/cwsaylor/gitmoni/pkg/forge"
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+type focusedPane int
+
+const (
+	focusRepo focusedPane = iota
+	focusFile
+	focusDiff
+	focusActivity
+)
+
+// fetchCompleteMsg is sent when remote fetching is complete
+type fetchCompleteMsg struct{}
+
+// storeEventMsg delivers a batch of repository status changes from the store
+type storeEventMsg struct {
+	events []gitstatus.Event
+}
+
+// repoFetchStartMsg is sent when a specific repo starts fetching
+type repoFetchStartMsg struct {
+	repo string
+}
+
+// layoutGap is the horizontal gap subtracted when computing the right column width.
+const layoutGap = 4
+
+type model struct {
+	ctx            context.Context // Cancelled on shutdown to stop background git processes
+	workers        *sync.WaitGroup // Background tasks still running
+	config         *config.Config
+	focused        focusedPane
+	width          int
+	height         int
+	repoList       list.Model
+	fileList       list.Model
+	sideList       list.Model // Items of the active side pane
+	side           sidePane   // Pane shown instead of the file list; nil for files
+	diffView       viewport.Model
+	selectedRepo   int
+	selectedFile   int
+	store          *gitstatus.Store        // Repository statuses, updated by background tasks
+	events         *gitstatus.Subscription // Changes to store, delivered as storeEventMsg
+	currentDiff    string
+	hunkLines      []int      // Line numbers of hunk headers in currentDiff
+	blame          *blameView // Blame mode of the diff pane, if active
+	scan           *scanState // Directory scan started from the onboarding view
+	graph          bool       // The diff pane shows the commit graph
+	readme         bool       // The diff pane shows the README
+	launchLazyGit  bool
+	lazyGitRepo    string
+	isFetching     bool
+	spinner        spinner.Model          // Fetch spinner; its ticks also animate the task spinners
+	animating      bool                   // The spinner tick loop is running
+	repoVersion    int                    // Bumped whenever the visible repo rows may have changed
+	repoPane       *renderCache           // Last rendering of the repo list
+	tasks          map[string]*task       // Running background task per repo
+	taskResults    map[string]*taskResult // Recently finished task per repo
+	initCmd        tea.Cmd                // Startup fetch, returned from Init()
+	fetchStarted   time.Time              // When the current fetch batch began
+	fetchBatchSize int                    // Number of repos in the current fetch batch
+	fetchFailed    int                    // Number of fetches in the current batch that failed
+	fetchSpan      *gitstatus.Span        // Trace span of the current fetch batch
+	activity       activityLog
+	activityView   viewport.Model
+	showActivity   bool
+	toasts         []toast
+	nextToastID    int
+	dialog         *confirmDialog            // Open confirmation dialog, if any
+	prompt         *inputModal               // Open text prompt, if any
+	popup          *infoPopup                // Open read-only popup, if any
+	browser        *dirBrowser               // Open directory browser, if any
+	switcher       *recentSwitcher           // Open recent repositories switcher, if any
+	recent         *recentRepos              // Repos recently opened, committed, or pushed
+	scheduler      *gitstatus.FetchScheduler // Decides when repos are fetched automatically
+	taskErrors     map[string]taskError      // Last failed task per repo
+	inputHistory   map[string][]string
+	plugins        []plugins.Plugin                   // Discovered plugins, in order
+	pluginBadges   map[string][]plugins.Badge         // Latest plugin badges per repo
+	pluginQueries  map[string]bool                    // Repos with a plugin status query in flight
+	branches       map[string][]gitstatus.LocalBranch // Local branches per repo, for health scores
+	lastActive     map[string]time.Time               // Newest local or fetched commit per repo
+	branchQueries  map[string]bool                    // Repos with a branch listing in flight
+	collapsedDirs  map[string]map[string]bool         // Collapsed directories of the changed files tree per repo
+	expandedGroups map[string]map[string]bool         // Expanded directory groups of the flat changed files list per repo
+	forges         *forge.Resolver                    // Hosting services for pull requests and CI
+	forgeStates    map[string]forgeState              // Latest pull requests and CI per hosted repo
+	forgeBranch    map[string]string                  // Branch each repo's forge state was last queried for
+	forgeQueries   map[string]bool                    // Repos with a forge query in flight
+	notifier       *notify.Notifier                   // Reports state changes as configured
+	hooks          *script.Hooks                      // User expressions for badges, sorting, and fetches
+	title          string                             // Window title last set, with terminal_title
+	links          bool                               // Repo and file names are OSC 8 hyperlinks
+	webURLs        map[string]string                  // Web page of each hosted repo's origin
+	graphics       string                             // How images are drawn: "kitty", "iterm2", or ""
+	imageIDs       map[string]uint32                  // Kitty image ids of the thumbnails sent, by content and size
+	terminal       *terminal                          // The program's output, which thumbnails are sent to
+}
+
+// Icon represents the different icon types we use
+type Icon struct {
+	Error   string
+	Success string
+	Changed string
+	Pull    string
+	Alert   string
+	Auth    string // failures by kind
+	Network string
+	Timeout string
+	Corrupt string
+}
+
+// getIcons returns the appropriate icons based on the config setting
+func getIcons(iconStyle string) Icon {
+	if iconStyle == "ascii" {
+		// Plain ASCII, for terminals and fonts without emoji or glyphs
+		return Icon{
+			Error:   "[x]",
+			Success: "[ok]",
+			Changed: "[~]",
+			Pull:    "[v]",
+			Alert:   "[!]",
+			Auth:    "[auth]",
+			Network: "[net]",
+			Timeout: "[time]",
+			Corrupt: "[bad]",
+		}
+	}
+	if iconStyle == "text" {
+		// Words, for screen readers
+		return Icon{
+			Error:   "ERROR",
+			Success: "OK",
+			Changed: "CHANGED",
+			Pull:    "BEHIND",
+			Alert:   "ALERT",
+			Auth:    "AUTH",
+			Network: "OFFLINE",
+			Timeout: "TIMEOUT",
+			Corrupt: "CORRUPT",
+		}
+	}
+	if iconStyle == "glyphs" {
+		// Nerd Font glyphs
+		return Icon{
+			Error:   "", // nf-fa-times_circle
+			Success: "", // nf-fa-check_circle
+			Changed: "", // nf-fa-refresh
+			Pull:    "", // nf-fa-download
+			Alert:   "", // nf-fa-warning
+			Auth:    "", // nf-fa-lock
+			Network: "", // nf-fa-wifi
+			Timeout: "", // nf-fa-clock_o
+			Corrupt: "", // nf-fa-chain_broken
+		}
+	}
+	// Default to emoji. Each is shown as an emoji without a variation
+	// selector, as terminals disagree on the width of those (⬇️, ⚠️) and
+	// columns after them drift.
+	return Icon{
+		Error:   "❌",
+		Success: "✅",
+		Changed: "🔄",
+		Pull:    "🔽",
+		Alert:   "❗",
+		Auth:    "🔒",
+		Network: "📡",
+		Timeout: "⏳",
+		Corrupt: "💥",
+	}
+}
+
+// status pads icon, shown first in a repo's title, to the width of the
+// widest of the set's status icons so the names after it line up. Words,
+// and the rarer icons of failures by kind, aren't padded to.
+func (icons Icon) status(icon string, iconStyle string) string {
+	if iconStyle == "text" {
+		return icon
+	}
+	width := 0
+	for _, i := range []string{icons.Error, icons.Success, icons.Changed, icons.Alert} {
+		width = max(width, ansi.StringWidth(i))
+	}
+	return padCells(icon, width)
+}
+
+// forKind returns the icon of a kind of failure, or "" if it has none.
+func (icons Icon) forKind(kind gitstatus.ErrorKind) string {
+	switch kind {
+	case gitstatus.ErrorAuth:
+		return icons.Auth
+	case gitstatus.ErrorNetwork:
+		return icons.Network
+	case gitstatus.ErrorTimeout:
+		return icons.Timeout
+	case gitstatus.ErrorCorrupt:
+		return icons.Corrupt
+	}
+	return ""
+}
+
+type repoItem struct {
+	path            string
+	status          gitstatus.Status
+	iconStyle       string
+	displayFullPath bool
+	task            *task
+	result          *taskResult
+	badges          []badge
+	alerts          []string          // names of the alert rules that hold
+	health          *gitstatus.Health // nil unless show_health is set
+	idleDays        int               // days without activity, if stale
+	watched         []string          // how each watched branch compares to its upstream
+	accessible      bool              // spell out what symbols and colours show
+	link            string            // web page the name links to, if hyperlinks are on
+}
+
+func (i repoItem) FilterValue() string { return i.path }
+
+func (i repoItem) Title() string {
+	icons := getIcons(i.iconStyle)
+	pullIcon := ""
+	if i.status.HasRemote && i.status.NeedsPull {
+		pullIcon = icons.Pull + " "
+	}
+	// A failed fetch of a known kind says so before the name too
+	if icon := icons.forKind(i.status.ErrorKind); icon != "" && !i.status.HasError {
+		pullIcon = icon + " " + pullIcon
+	}
+
+	displayName := i.path
+	if !i.displayFullPath {
+		displayName = filepath.Base(i.path)
+	}
+	displayName = hyperlink(i.link, displayName)
+
+	title := ""
+	if i.status.HasError {
+		errorIcon := icons.Error
+		if icon := icons.forKind(i.status.ErrorKind); icon != "" {
+			errorIcon = icon
+		}
+		title = fmt.Sprintf("%s %s%s", icons.status(errorIcon, i.iconStyle), pullIcon, displayName)
+	} else if len(i.alerts) > 0 {
+		title = fmt.Sprintf("%s %s%s", icons.status(icons.Alert, i.iconStyle), pullIcon, displayName)
+		if len(i.status.Files) > 0 {
+			title += fmt.Sprintf(" (%d)", len(i.status.Files))
+		}
+	} else if len(i.status.Files) == 0 {
+		title = fmt.Sprintf("%s %s%s", icons.status(icons.Success, i.iconStyle), pullIcon, displayName)
+	} else {
+		title = fmt.Sprintf("%s %s%s (%d)", icons.status(icons.Changed, i.iconStyle), pullIcon, displayName, len(i.status.Files))
+	}
+
+	// Apply yellow color to repos with alerts, green to repos with changes,
+	// orange to repos behind remote, grey to stale repos
+	if len(i.alerts) > 0 && !i.status.HasError {
+		title = lipgloss.NewStyle().Foreground(lipgloss.Color("#e5c890")).Render(title)
+	} else if len(i.status.Files) > 0 && !i.status.HasError {
+		title = lipgloss.NewStyle().Foreground(lipgloss.Color("#a6d189")).Render(title)
+	} else if i.status.HasRemote && i.status.NeedsPull && !i.status.HasError {
+		title = lipgloss.NewStyle().Foreground(lipgloss.Color("#ef9f76")).Render(title)
+	} else if i.idleDays > 0 && !i.status.HasError {
+		// Dim stale repos, which are candidates for archiving
+		title = lipgloss.NewStyle().Foreground(lipgloss.Color("#737994")).Render(title)
+	}
+
+	if i.health != nil && !i.status.HasError {
+		title += " " + lipgloss.NewStyle().Foreground(lipgloss.Color(healthColors[i.health.Grade])).Render(i.health.Grade)
+	}
+
+	// Plugin badges follow the name
+	if badges := renderBadges(i.badges, i.accessible); badges != "" {
+		title += " " + badges
+	}
+	return title
+}
+
+func (i repoItem) Description() string {
+	if i.status.HasError {
+		return i.status.Error
+	}
+
+	branchPrefix := ""
+	if i.status.OffDefault() {
+		// Easily forgotten when pulling, so make it stand out
+		branch := fmt.Sprintf("%s (not %s)", i.status.Branch, i.status.DefaultBranch)
+		branchPrefix = lipgloss.NewStyle().Foreground(lipgloss.Color("#ca9ee6")).Render(branch) + " • " // Mauve
+	} else if i.status.Branch != "" {
+		branchPrefix = i.status.Branch + " • "
+	}
+
+	baseDesc := ""
+	if len(i.status.Files) == 0 {
+		baseDesc = branchPrefix + "No changes"
+	} else if len(i.status.Files) == 1 {
+		baseDesc = branchPrefix + "1 changed file"
+	} else {
+		baseDesc = fmt.Sprintf("%s%d changed files", branchPrefix, len(i.status.Files))
+	}
+	if i.status.Ignored > 0 {
+		baseDesc += fmt.Sprintf(" (%d ignored)", i.status.Ignored)
+	}
+	if age := dirtyFor(i.status); age != "" {
+		baseDesc += " • " + age
+	}
+	if i.idleDays > 0 {
+		baseDesc += fmt.Sprintf(" • no activity in %d days", i.idleDays)
+	}
+	for _, w := range i.watched {
+		baseDesc += " • " + w
+	}
+	if len(i.alerts) > 0 {
+		mark := "⚠"
+		if i.accessible {
+			mark = "alerts:"
+		}
+		baseDesc += " • " + mark + " " + strings.Join(i.alerts, ", ")
+	}
+	if i.health != nil && i.health.Score < 40 {
+		// Failing grades say why, so triage knows where to start
+		baseDesc += " • " + strings.Join(i.health.Reasons, ", ")
+	}
+
+	// Show spinner and progress text while a task is running, then its result
+	if i.task != nil {
+		return fmt.Sprintf("%s • %s %s", baseDesc, i.task.spinner.View(), i.task.label)
+	}
+	if i.result != nil {
+		ok, failed := resultMarks(i.iconStyle)
+		if i.result.err != nil {
+			return fmt.Sprintf("%s • %s %s", baseDesc, failed, i.result.message)
+		}
+		return fmt.Sprintf("%s • %s %s", baseDesc, ok, i.result.message)
+	}
+
+	if i.status.HasRemote && i.status.RemoteStatus != "" {
+		return fmt.Sprintf("%s • %s", baseDesc, i.status.RemoteStatus)
+	}
+
+	return baseDesc
+}
+
+// dirtyFor describes how long status's changes have been left uncommitted,
+// e.g. "dirty for 5 days", or when the time format is absolute "dirty since"
+// the time, or "" if it is less than an hour.
+func dirtyFor(status gitstatus.Status) string {
+	if status.DirtySince.IsZero() || len(status.Files) == 0 {
+		return ""
+	}
+	d := time.Since(status.DirtySince)
+	switch {
+	case d < time.Hour:
+		return ""
+	case !timeFormat.Relative():
+		return "dirty since " + timeFormat.DateTime(status.DirtySince)
+	case d < 2*time.Hour:
+		return "dirty for 1 hour"
+	case d < 24*time.Hour:
+		return fmt.Sprintf("dirty for %d hours", int(d.Hours()))
+	case d < 48*time.Hour:
+		return "dirty for 1 day"
+	default:
+		return fmt.Sprintf("dirty for %d days", int(d.Hours()/24))
+	}
+}
+
+type fileItem struct {
+	gitFile gitstatus.File
+	depth   int  // nesting in the tree view, or 1 in an expanded group
+	tree    bool // listed in the tree view, under its directory
+	change  *gitstatus.FileChange
+	link    string // file:// URL the name links to, if hyperlinks are on
+}
+
+func (i fileItem) FilterValue() string { return i.gitFile.Path }
+
+func (i fileItem) Title() string {
+	if i.tree {
+		name := path.Base(strings.TrimSuffix(i.gitFile.Path, "/"))
+		if strings.HasSuffix(i.gitFile.Path, "/") {
+			name += "/"
+		}
+		name = hyperlink(i.link, name)
+		// The tree view has no descriptions, so changes go in the title
+		if desc := describeChange(i.change); desc != "" {
+			name += "  " + desc
+		}
+		return fmt.Sprintf("%s%s %s", strings.Repeat("  ", i.depth), i.gitFile.Status, name)
+	}
+	return fmt.Sprintf("%s%s %s", strings.Repeat("  ", i.depth), i.gitFile.Status, hyperlink(i.link, i.gitFile.Path))
+}
+
+func (i fileItem) Description() string {
+	if desc := describeChange(i.change); desc != "" {
+		return getStatusDescription(i.gitFile.Status) + " • " + desc
+	}
+	return getStatusDescription(i.gitFile.Status)
+}
+
+func getStatusDescription(status string) string {
+	switch status {
+	case "M":
+		return "Modified"
+	case "A":
+		return "Added"
+	case "D":
+		return "Deleted"
+	case "R":
+		return "Renamed"
+	case "C":
+		return "Copied"
+	case "T":
+		return "Type changed"
+	case "U":
+		return "Updated but unmerged"
+	case "??":
+		return "Untracked"
+	default:
+		return "Unknown"
+	}
+}
+
+// applySyntaxHighlighting applies syntax highlighting to diff content
+func applySyntaxHighlighting(content, filePath string) string {
+	if content == "" {
+		return content
+	}
+
+	// Check if this is a git diff format
+	isDiff := strings.Contains(content, "diff --git") ||
+		strings.Contains(content, "@@") ||
+		strings.HasPrefix(content, "New file:")
+
+	var lexer chroma.Lexer
+
+	if isDiff {
+		// Use diff lexer for git diff output
+		lexer = lexers.Get("diff")
+	} else {
+		// For new files, try to detect lexer by file extension
+		lexer = lexers.Match(filePath)
+	}
+
+	// Fallback to plain text if no lexer found
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+
+	// Use a terminal-friendly style
+	style := styles.Get("catppuccin-frappe")
+	if style == nil {
+		style = styles.Fallback
+	}
+
+	// Create a 16-color terminal formatter for better compatibility
+	formatter := formatters.Get("terminal16m")
+	if formatter == nil {
+		formatter = formatters.Fallback
+	}
+
+	// Apply syntax highlighting
+	var buf strings.Builder
+	iterator, err := lexer.Tokenise(nil, content)
+	if err != nil {
+		return content // Return original content if highlighting fails
+	}
+
+	err = formatter.Format(&buf, style, iterator)
+	if err != nil {
+		return content // Return original content if formatting fails
+	}
+
+	return buf.String()
+}
+
+// newStyledList returns an empty list using the shared Catppuccin styling.
+func newStyledList(title string) list.Model {
+	// Catppuccin Frappé palette
+	titleStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#c6d0f5")). // Text
+		Bold(true)
+	l := list.New([]list.Item{}, newStyledDelegate(), 0, 0)
+	l.Title = title
+	l.Styles.Title = titleStyle
+	l.SetShowStatusBar(false)
+	l.SetShowPagination(false)
+	return l
+}
+
+// newStyledDelegate returns the item delegate of styled lists.
+func newStyledDelegate() list.DefaultDelegate {
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#c6d0f5")). // Text
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		BorderForeground(lipgloss.Color("#ca9ee6")). // Mauve
+		Padding(0, 0, 0, 1)
+	selectedDescStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#a5adce")). // Subtext0
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		BorderForeground(lipgloss.Color("#ca9ee6")). // Mauve
+		Padding(0, 0, 0, 1)
+	normalStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#c6d0f5")). // Text
+		Padding(0, 0, 0, 2)
+	normalDescStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#737994")). // Overlay0
+		Padding(0, 0, 0, 2)
+
+	delegate := list.NewDefaultDelegate()
+	delegate.Styles.SelectedTitle = selectedStyle
+	delegate.Styles.SelectedDesc = selectedDescStyle
+	delegate.Styles.NormalTitle = normalStyle
+	delegate.Styles.NormalDesc = normalDescStyle
+	return delegate
+}
+
+// newModel returns the initial model for the repositories in cfg. Background
+// tasks stop when ctx is cancelled.
+func newModel(ctx context.Context, cfg *config.Config, opts Options) model {
+	repoList := newStyledList("Repositories")
+	fileList := newStyledList("Changed Files")
+
+	diffView := viewport.New(0, 0)
+	// ←/→ scroll long lines sideways when they aren't wrapped
+	diffView.SetHorizontalStep(8)
+	timeFormat = timefmt.New(cfg.TimeFormat, cfg.Clock)
+
+	m := model{
+		ctx:            ctx,
+		workers:        &sync.WaitGroup{},
+		config:         cfg,
+		focused:        focusRepo,
+		repoList:       repoList,
+		fileList:       fileList,
+		sideList:       newStyledList(""),
+		diffView:       diffView,
+		activityView:   viewport.New(0, 0),
+		store:          repostore.New(cfg),
+		spinner:        newSpinner(cfg.Accessible),
+		repoPane:       &renderCache{},
+		tasks:          make(map[string]*task),
+		taskResults:    make(map[string]*taskResult),
+		taskErrors:     make(map[string]taskError),
+		inputHistory:   make(map[string][]string),
+		pluginBadges:   make(map[string][]plugins.Badge),
+		pluginQueries:  make(map[string]bool),
+		branches:       make(map[string][]gitstatus.LocalBranch),
+		lastActive:     make(map[string]time.Time),
+		branchQueries:  make(map[string]bool),
+		collapsedDirs:  make(map[string]map[string]bool),
+		expandedGroups: make(map[string]map[string]bool),
+		forges:         forges.New(cfg),
+		forgeStates:    make(map[string]forgeState),
+		forgeBranch:    make(map[string]string),
+		forgeQueries:   make(map[string]bool),
+		webURLs:        make(map[string]string),
+		links:          hyperlinksEnabled(cfg.Hyperlinks),
+		graphics:       graphicsProtocol(cfg.ImagePreviews),
+		imageIDs:       make(map[string]uint32),
+		terminal:       &terminal{File: os.Stdout},
+		notifier:       notify.NewNotifier(cfg),
+	}
+
+	if opts.Trace != nil {
+		m.store.SetTrace(opts.Trace)
+	}
+	m.setFileDelegate()
+
+	var hooksErr error
+	m.hooks, hooksErr = script.Load(cfg)
+
+	m.recent = &recentRepos{}
+	if path, err := defaultRecentPath(); err == nil {
+		m.recent = loadRecentRepos(path)
+	}
+
+	if len(cfg.Repositories) > 0 {
+		// Do initial status check without fetching
+		m.store.RefreshAll(cfg.Repositories)
+		m.updateRepoList()
+		m.selectRepo(0)
+	}
+
+	// Subscribe after the initial check so its results aren't replayed,
+	// and start fetch tasks before Init() runs (Init is a value receiver,
+	// so mutations there would be lost).
+	m.events = m.store.Subscribe()
+	m.scheduler = gitstatus.NewFetchScheduler(cfg.DefaultFetchInterval(), func(repo string) gitstatus.FetchPlan {
+		interval, quiet := cfg.FetchSchedule(repo)
+		return gitstatus.FetchPlan{Interval: interval, Quiet: quiet.Contains}
+	})
+	if len(cfg.Repositories) > 0 {
+		m.initCmd = m.startFetch(m.autoFetchable(m.notQuiet(cfg.Repositories)))
+		for _, repo := range cfg.Repositories {
+			m.initCmd = tea.Batch(m.initCmd, m.queryBranches(repo))
+		}
+		if cfg.GitMaintenance {
+			m.initCmd = tea.Batch(m.initCmd, m.enableMaintenance(cfg.Repositories))
+		}
+	}
+	if hooksErr != nil {
+		slog.Warn("invalid scripts", "err", hooksErr)
+		m.initCmd = tea.Batch(m.initCmd, m.notify("Invalid script: "+hooksErr.Error(), true))
+	}
+
+	return m
+}
+
+func (m *model) updateRepoList() {
+	items := make([]list.Item, 0)
+	for _, repo := range m.config.Repositories {
+		status, exists := m.store.Status(repo)
+		if !exists {
+			status = gitstatus.Status{Path: repo, HasError: true, Error: "Status not loaded"}
+		}
+
+		items = append(items, repoItem{
+			path:            repo,
+			status:          status,
+			iconStyle:       m.iconStyle(),
+			displayFullPath: m.config.DisplayFullPath,
+			task:            m.tasks[repo],
+			result:          m.taskResults[repo],
+			badges:          m.repoBadges(repo),
+			alerts:          m.hooks.AlertsFor(status),
+			health:          m.health(status),
+			idleDays:        m.idleDays(repo),
+			watched:         m.watchedBranches(repo, status.Branch),
+			accessible:      m.config.Accessible,
+			link:            m.repoLink(repo),
+		})
+	}
+	// Sort by path if alphabetical order is configured, or worst health
+	// first, by path among equals
+	switch m.config.SortOrder {
+	case "alphabetical":
+		slices.SortStableFunc(items, func(a, b list.Item) int {
+			return strings.Compare(a.(repoItem).path, b.(repoItem).path)
+		})
+	case "health":
+		scores := make(map[string]int, len(items))
+		for _, item := range items {
+			status := item.(repoItem).status
+			scores[status.Path] = gitstatus.HealthOf(status, m.branches[status.Path], m.staleAfter()).Score
+		}
+		slices.SortStableFunc(items, func(a, b list.Item) int {
+			pa, pb := a.(repoItem).path, b.(repoItem).path
+			if c := scores[pa] - scores[pb]; c != 0 {
+				return c
+			}
+			return strings.Compare(pa, pb)
+		})
+	}
+
+	// Float changed/behind repos to top if configured, grouped by priority:
+	// 1. Both local changes and behind remote
+	// 2. Behind remote only
+	// 3. Local changes only
+	// 4. Clean repos
+	// Within each group, the primary sort_order is preserved (stable sort).
+	// Health already weighs changes and commits to pull. Accessible mode
+	// keeps repos in place, so they can be found where they were.
+	if m.config.SortChangedToTop && m.config.SortOrder != "health" && !m.config.Accessible {
+		slices.SortStableFunc(items, func(a, b list.Item) int {
+			return repoChangePriority(a.(repoItem)) - repoChangePriority(b.(repoItem))
+		})
+	}
+
+	// sort_script has the final say, again keeping the order within equal
+	// priorities
+	if m.hooks.Priority != nil {
+		priority := make(map[string]int, len(items))
+		for _, item := range items {
+			priority[item.(repoItem).path] = m.hooks.PriorityOf(item.(repoItem).status)
+		}
+		slices.SortStableFunc(items, func(a, b list.Item) int {
+			return priority[a.(repoItem).path] - priority[b.(repoItem).path]
+		})
+	}
+
+	m.repoVersion++
+
+	// Keep the cursor on the same repo when re-sorting moves it
+	selected := m.selectedRepoPath()
+	m.repoList.SetItems(items)
+	for i, item := range items {
+		if item.(repoItem).path == selected {
+			m.repoList.Select(i)
+			m.selectedRepo = i
+			break
+		}
+	}
+}
+
+// health scores status with its repository's branches, or returns nil if
+// show_health is off.
+func (m *model) health(status gitstatus.Status) *gitstatus.Health {
+	if !m.config.ShowHealth {
+		return nil
+	}
+	h := gitstatus.HealthOf(status, m.branches[status.Path], m.staleAfter())
+	return &h
+}
+
+// staleAfter is how long a branch can go without commits before its
+// repository's health suffers.
+func (m *model) staleAfter() time.Duration {
+	return time.Duration(m.config.StaleBranchDays) * 24 * time.Hour
+}
+
+// repoBadges returns the badges shown after repo's name: its CI and pull
+// requests, then what each plugin and badge script reports.
+func (m *model) repoBadges(repo string) []badge {
+	var badges []badge
+	if state, ok := m.forgeStates[repo]; ok {
+		badges = append(badges, forgeBadges(state, m.iconStyle())...)
+	}
+	for _, b := range m.pluginBadges[repo] {
+		badges = append(badges, badge{text: b.Text, level: b.Level})
+	}
+	if status, ok := m.store.Status(repo); ok {
+		for _, b := range m.hooks.BadgesFor(status) {
+			badges = append(badges, badge{text: b.Text, level: b.Level})
+		}
+	}
+	return badges
+}
+
+// autoFetchable returns the repos that fetch_script allows gitmoni to fetch
+// on its own. Fetches the user asks for aren't vetoed.
+func (m *model) autoFetchable(repos []string) []string {
+	var allowed []string
+	for _, repo := range repos {
+		status, _ := m.store.Status(repo)
+		if m.hooks.AllowFetch(status) {
+			allowed = append(allowed, repo)
+		} else {
+			m.activity.add(repo, "Skipped fetch (fetch_script)")
+		}
+	}
+	return allowed
+}
+
+// repoChangePriority returns a sort key for grouping repos by change state.
+// Lower values sort first.
+func repoChangePriority(item repoItem) int {
+	hasLocal := len(item.status.Files) > 0
+	hasRemote := item.status.HasRemote && item.status.NeedsPull
+	switch {
+	case hasLocal && hasRemote:
+		return 0
+	case hasRemote:
+		return 1
+	case hasLocal:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// needsAttention reports whether a repo is dirty, behind its upstream, in
+// an error state, or alerted about.
+func needsAttention(item repoItem) bool {
+	return item.status.HasError || repoChangePriority(item) < 3 || len(item.alerts) > 0
+}
+
+// jumpToAttention moves the selection to the next repo (in direction delta)
+// that needs attention, wrapping around the list. It reports whether one
+// was found.
+func (m *model) jumpToAttention(delta int) bool {
+	items := m.repoList.Items()
+	n := len(items)
+	for step := 1; step <= n; step++ {
+		i := ((m.repoList.Index()+delta*step)%n + n) % n
+		if needsAttention(items[i].(repoItem)) {
+			if i != m.repoList.Index() {
+				m.selectRepo(i)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// selectedRepoPath returns the path of the currently selected repo from the
+// displayed (sorted) list, not from the config array.
+func (m *model) selectedRepoPath() string {
+	item := m.repoList.SelectedItem()
+	if item == nil {
+		return ""
+	}
+	return item.(repoItem).path
+}
+
+func (m *model) updateFileList() {
+	repo := m.selectedRepoPath()
+	if repo == "" {
+		m.fileList.SetItems([]list.Item{})
+		return
+	}
+	status, exists := m.store.Status(repo)
+	if !exists || status.HasError {
+		m.fileList.SetItems([]list.Item{})
+		return
+	}
+
+	changes, err := m.store.FileChanges(repo, status.Files)
+	if err != nil {
+		slog.Debug("file changes unavailable", "repo", repo, "err", err)
+	}
+	var items []list.Item
+	if m.config.FileTree {
+		collapsed := m.collapsedDirs[repo]
+		items = withChanges(fileTreeItems(status.Files, func(dir string) bool { return collapsed[dir] }, m.iconStyle()), changes)
+	} else {
+		expanded := m.expandedGroups[repo]
+		items = withChanges(groupedFileItems(status.Files, func(dir string) bool { return expanded[dir] }, m.iconStyle()), changes)
+	}
+	if m.links {
+		items = withLinks(items, repo)
+	}
+	m.fileList.SetItems(items)
+}
+
+func (m *model) selectRepo(index int) {
+	if index >= 0 && index < len(m.repoList.Items()) {
+		m.selectedRepo = index
+		m.selectedFile = 0
+		m.repoList.Select(index)
+		m.syncLowerPane(false)
+	}
+}
+
+// syncLowerPane reloads the lower-left pane (changed files, or the active
+// side pane) for the selected repo and updates the diff pane to match.
+// keepCursor preserves the cursor position where possible, as when the
+// selected repo was refreshed: an open commit graph, README, or blame view
+// is then brought up to date where it is scrolled to. The graph and README
+// also stay open when another repo is selected.
+func (m *model) syncLowerPane(keepCursor bool) {
+	if !keepCursor {
+		// Blame is of a file in the repo that was selected
+		m.blame = nil
+	}
+	offset := m.diffView.YOffset
+	if m.side != nil {
+		m.loadSidePane(keepCursor)
+	} else {
+		m.updateFileList()
+		if len(m.fileList.Items()) > 0 {
+			index := 0
+			if keepCursor {
+				index = min(m.selectedFile, len(m.fileList.Items())-1)
+			}
+			m.selectFile(index)
+		} else if !m.updateDiffMode() {
+			m.setDiffContent("")
+		}
+	}
+	if keepCursor && (m.graph || m.readme) {
+		m.diffView.SetYOffset(offset)
+	}
+}
+
+// closeDiffModes closes the commit graph, README, and blame view, for when
+// the user selects something else to show in the diff pane.
+func (m *model) closeDiffModes() {
+	m.blame = nil
+	m.graph = false
+	m.readme = false
+}
+
+// updateDiffMode redraws the commit graph, README, or blame view if one is
+// open in the diff pane, and reports whether one was. A blame view whose
+// file is no longer selected is closed instead.
+func (m *model) updateDiffMode() bool {
+	switch {
+	case m.graph:
+		m.updateGraph()
+	case m.readme:
+		m.updateReadme()
+	case m.blame != nil:
+		item, ok := m.fileList.SelectedItem().(fileItem)
+		if !ok || m.blame.repo != m.selectedRepoPath() || m.blame.file != item.gitFile.Path {
+			m.blame = nil
+			return false
+		}
+		m.reloadBlame()
+	default:
+		return false
+	}
+	return true
+}
+
+func (m *model) selectFile(index int) {
+	items := m.fileList.Items()
+	if index >= 0 && index < len(items) {
+		m.selectedFile = index
+		m.fileList.Select(index)
+		m.updateDiff()
+	}
+}
+
+func (m *model) updateDiff() {
+	if m.updateDiffMode() {
+		return
+	}
+	items := m.fileList.Items()
+	if m.selectedFile >= 0 && m.selectedFile < len(items) {
+		if dir, ok := items[m.selectedFile].(dirItem); ok {
+			m.setDiffContent(dirSummary(dir))
+			return
+		}
+		fileItem, ok := items[m.selectedFile].(fileItem)
+		if !ok {
+			return
+		}
+		repo := m.selectedRepoPath()
+		if isImage(fileItem.gitFile.Path) {
+			m.setDiffContent(m.imageDiff(repo, fileItem.gitFile))
+			return
+		}
+
+		diff, err := m.store.FileDiff(repo, fileItem.gitFile.Path)
+		if err != nil {
+			m.setDiffContent(fmt.Sprintf("Error getting diff: %s", err.Error()))
+		} else if diff == "" {
+			m.setDiffContent(fmt.Sprintf("No diff available for: %s\n\nThis could mean:\n- File is newly added (not tracked)\n- File is staged but no changes in working directory\n- Binary file", fileItem.gitFile.Path))
+		} else {
+			// Apply syntax highlighting to the diff content
+			m.setDiffContent(applySyntaxHighlighting(diff, fileItem.gitFile.Path))
+		}
+	}
+}
+
+// refreshRepoStatus re-checks a single repo. The view catches up when the
+// resulting store event arrives.
+func (m *model) refreshRepoStatus(repo string) {
+	m.store.Refresh(repo)
+}
+
+// listen waits for the next batch of store changes.
+func (m *model) listen() tea.Cmd {
+	events := m.events
+	return func() tea.Msg {
+		return storeEventMsg{events: events.Next()}
+	}
+}
+
+// applyStoreEvents redraws the repo list for changed statuses and, if the
+// selected repo changed, its files and diff. The all-changes pane is
+// redrawn for any repo.
+func (m *model) applyStoreEvents(events []gitstatus.Event) tea.Cmd {
+	m.updateRepoList()
+	selected := m.selectedRepoPath()
+	_, allChanges := m.side.(allChangesPane)
+	var cmds []tea.Cmd
+	for _, e := range events {
+		if e.Repo == selected || allChanges {
+			m.syncLowerPane(true)
+			allChanges = false
+		}
+		if e.Removed {
+			delete(m.pluginBadges, e.Repo)
+			delete(m.branches, e.Repo)
+			delete(m.lastActive, e.Repo)
+			delete(m.forgeStates, e.Repo)
+			delete(m.forgeBranch, e.Repo)
+			delete(m.webURLs, e.Repo)
+			m.recent.forget(e.Repo)
+			m.notifier.Forget(e.Repo)
+			continue
+		}
+		cmds = append(cmds, m.queryPlugins(e.Repo), m.queryBranches(e.Repo), m.notifyChanges(e.Status))
+		if e.Status.Branch != m.forgeBranch[e.Repo] {
+			// A different branch has its own pull request and CI runs
+			cmds = append(cmds, m.queryForge(e.Repo))
+		}
+	}
+	// Scripts ran for the new statuses when the list was updated
+	for _, err := range m.hooks.Failures() {
+		cmds = append(cmds, m.notify("Script failed: "+err.Error(), true))
+	}
+	cmds = append(cmds, m.updateTitle())
+	return tea.Batch(cmds...)
+}
+
+// showErrorDetail opens a popup describing the selected repo's status error
+// or, failing that, its most recent failed task.
+func (m *model) showErrorDetail(repo string) {
+	status, _ := m.store.Status(repo)
+	if status.HasError {
+		if status.ErrorDetail != nil {
+			m.showInfo("Status failed: "+filepath.Base(repo), formatGitError(status.ErrorDetail))
+		} else {
+			m.showInfo("Status failed: "+filepath.Base(repo), status.Error)
+		}
+		return
+	}
+	if te, ok := m.taskErrors[repo]; ok {
+		title := fmt.Sprintf("%s failed: %s", capitalize(te.kind), filepath.Base(repo))
+		var gitErr *gitstatus.Error
+		if errors.As(te.err, &gitErr) {
+			m.showInfo(title, formatGitError(gitErr))
+		} else {
+			m.showInfo(title, te.err.Error())
+		}
+		return
+	}
+	m.showInfo(filepath.Base(repo), "No errors recorded for this repository.")
+}
+
+// formatGitError lays out a failed git command for the error popup.
+func formatGitError(e *gitstatus.Error) string {
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#737994")) // Overlay0
+	lines := []string{
+		labelStyle.Render("Command:   ") + e.Command(),
+		labelStyle.Render("Directory: ") + e.Dir,
+		labelStyle.Render("Failed:    ") + timeFormat.When(e.Time),
+		labelStyle.Render("Error:     ") + e.Err.Error(),
+	}
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		lines = append(lines, "", stderr)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// focusedListFiltering reports whether the focused list is capturing input
+// for its filter.
+func (m *model) focusedListFiltering() bool {
+	switch {
+	case m.focused == focusRepo:
+		return m.repoList.FilterState() == list.Filtering
+	case m.focused == focusFile && m.side != nil:
+		return m.sideList.FilterState() == list.Filtering
+	case m.focused == focusFile:
+		return m.fileList.FilterState() == list.Filtering
+	}
+	return false
+}
+
+// handleNavigation routes a key event to the currently focused pane and
+// syncs selection state accordingly.
+func (m *model) handleNavigation(msg tea.KeyMsg, cmds *[]tea.Cmd, cmd tea.Cmd) tea.Cmd {
+	switch m.focused {
+	case focusRepo:
+		m.repoList, cmd = m.repoList.Update(msg)
+		*cmds = append(*cmds, cmd)
+		if m.repoList.SelectedItem() != nil {
+			m.selectedRepo = m.repoList.Index()
+			m.syncLowerPane(false)
+		}
+	case focusFile:
+		if m.side != nil {
+			m.sideList, cmd = m.sideList.Update(msg)
+			*cmds = append(*cmds, cmd)
+			m.closeDiffModes()
+			m.updateSideDetail()
+			break
+		}
+		m.fileList, cmd = m.fileList.Update(msg)
+		*cmds = append(*cmds, cmd)
+		if m.fileList.SelectedItem() != nil {
+			m.selectedFile = m.fileList.Index()
+			m.closeDiffModes()
+			m.updateDiff()
+		}
+	case focusDiff:
+		m.diffView, cmd = m.diffView.Update(msg)
+		*cmds = append(*cmds, cmd)
+	case focusActivity:
+		m.activityView, cmd = m.activityView.Update(msg)
+		*cmds = append(*cmds, cmd)
+	}
+	return tea.Batch(*cmds...)
+}
+
+// resize recomputes pane sizes from the terminal dimensions and the set of
+// visible panes. Called on window resize and whenever a pane is toggled.
+func (m *model) resize() {
+	// Create a style to calculate frame size including borders and padding
+	frameStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		Padding(0, 1)
+
+	// Calculate frame overhead (borders + padding)
+	frameWidth, frameHeight := frameStyle.GetFrameSize()
+
+	// 2-column layout: left column (40%) for repo and file lists, right column (60%) for diff
+	leftColumnWidth := int(float64(m.width) * 0.4)
+	rightColumnWidth := m.width - leftColumnWidth - layoutGap
+
+	// Help text takes up some vertical space
+	helpHeight := 2 // Help text + some padding
+	availableHeight := m.height - helpHeight
+
+	// The activity log spans the full width below both columns when visible
+	if m.showActivity {
+		availableHeight -= activityPaneHeight + frameHeight
+		m.activityView.Width = m.width - frameWidth
+		if m.activityView.Width < 0 {
+			m.activityView.Width = 0
+		}
+		m.activityView.Height = activityPaneHeight
+	}
+
+	// Left column is split vertically: repositories (70%) and files (30%)
+	// Compute total content budget first to avoid rounding overflow, then split.
+	leftPaneContentWidth := leftColumnWidth - frameWidth
+	if leftPaneContentWidth < 0 {
+		leftPaneContentWidth = 0
+	}
+	rightPaneContentWidth := rightColumnWidth - frameWidth
+	if rightPaneContentWidth < 0 {
+		rightPaneContentWidth = 0
+	}
+
+	leftContentBudget := availableHeight - (2 * frameHeight)
+	if leftContentBudget < 0 {
+		leftContentBudget = 0
+	}
+	repoHeight := (leftContentBudget * 7) / 10
+	fileHeight := leftContentBudget - repoHeight
+
+	diffHeight := availableHeight - frameHeight
+	if diffHeight < 0 {
+		diffHeight = 0
+	}
+
+	m.repoList.SetSize(leftPaneContentWidth, repoHeight)
+	m.fileList.SetSize(leftPaneContentWidth, fileHeight)
+	m.sideList.SetSize(leftPaneContentWidth, fileHeight)
+	m.diffView.Width = rightPaneContentWidth
+	m.diffView.Height = diffHeight
+}
+
+// focusOrder returns the panes that Tab cycles through, in order.
+func (m *model) focusOrder() []focusedPane {
+	panes := []focusedPane{focusRepo, focusFile, focusDiff}
+	if m.showActivity {
+		panes = append(panes, focusActivity)
+	}
+	return panes
+}
+
+// cycleFocus moves focus forward (delta 1) or backward (delta -1) through
+// the visible panes.
+func (m *model) cycleFocus(delta int) {
+	panes := m.focusOrder()
+	idx := slices.Index(panes, m.focused)
+	if idx < 0 {
+		m.focused = focusRepo
+		return
+	}
+	m.focused = panes[(idx+delta+len(panes))%len(panes)]
+}
+
+// refreshActivityView re-renders the activity log, keeping the view pinned
+// to the newest entry unless the user has scrolled up.
+func (m *model) refreshActivityView() {
+	atBottom := m.activityView.AtBottom()
+	m.activityView.SetContent(m.activity.render())
+	if atBottom {
+		m.activityView.GotoBottom()
+	}
+}
+
+// startFetch starts a fetch task for each repo and the global fetch spinner.
+// Repos that already have a task running are skipped.
+func (m *model) startFetch(repos []string) tea.Cmd {
+	store := m.store
+	var cmds []tea.Cmd
+	if !m.isFetching {
+		m.isFetching = true
+		m.fetchStarted = time.Now()
+		m.fetchBatchSize = 0
+		m.fetchFailed = 0
+		_, m.fetchSpan = gitstatus.StartSpan(context.Background(), "fetch batch")
+		cmds = append(cmds, m.animate())
+	}
+
+	span := m.fetchSpan
+	for _, repo := range repos {
+		if cmd := m.startTask(repo, "fetch", "Updating", "Fetched", func(ctx context.Context) error {
+			return store.Fetch(gitstatus.WithSpan(ctx, span), repo)
+		}); cmd != nil {
+			m.scheduler.Fetched(repo, time.Now())
+			m.fetchBatchSize++
+			cmds = append(cmds, cmd)
+		}
+	}
+	if m.runningTasks("fetch") == 0 {
+		// Nothing to fetch; don't leave the global spinner running
+		m.isFetching = false
+		m.fetchSpan = nil
+	}
+	return tea.Batch(cmds...)
+}
+
+// addRepository validates path, adds it to the config, and starts
+// monitoring it. The returned error is suitable for showing to the user.
+func (m *model) addRepository(path string) (tea.Cmd, error) {
+	absPath, err := gitstatus.ValidateRepository(expandHome(strings.TrimSpace(path)))
+	if err != nil {
+		return nil, err
+	}
+	if !m.config.AddRepository(absPath) {
+		return nil, fmt.Errorf("already monitoring %s", absPath)
+	}
+	if err := m.config.Save(); err != nil {
+		m.config.RemoveRepository(absPath)
+		return nil, fmt.Errorf("failed to save config: %w", err)
+	}
+
+	m.store.Refresh(absPath)
+	fetch := m.startFetch([]string{absPath})
+	for i, item := range m.repoList.Items() {
+		if item.(repoItem).path == absPath {
+			m.selectRepo(i)
+			break
+		}
+	}
+	return tea.Batch(fetch, m.actionResult(absPath, "Added "+absPath, "", nil)), nil
+}
+
+// removeRepository drops repo from the config and the view.
+func (m *model) removeRepository(repo string) tea.Cmd {
+	if !m.config.RemoveRepository(repo) {
+		return m.actionResult(repo, "", "Remove failed", fmt.Errorf("not in config"))
+	}
+	if err := m.config.Save(); err != nil {
+		m.config.AddRepository(repo)
+		return m.actionResult(repo, "", "Remove failed", err)
+	}
+
+	m.store.Remove(repo)
+	delete(m.taskResults, repo)
+	index := m.repoList.Index()
+	m.updateRepoList()
+	m.selectRepo(min(index, len(m.repoList.Items())-1))
+	if len(m.repoList.Items()) == 0 {
+		m.fileList.SetItems([]list.Item{})
+		m.sideList.SetItems([]list.Item{})
+		m.setDiffContent("")
+	}
+	return m.actionResult(repo, "Removed "+repo, "", nil)
+}
+
+// moveSelectedRepo swaps the selected repo with its neighbour in the displayed
+// list and saves the new order. Alphabetical sorting is switched to manual
+// first, starting from the current alphabetical order, so the move is visible.
+func (m *model) moveSelectedRepo(delta int) tea.Cmd {
+	items := m.repoList.Items()
+	target := m.repoList.Index() + delta
+	if target < 0 || target >= len(items) {
+		return nil
+	}
+	repo := m.selectedRepoPath()
+	neighbour := items[target].(repoItem).path
+	if setting := m.keptApartBy(items[m.repoList.Index()].(repoItem), items[target].(repoItem)); setting != "" {
+		// Swapping them in the config wouldn't move either on screen
+		return m.notify(fmt.Sprintf("Can't move past %s: %s sorts it apart", filepath.Base(neighbour), setting), false)
+	}
+
+	var cmd tea.Cmd
+	if m.config.SortOrder != "manual" {
+		slices.Sort(m.config.Repositories)
+		m.config.SortOrder = "manual"
+		cmd = m.notify("Switched sort_order to manual", false)
+	}
+	m.config.SwapRepositories(repo, neighbour)
+	if err := m.config.Save(); err != nil {
+		return tea.Batch(cmd, m.actionResult(repo, "", "Failed to save order", err))
+	}
+	m.updateRepoList()
+	return cmd
+}
+
+// keptApartBy returns the setting that sorts a and b into different groups
+// of the repo list whatever their order in the config, or "" if none does.
+// sort_order is left out, as moving a repo makes it manual.
+func (m *model) keptApartBy(a, b repoItem) string {
+	if m.config.SortChangedToTop && !m.config.Accessible && repoChangePriority(a) != repoChangePriority(b) {
+		return "sort_changed_to_top"
+	}
+	if m.hooks.Priority != nil && m.hooks.PriorityOf(a.status) != m.hooks.PriorityOf(b.status) {
+		return "sort_script"
+	}
+	return ""
+}
+
+func (m model) Init() tea.Cmd {
+	// The startup fetch is prepared in newModel() because Init() is a
+	// value receiver — mutations here would be lost.
+	return tea.Batch(m.initCmd, m.listen(), m.loadPlugins(), scheduleFetches())
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	defer crash.Capture()
+	var cmd tea.Cmd
+	var cmds []tea.Cmd
+
+	switch msg := msg.(type) {
+	case taskDoneMsg:
+		return m, m.finishTask(msg)
+
+	case commitOutputMsg:
+		return m, m.showCommitOutput(msg)
+
+	case controlMsg:
+		return m, m.handleControl(msg)
+
+	case storeEventMsg:
+		if msg.events == nil {
+			return m, nil
+		}
+		cmd := m.applyStoreEvents(msg.events)
+		return m, tea.Batch(cmd, m.listen())
+
+	case pluginsLoadedMsg:
+		m.plugins = msg.plugins
+		if msg.err != nil {
+			m.activity.addError("", "%s", msg.err)
+			m.refreshActivityView()
+		}
+		var cmds []tea.Cmd
+		for _, repo := range m.config.Repositories {
+			cmds = append(cmds, m.queryPlugins(repo))
+		}
+		return m, tea.Batch(cmds...)
+
+	case forgeMsg:
+		cmd := m.applyForge(msg)
+		return m, cmd
+
+	case commitEditedMsg:
+		return m, m.finishCommitEdit(msg)
+
+	case imageViewedMsg:
+		if msg.err != nil {
+			return m, m.notify("Showing the image failed: "+msg.err.Error(), true)
+		}
+		return m, nil
+
+	case branchesMsg:
+		delete(m.branchQueries, msg.repo)
+		if msg.err == nil {
+			m.branches[msg.repo] = msg.branches
+			m.lastActive[msg.repo] = msg.lastActive
+			m.updateRepoList()
+		}
+		return m, nil
+
+	case scanDoneMsg:
+		m.finishScan(msg)
+		return m, nil
+
+	case maintenanceMsg:
+		m.finishMaintenance(msg)
+		return m, nil
+
+	case pluginBadgesMsg:
+		delete(m.pluginQueries, msg.repo)
+		m.pluginBadges[msg.repo] = msg.badges
+		m.updateRepoList()
+		return m, nil
+
+	case fetchTickMsg:
+		return m, m.fetchDue()
+
+	case taskResultExpiredMsg:
+		// Only clear the result if no newer task has replaced it
+		if r, ok := m.taskResults[msg.repo]; ok && r.finished.Equal(msg.finished) {
+			delete(m.taskResults, msg.repo)
+			m.updateRepoList()
+		}
+		return m, nil
+
+	case toastExpiredMsg:
+		m.dismissToast(msg.id)
+		return m, nil
+
+	case spinner.TickMsg:
+		return m, m.tickSpinners(msg)
+
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.resize()
+		if m.config.WrapDiff {
+			m.renderDiff()
+		}
+		// The README is wrapped to the diff pane's width
+		if m.readme {
+			m.updateReadme()
+		}
+
+	case tea.KeyMsg:
+		// An open dialog or prompt captures all keys until it is dismissed
+		if m.dialog != nil {
+			return m, m.handleDialogKey(msg)
+		}
+		if m.prompt != nil {
+			return m, m.handlePromptKey(msg)
+		}
+		if m.popup != nil {
+			return m, m.handlePopupKey(msg)
+		}
+		if m.browser != nil {
+			return m, m.handleBrowserKey(msg)
+		}
+		if m.switcher != nil {
+			return m, m.handleSwitcherKey(msg)
+		}
+		// While a list filter is being typed, keys belong to the filter
+		if m.focusedListFiltering() {
+			return m, m.handleNavigation(msg, &cmds, cmd)
+		}
+		// Blame mode moves its own cursor when the diff pane is focused
+		if m.blame != nil && m.focused == focusDiff && m.handleBlameKey(msg.String()) {
+			return m, nil
+		}
+		// The open side pane may bind its own actions
+		if m.side != nil && m.focused == focusFile {
+			if cmd, ok := m.handleSidePaneKey(msg.String()); ok {
+				return m, cmd
+			}
+		}
+		// As do directories in the changed files pane
+		if m.side == nil && m.focused == focusFile && m.handleDirKey(msg.String()) {
+			return m, nil
+		}
+		// So does the onboarding view shown until a repository is added
+		if len(m.config.Repositories) == 0 {
+			if cmd, ok := m.handleOnboardingKey(msg.String()); ok {
+				return m, cmd
+			}
+		}
+
+		switch msg.String() {
+		case "ctrl+c", "q":
+			return m, tea.Quit
+		case "e":
+			// Show details of the selected repo's last failure
+			if repo := m.selectedRepoPath(); repo != "" {
+				m.showErrorDetail(repo)
+			}
+		case "enter":
+			// Repos in an error state show what went wrong instead of launching
+			if status, _ := m.store.Status(m.selectedRepoPath()); status.HasError {
+				m.showErrorDetail(status.Path)
+				return m, nil
+			}
+			if repo := m.selectedRepoPath(); gitstatus.IsSSH(repo) {
+				// Neither lazygit nor a GUI can open a repository on another host
+				return m, m.notify(fmt.Sprintf("%s is on another host; Enter only opens local repositories", filepath.Base(repo)), true)
+			} else if repo != "" {
+				// Check if the command starts with "github" - if so, launch in background
+				if strings.HasPrefix(m.config.EnterCommand(repo), "github") {
+					// Launch GitHub Desktop in background and continue running TUI
+					commandTemplate := m.config.EnterCommand(repo)
+					command := strings.ReplaceAll(commandTemplate, "$REPO", gitstatus.WorkDir(repo))
+					parts := strings.Fields(command)
+					if len(parts) > 0 {
+						m.noteRecent(repo, "opened")
+						var cmd *exec.Cmd
+						if len(parts) == 1 {
+							cmd = exec.Command(parts[0])
+						} else {
+							cmd = exec.Command(parts[0], parts[1:]...)
+						}
+						// Start the GUI in background
+						if err := cmd.Start(); err != nil {
+							m.activity.addError(repo, "Failed to launch %s: %s", parts[0], err)
+							m.refreshActivityView()
+							return m, m.notify(fmt.Sprintf("Failed to launch %s: %s", parts[0], err), true)
+						}
+						m.activity.add(repo, "Launched %s", parts[0])
+						m.refreshActivityView()
+					}
+					// Don't quit - return to TUI
+					return m, nil
+				} else {
+					// For TUI apps like lazygit, set flag to launch and quit
+					m.noteRecent(repo, "opened")
+					m.launchLazyGit = true
+					m.lazyGitRepo = repo
+					return m, tea.Quit
+				}
+			}
+		case "tab":
+			// Switch focus forward through the visible panes
+			m.cycleFocus(1)
+		case "shift+tab":
+			// Switch focus backwards through the visible panes
+			m.cycleFocus(-1)
+		case "m":
+			// Toggle the activity log pane
+			m.showActivity = !m.showActivity
+			if !m.showActivity && m.focused == focusActivity {
+				m.focused = focusRepo
+			}
+			m.resize()
+			m.refreshActivityView()
+		case "up", "k":
+			return m, m.handleNavigation(msg, &cmds, cmd)
+		case "down", "j":
+			return m, m.handleNavigation(msg, &cmds, cmd)
+		case "d", "delete":
+			// Stop monitoring the selected repository after confirmation
+			repo := m.selectedRepoPath()
+			if repo == "" || m.focused != focusRepo {
+				break
+			}
+			m.confirm("Remove repository?",
+				fmt.Sprintf("Stop monitoring %s? The repository itself is not touched.", repo),
+				func(m *model) tea.Cmd {
+					return m.removeRepository(repo)
+				})
+		case "esc":
+			// Close the side pane, unless the focused list has a filter to clear
+			if m.side != nil && m.focused == focusFile && m.sideList.FilterState() == list.Unfiltered {
+				m.closeSidePane()
+				return m, nil
+			}
+			return m, m.handleNavigation(msg, &cmds, cmd)
+		case "]", "[":
+			// Jump between hunks in the diff pane
+			if m.focused == focusDiff || m.focused == focusFile {
+				delta := 1
+				if msg.String() == "[" {
+					delta = -1
+				}
+				m.jumpHunk(delta)
+			}
+		case "b":
+			// Toggle blame for the selected file
+			if m.blame != nil {
+				m.closeBlame()
+				break
+			}
+			if m.focused == focusFile && m.side == nil {
+				if err := m.openBlame(); err != nil {
+					return m, m.actionResult(m.selectedRepoPath(), "", "Blame failed", err)
+				}
+			}
+		case "l":
+			// Toggle the commit log for the selected repo
+			m.toggleSidePane(commitLogPane{})
+		case "g":
+			// Toggle the commit graph for the selected repo
+			m.toggleGraph()
+		case "i":
+			// Toggle the README of the selected repo
+			m.toggleReadme()
+		case "T":
+			// Toggle the changed files between a list and a directory tree
+			return m, m.toggleFileTree()
+		case "t":
+			// Toggle the tags pane for the selected repo
+			m.toggleSidePane(tagsPane{})
+		case "B":
+			// Toggle the remote branches pane for the selected repo
+			m.toggleSidePane(remoteBranchesPane{})
+		case "w":
+			// Toggle the worktrees pane for the selected repo
+			m.toggleSidePane(worktreesPane{})
+		case "S":
+			// Toggle the submodules pane for the selected repo
+			m.toggleSidePane(submodulesPane{})
+		case "s":
+			// Toggle the stash pane for the selected repo
+			m.toggleSidePane(stashPane{})
+		case "H":
+			// Toggle the reflog for the selected repo
+			m.toggleSidePane(reflogPane{})
+		case "h":
+			// Toggle the recorded state changes of the selected repo
+			m.toggleSidePane(historyPane{})
+		case "!":
+			// Toggle the plugin actions for the selected repo
+			m.toggleSidePane(pluginActionsPane{plugins: m.plugins, badges: m.pluginBadges})
+		case "O":
+			// Open the selected repo's failing or latest CI run
+			if repo := m.selectedRepoPath(); repo != "" {
+				return m, m.openCIRun(repo)
+			}
+		case "ctrl+p":
+			// Switch to a recently used repo
+			return m, m.openRecentSwitcher()
+		case "u":
+			// Toggle the backups taken before destructive actions
+			m.toggleSidePane(undoPane{})
+		case "F":
+			// Toggle the changed files of every repo
+			m.toggleSidePane(allChangesPane{store: m.store, config: m.config})
+		case "W":
+			// Wrap long lines in the diff pane, or cut them off
+			return m, m.toggleWrapDiff()
+		case "v":
+			// View the selected image full size where thumbnails can't be drawn
+			if m.focused == focusFile && m.side == nil {
+				return m, m.viewImage()
+			}
+		case "y", "Y":
+			// Copy the selected repo's HEAD commit hash, short or full
+			if repo := m.selectedRepoPath(); repo != "" {
+				return m, m.copyHead(repo, msg.String() == "Y")
+			}
+		case "n", "N":
+			// Jump to the next/previous repo that is dirty, behind, errored, or alerted about
+			delta := 1
+			if msg.String() == "N" {
+				delta = -1
+			}
+			if !m.jumpToAttention(delta) {
+				return m, m.notify("No repositories need attention", false)
+			}
+		case "J", "K":
+			// Move the selected repository down/up and persist the order
+			if m.focused != focusRepo {
+				break
+			}
+			delta := 1
+			if msg.String() == "K" {
+				delta = -1
+			}
+			return m, m.moveSelectedRepo(delta)
+		case "p":
+			// Fast-forward the selected repository from its upstream
+			if repo := m.selectedRepoPath(); repo != "" {
+				label, done := "Pulling", "Pulled"
+				if status, _ := m.store.Status(repo); status.OffDefault() {
+					label += " " + status.Branch
+					done += " " + status.Branch
+				}
+				return m, m.startAction(repo, "pull", label, done, gitstatus.Pull)
+			}
+		case "P":
+			// Push the selected repository's current branch
+			if repo := m.selectedRepoPath(); repo != "" {
+				m.noteRecent(repo, "pushed")
+				return m, m.startAction(repo, "push", "Pushing", "Pushed", gitstatus.Push)
+			}
+		case "c", "C":
+			// Commit the selected repository's staged changes, or all of
+			// them; C composes a Conventional Commits message
+			status, _ := m.store.Status(m.selectedRepoPath())
+			switch {
+			case len(status.Files) == 0:
+				if status.Path != "" {
+					return m, m.notify("Nothing to commit", false)
+				}
+			case msg.String() == "C" || config.On(m.config.Settings(status.Path).ConventionalCommits):
+				m.openCommitComposer(status.Path)
+			case config.On(m.config.Settings(status.Path).CommitEditor):
+				settings := m.config.Settings(status.Path)
+				opts := gitstatus.CommitOptions{NoVerify: config.On(settings.CommitNoVerify), Signoff: config.On(settings.CommitSignoff), Sign: config.On(settings.CommitSign)}
+				return m, m.editCommitMessage(status.Path, m.commitTemplate(status), opts)
+			default:
+				m.openCommitPrompt(status.Path, m.commitTemplate(status))
+			}
+		case "x":
+			// Discard changes to the selected file after confirmation
+			if m.focused != focusFile || m.side != nil {
+				break
+			}
+			item, ok := m.fileList.SelectedItem().(fileItem)
+			if !ok {
+				break
+			}
+			repo, file := m.selectedRepoPath(), item.gitFile
+			m.confirm("Discard changes?",
+				fmt.Sprintf("Discard all changes to %s in %s? %s", file.Path, filepath.Base(repo), undoHint(repo)),
+				func(m *model) tea.Cmd {
+					err := withBackup(repo, "Discard "+file.Path, file.Paths(), func() error {
+						return gitstatus.DiscardFile(repo, file)
+					})
+					m.refreshRepoStatus(repo)
+					return m.actionResult(repo, fmt.Sprintf("Discarded %s", file.Path), "Discard failed", err)
+				})
+		case "X":
+			// Delete untracked files in the selected repo after confirmation
+			repo := m.selectedRepoPath()
+			if repo == "" {
+				break
+			}
+			m.confirm("Clean untracked files?",
+				fmt.Sprintf("Delete all untracked files and directories in %s? %s", filepath.Base(repo), undoHint(repo)),
+				func(m *model) tea.Cmd {
+					untracked, err := gitstatus.UntrackedPaths(repo)
+					if err != nil {
+						return m.actionResult(repo, "", "Clean failed", err)
+					}
+					if len(untracked) == 0 {
+						return m.notify("No untracked files", false)
+					}
+					err = withBackup(repo, "Clean untracked files", untracked, func() error {
+						return gitstatus.CleanUntracked(repo)
+					})
+					m.refreshRepoStatus(repo)
+					return m.actionResult(repo, "Removed untracked files", "Clean failed", err)
+				})
+		case "r":
+			// Refresh both local status and fetch remote updates
+			m.store.RefreshAll(m.config.Repositories)
+
+			// Also fetch remote updates for all repositories asynchronously
+			if !m.isFetching {
+				return m, m.startFetch(m.config.Repositories)
+			}
+		case "a":
+			// Add a repository by path
+			m.openPrompt(promptOptions{
+				title:       "Add repository",
+				placeholder: "/path/to/repository",
+				historyKey:  "repo-path",
+				complete:    completeDirectory,
+				browse:      (*model).openBrowser,
+				onSubmit: func(m *model, value string) (tea.Cmd, error) {
+					return m.addRepository(value)
+				},
+			})
+		default:
+			// Forward all other key events (e.g. PgUp/PgDn) to the focused pane only
+			return m, m.handleNavigation(msg, &cmds, cmd)
+		}
+	}
+
+	// Only propagate non-key messages to other components to avoid duplicate key handling
+	if _, isKey := msg.(tea.KeyMsg); !isKey {
+		if m.focused != focusRepo {
+			m.repoList, cmd = m.repoList.Update(msg)
+			cmds = append(cmds, cmd)
+		}
+		if m.focused != focusFile {
+			m.fileList, cmd = m.fileList.Update(msg)
+			cmds = append(cmds, cmd)
+		}
+		if m.focused != focusDiff {
+			m.diffView, cmd = m.diffView.Update(msg)
+			cmds = append(cmds, cmd)
+		}
+	}
+
+	return m, tea.Batch(cmds...)
+}
+
+func (m model) View() string {
+	defer crash.Capture()
+	// Guard against rendering before the first WindowSizeMsg arrives.
+	// Without valid dimensions the layout math produces negative widths
+	// and misaligned borders.
+	if m.width == 0 || m.height == 0 {
+		return ""
+	}
+
+	// Without repositories the panes would be empty, so explain how to add
+	// some instead
+	var content string
+	if len(m.config.Repositories) == 0 {
+		content = m.renderOnboarding()
+	} else {
+		content = m.renderPanes()
+	}
+
+	// Show spinner or help text
+	var help string
+	if m.isFetching {
+		spinnerView := m.spinner.View()
+		fetchText := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#737994")).
+			Render(" Fetching remote updates from repositories...")
+		help = spinnerView + fetchText
+	} else if len(m.config.Repositories) > 0 { // the onboarding view lists its own keys
+		helpText := fmt.Sprintf("Press 'r' to refresh, 'm' for activity log, 'q' to quit, Tab to switch panes, ↑↓/PgUp/PgDn to navigate, Enter to open %s", m.config.EnterCommand(m.selectedRepoPath()))
+		if m.config.Accessible {
+			helpText = fmt.Sprintf("Focus: %s. %s", m.focusName(), helpText)
+		}
+		help = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#737994")).
+			Width(m.width).
+			Render(helpText)
+	}
+
+	if m.showActivity {
+		activityStyle := lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			Padding(0, 1).
+			Width(m.width - 2) // full width minus the left and right border
+		if m.focused == focusActivity {
+			activityStyle = activityStyle.BorderForeground(lipgloss.Color("#ca9ee6"))
+		}
+		content = lipgloss.JoinVertical(lipgloss.Left, content, activityStyle.Render(m.activityView.View()))
+	}
+
+	joined := lipgloss.JoinVertical(lipgloss.Left, content, help)
+	// Force the final frame to exactly match the terminal size to prevent scrollback growth
+	frame := lipgloss.Place(m.width, m.height, lipgloss.Left, lipgloss.Top, joined)
+
+	if m.prompt != nil {
+		frame = m.placeCentered(m.prompt.view(), frame)
+	}
+	if m.popup != nil {
+		frame = m.placeCentered(m.popup.view(m.width, m.height), frame)
+	}
+	if m.browser != nil {
+		frame = m.placeCentered(m.renderBrowser(), frame)
+	}
+	if m.switcher != nil {
+		frame = m.placeCentered(m.renderSwitcher(), frame)
+	}
+	if m.dialog != nil {
+		frame = m.placeCentered(m.dialog.view(m.width), frame)
+	}
+
+	// Draw toasts over the top-right corner, inside the diff pane border
+	if toasts := m.renderToasts(); toasts != "" {
+		x := m.width - lipgloss.Width(toasts) - 1
+		frame = placeOverlay(max(x, 0), 1, toasts, frame)
+	}
+	return frame
+}
+
+// renderPanes lays out the repository, file, and diff panes.
+func (m model) renderPanes() string {
+	// Calculate left column width for proper pane sizing
+	leftColumnWidth := int(float64(m.width) * 0.4)
+	rightColumnWidth := m.width - leftColumnWidth - layoutGap
+
+	paneStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		Padding(0, 1).
+		Width(leftColumnWidth)
+
+	focusedStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("#ca9ee6")).
+		Padding(0, 1).
+		Width(leftColumnWidth)
+
+	rightPaneStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		Padding(0, 1).
+		Width(rightColumnWidth)
+
+	// The lower-left pane shows changed files unless a side pane is open
+	lowerView := m.fileList.View()
+	if m.side != nil {
+		lowerView = m.sideList.View()
+	}
+
+	// Apply focused styling to the current pane
+	repoView := m.renderRepoList()
+	var repoPane, filePane, diffPane string
+	if m.focused == focusRepo {
+		repoPane = focusedStyle.Render(repoView)
+		filePane = paneStyle.Render(lowerView)
+		diffPane = rightPaneStyle.Render(m.diffView.View())
+	} else if m.focused == focusFile {
+		repoPane = paneStyle.Render(repoView)
+		filePane = focusedStyle.Render(lowerView)
+		diffPane = rightPaneStyle.Render(m.diffView.View())
+	} else {
+		repoPane = paneStyle.Render(repoView)
+		filePane = paneStyle.Render(lowerView)
+		diffPane = rightPaneStyle.
+			BorderForeground(lipgloss.Color("#ca9ee6")).
+			Render(m.diffView.View())
+	}
+
+	// Create the left column by joining repo and file lists vertically
+	leftColumn := lipgloss.JoinVertical(
+		lipgloss.Left,
+		repoPane,
+		filePane,
+	)
+
+	// Create the right column with the diff view
+	rightColumn := diffPane
+
+	// Join the two columns horizontally
+	return lipgloss.JoinHorizontal(
+		lipgloss.Top,
+		leftColumn,
+		rightColumn,
+	)
+}
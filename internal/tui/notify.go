@@ -0,0 +1,34 @@
+package tui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/cwsaylor/gitmoni/internal/crash"
+	"github.com/cwsaylor/gitmoni/internal/notify"
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// notifyChanges reports what changed in status since the repo's previous
+// status, and the alert rules that started to hold, as configured.
+func (m *model) notifyChanges(status gitstatus.Status) tea.Cmd {
+	changes := m.notifier.Observe(status)
+	changes = append(changes, m.notifier.Alerts(status.Path, m.hooks.AlertsFor(status))...)
+	return m.sendNotifications(changes)
+}
+
+// sendNotifications sends changes in the background.
+func (m *model) sendNotifications(changes []notify.Change) tea.Cmd {
+	changes = m.notifier.Relevant(changes)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	ctx, workers, notifier := m.ctx, m.workers, m.notifier
+	workers.Add(1)
+	return func() tea.Msg {
+		defer workers.Done()
+		defer crash.Capture()
+		notifier.Send(ctx, changes)
+		return nil
+	}
+}
@@ -0,0 +1,247 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/cwsaylor/gitmoni/internal/crash"
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// scanState is a directory scan started from the onboarding view, shown
+// while no repositories are configured.
+type scanState struct {
+	dir    string
+	cancel context.CancelFunc // nil once the scan has finished
+	found  []gitstatus.FoundRepo
+	err    error
+}
+
+// scanDoneMsg delivers the repositories found by a scan.
+type scanDoneMsg struct {
+	dir   string
+	found []gitstatus.FoundRepo
+	err   error
+}
+
+// startScan looks for repositories in dir in the background.
+func (m *model) startScan(dir string) tea.Cmd {
+	if m.scan != nil && m.scan.cancel != nil {
+		m.scan.cancel()
+	}
+	ctx, cancel := context.WithCancel(m.ctx)
+	m.scan = &scanState{dir: dir, cancel: cancel}
+
+	workers := m.workers
+	workers.Add(1)
+	return tea.Batch(m.animate(), func() tea.Msg {
+		defer workers.Done()
+		defer crash.Capture()
+		found, err := gitstatus.Scan(ctx, dir, gitstatus.ScanDepth)
+		return scanDoneMsg{dir: dir, found: found, err: err}
+	})
+}
+
+// finishScan shows the result of a scan, unless it was cancelled or
+// replaced by another one.
+func (m *model) finishScan(msg scanDoneMsg) {
+	if m.scan == nil || m.scan.dir != msg.dir || m.scan.cancel == nil {
+		return
+	}
+	m.scan.cancel()
+	m.scan.cancel = nil
+	m.scan.found, m.scan.err = msg.found, msg.err
+	if msg.err != nil {
+		m.scan.found = nil
+	}
+}
+
+// scanning reports whether a scan is running.
+func (m *model) scanning() bool {
+	return m.scan != nil && m.scan.cancel != nil
+}
+
+// handleOnboardingKey handles the onboarding view's keys. It reports false
+// for keys it doesn't handle, such as a to add a repository.
+func (m *model) handleOnboardingKey(key string) (tea.Cmd, bool) {
+	found := m.scan != nil && !m.scanning() && len(m.scan.found) > 0
+	switch key {
+	case "s":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return m.notify(err.Error(), true), true
+		}
+		return m.startScan(home), true
+	case "c":
+		m.openPrompt(promptOptions{
+			title:       "Scan directory for repositories",
+			placeholder: "~/src",
+			historyKey:  "scan-path",
+			complete:    completeDirectory,
+			onSubmit: func(m *model, value string) (tea.Cmd, error) {
+				dir, err := filepath.Abs(expandHome(strings.TrimSpace(value)))
+				if err != nil {
+					return nil, err
+				}
+				if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+					return nil, fmt.Errorf("not a directory: %s", dir)
+				}
+				return m.startScan(dir), nil
+			},
+		})
+		return nil, true
+	case "esc":
+		if m.scan != nil && m.scan.cancel != nil {
+			m.scan.cancel()
+		}
+		m.scan = nil
+		return nil, true
+	case "enter", "A":
+		if !found {
+			return nil, true
+		}
+		// Nested repositories, such as vendored checkouts and submodule
+		// working copies, are only added when asked for
+		var repos []string
+		for _, r := range m.scan.found {
+			if r.Parent == "" || key == "A" {
+				repos = append(repos, r.Path)
+			}
+		}
+		return m.addScanned(repos), true
+	}
+	return nil, false
+}
+
+// addScanned adds the repositories found by a scan to the config and starts
+// monitoring them.
+func (m *model) addScanned(repos []string) tea.Cmd {
+	var added []string
+	for _, repo := range repos {
+		if m.config.AddRepository(repo) {
+			added = append(added, repo)
+		}
+	}
+	if err := m.config.Save(); err != nil {
+		for _, repo := range added {
+			m.config.RemoveRepository(repo)
+		}
+		return m.actionResult("", "", "Adding repositories failed", err)
+	}
+	m.scan = nil
+
+	m.store.RefreshAll(added)
+	m.updateRepoList()
+	m.selectRepo(0)
+	cmds := []tea.Cmd{
+		m.startFetch(added),
+		m.actionResult("", fmt.Sprintf("Added %d repositories", len(added)), "", nil),
+	}
+	for _, repo := range added {
+		cmds = append(cmds, m.queryBranches(repo))
+	}
+	return tea.Batch(cmds...)
+}
+
+// renderOnboarding renders the view shown while no repositories are
+// configured: how to add them, and the state of a scan.
+func (m *model) renderOnboarding() string {
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#ca9ee6")).Bold(true) // Mauve
+	keyStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#8caaee"))              // Blue
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#737994"))              // Overlay0
+	width := min(76, max(m.width-8, 20))
+
+	lines := []string{
+		titleStyle.Render("Welcome to GitMoni"),
+		"",
+		"No repositories are being monitored yet. Add some to see their",
+		"changes, branches, and upstream status at a glance.",
+		"",
+	}
+	key := func(k, what string) string { return "  " + keyStyle.Render(fmt.Sprintf("%-7s", k)) + what }
+
+	if m.scan != nil && m.scan.err != nil {
+		lines = append(lines, lipgloss.NewStyle().Foreground(lipgloss.Color("#e78284")).Render("Scan failed: "+m.scan.err.Error()), "") // Red
+	} else if m.scan != nil && !m.scanning() && len(m.scan.found) == 0 {
+		lines = append(lines, fmt.Sprintf("No repositories found in %s.", displayPath(m.scan.dir)), "")
+	}
+
+	switch {
+	case m.scanning():
+		lines = append(lines,
+			fmt.Sprintf("%s Scanning %s for repositories...", m.spinner.View(), displayPath(m.scan.dir)),
+			"",
+			key("Esc", "stop scanning"))
+	case m.scan != nil && len(m.scan.found) > 0:
+		var top, nested []string
+		for _, r := range m.scan.found {
+			rel, err := filepath.Rel(m.scan.dir, r.Path)
+			if err != nil || rel == "." {
+				rel = r.Path
+			}
+			if r.Parent == "" {
+				top = append(top, rel)
+			} else if r.Submodule {
+				nested = append(nested, rel+" (submodule)")
+			} else {
+				nested = append(nested, rel)
+			}
+		}
+		lines = append(lines, fmt.Sprintf("Found %s in %s:", countRepos(len(top)), displayPath(m.scan.dir)))
+		lines = append(lines, listSome(top, 8)...)
+		if len(nested) > 0 {
+			lines = append(lines, "", fmt.Sprintf("and %s nested inside them:", countRepos(len(nested))))
+			lines = append(lines, listSome(nested, 4)...)
+		}
+		lines = append(lines, "", key("Enter", "add "+countRepos(len(top))))
+		if len(nested) > 0 {
+			lines = append(lines, key("A", "add the nested repositories too"))
+		}
+		lines = append(lines, key("Esc", "discard"))
+	default:
+		lines = append(lines,
+			key("s", "scan your home directory for repositories"),
+			key("c", "choose a directory to scan"),
+			key("a", "add a single repository by path"),
+			key("q", "quit"),
+			"",
+			dimStyle.Render("From a shell: gitmoni -a /path/to/repo, or gitmoni scan ~/src."),
+			dimStyle.Render("Repositories are saved in ~/.gitmoni.json."))
+	}
+
+	box := modalStyle().Width(width).Render(strings.Join(lines, "\n"))
+	return lipgloss.Place(m.width, m.height-1, lipgloss.Center, lipgloss.Center, box)
+}
+
+// listSome renders up to limit items as an indented list, summing up the
+// rest.
+func listSome(items []string, limit int) []string {
+	var lines []string
+	for i, item := range items {
+		if i == limit {
+			lines = append(lines, fmt.Sprintf("    ... and %d more", len(items)-limit))
+			break
+		}
+		lines = append(lines, "    "+item)
+	}
+	return lines
+}
+
+// displayPath shortens paths in the home directory to ~/...
+func displayPath(path string) string {
+	if home, err := os.UserHomeDir(); err == nil {
+		if path == home {
+			return "~"
+		}
+		if rest, ok := strings.CutPrefix(path, home+string(filepath.Separator)); ok {
+			return "~/" + rest
+		}
+	}
+	return path
+}
@@ -0,0 +1,102 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// sidePane is an alternative view shown in place of the changed-files list,
+// such as the commit log. Its items are loaded for the selected repo and the
+// selected item's details are shown in the diff pane.
+type sidePane interface {
+	// title is shown above the list and identifies the pane when toggling.
+	title() string
+	// load returns the pane's items for repo.
+	load(repo string) ([]list.Item, error)
+	// detail returns the diff pane content for the selected item, already
+	// highlighted where appropriate.
+	detail(repo string, item list.Item) string
+}
+
+// sidePaneActions is implemented by side panes that offer actions on their
+// selected item.
+type sidePaneActions interface {
+	// handleKey runs the action bound to key, if any, and reports whether
+	// the key was consumed. item is nil when the list is empty.
+	handleKey(m *model, repo string, item list.Item, key string) (tea.Cmd, bool)
+}
+
+// handleSidePaneKey offers key to the active side pane's actions.
+func (m *model) handleSidePaneKey(key string) (tea.Cmd, bool) {
+	actions, ok := m.side.(sidePaneActions)
+	repo := m.selectedRepoPath()
+	if !ok || repo == "" {
+		return nil, false
+	}
+	return actions.handleKey(m, repo, m.sideList.SelectedItem(), key)
+}
+
+// toggleSidePane opens p in the lower-left pane, or returns to the file list
+// if p is already open.
+func (m *model) toggleSidePane(p sidePane) {
+	if m.side != nil && m.side.title() == p.title() {
+		m.closeSidePane()
+		return
+	}
+	m.side = p
+	m.sideList.Title = p.title()
+	m.sideList.ResetFilter()
+	m.focused = focusFile
+	m.closeDiffModes()
+	m.loadSidePane(false)
+}
+
+// closeSidePane returns the lower-left pane to the changed-files list.
+func (m *model) closeSidePane() {
+	m.side = nil
+	m.sideList.SetItems(nil)
+	m.syncLowerPane(false)
+}
+
+// loadSidePane reloads the active side pane for the selected repo.
+func (m *model) loadSidePane(keepCursor bool) {
+	repo := m.selectedRepoPath()
+	if repo == "" {
+		m.sideList.SetItems(nil)
+		m.setDiffContent("")
+		return
+	}
+
+	index := m.sideList.Index()
+	items, err := m.side.load(repo)
+	m.sideList.SetItems(items)
+	if err != nil {
+		m.setDiffContent(fmt.Sprintf("Error loading %s: %s", m.side.title(), gitstatus.ErrorSummary(err)))
+		return
+	}
+	if keepCursor && len(items) > 0 {
+		m.sideList.Select(min(index, len(items)-1))
+	} else {
+		m.sideList.Select(0)
+	}
+	m.updateSideDetail()
+}
+
+// updateSideDetail shows the selected side pane item's details in the diff pane.
+// An open commit graph or README is redrawn instead.
+func (m *model) updateSideDetail() {
+	m.blame = nil
+	if m.updateDiffMode() {
+		return
+	}
+	item := m.sideList.SelectedItem()
+	if item == nil {
+		m.setDiffContent("")
+		return
+	}
+	m.setDiffContent(m.side.detail(m.selectedRepoPath(), item))
+}
@@ -0,0 +1,76 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// expandHome replaces a leading "~" with the user's home directory.
+func expandHome(path string) string {
+	if path == "~" {
+		return os.Getenv("HOME")
+	}
+	if strings.HasPrefix(path, "~/") {
+		return filepath.Join(os.Getenv("HOME"), path[2:])
+	}
+	return path
+}
+
+// completeDirectory completes the last path element of value against the
+// directories on disk. A unique match gets a trailing separator so the next
+// Tab descends into it; multiple matches complete to their common prefix.
+func completeDirectory(value string) string {
+	if value == "~" {
+		return "~" + string(filepath.Separator)
+	}
+	expanded := expandHome(value)
+	dir, prefix := filepath.Split(expanded)
+	if dir == "" {
+		dir = "."
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return value
+	}
+
+	var matches []string
+	for _, e := range entries {
+		if !e.IsDir() || !strings.HasPrefix(e.Name(), prefix) {
+			continue
+		}
+		// Hide dot-directories unless the user started typing one
+		if strings.HasPrefix(e.Name(), ".") && !strings.HasPrefix(prefix, ".") {
+			continue
+		}
+		matches = append(matches, e.Name())
+	}
+	if len(matches) == 0 {
+		return value
+	}
+
+	completed := matches[0]
+	if len(matches) == 1 {
+		completed += string(filepath.Separator)
+	} else {
+		for _, match := range matches[1:] {
+			completed = commonPrefix(completed, match)
+		}
+	}
+
+	// Preserve the user's "~" rather than expanding it in the input
+	base := value[:len(value)-len(prefix)]
+	return base + completed
+}
+
+// commonPrefix returns the longest common prefix of a and b.
+func commonPrefix(a, b string) string {
+	n := min(len(a), len(b))
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return a[:i]
+		}
+	}
+	return a[:n]
+}
@@ -0,0 +1,175 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/cwsaylor/gitmoni/internal/crash"
+	"github.com/cwsaylor/gitmoni/internal/plugins"
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// pluginsLoadedMsg is sent once the plugins directory has been scanned.
+type pluginsLoadedMsg struct {
+	plugins []plugins.Plugin
+	err     error
+}
+
+// pluginBadgesMsg carries the plugin badges for a repo, in plugin order.
+type pluginBadgesMsg struct {
+	repo   string
+	badges []plugins.Badge
+}
+
+// loadPlugins discovers plugins in the background.
+func (m *model) loadPlugins() tea.Cmd {
+	ctx, workers := m.ctx, m.workers
+	workers.Add(1)
+	return func() tea.Msg {
+		defer workers.Done()
+		defer crash.Capture()
+		found, err := plugins.Discover(ctx, plugins.Dir())
+		return pluginsLoadedMsg{plugins: found, err: err}
+	}
+}
+
+// queryPlugins asks every plugin for repo's badge in the background. It
+// returns nil if there are no plugins, repo is on another host, where they
+// can't run, or a query for repo is in flight.
+func (m *model) queryPlugins(repo string) tea.Cmd {
+	if len(m.plugins) == 0 || gitstatus.IsSSH(repo) || m.pluginQueries[repo] {
+		return nil
+	}
+	m.pluginQueries[repo] = true
+
+	ctx, workers, found := m.ctx, m.workers, m.plugins
+	workers.Add(1)
+	return func() tea.Msg {
+		defer workers.Done()
+		defer crash.Capture()
+		badges := make([]plugins.Badge, 0, len(found))
+		for _, p := range found {
+			badge, err := p.Status(ctx, repo)
+			if err != nil {
+				badge = plugins.Badge{Text: p.Name + " failed", Level: "error", Detail: err.Error()}
+			}
+			badges = append(badges, badge)
+		}
+		return pluginBadgesMsg{repo: repo, badges: badges}
+	}
+}
+
+// badge is a short status label shown after a repo's name.
+type badge struct {
+	text  string
+	level string // "ok", "info", "warn", or "error"; picks the colour
+}
+
+// renderBadges formats badges for a repo title, coloured by level. The
+// level is also spelled out when accessible is set, unless it is "info".
+func renderBadges(badges []badge, accessible bool) string {
+	var parts []string
+	for _, b := range badges {
+		if b.text == "" {
+			continue
+		}
+		color := "#a5adce" // Subtext0
+		switch b.level {
+		case "ok":
+			color = "#a6d189" // Green
+		case "warn":
+			color = "#e5c890" // Yellow
+		case "error":
+			color = "#e78284" // Red
+		}
+		text := b.text
+		if accessible && b.level != "" && b.level != "info" {
+			text = b.level + ": " + text
+		}
+		parts = append(parts, lipgloss.NewStyle().Foreground(lipgloss.Color(color)).Render("["+text+"]"))
+	}
+	return strings.Join(parts, " ")
+}
+
+type pluginActionItem struct {
+	plugin plugins.Plugin
+	action plugins.Action
+}
+
+func (i pluginActionItem) FilterValue() string { return i.action.Title }
+
+func (i pluginActionItem) Title() string {
+	title := i.action.Title
+	if title == "" {
+		title = i.action.ID
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("#8caaee")).Render(title) // Blue
+}
+
+func (i pluginActionItem) Description() string { return i.plugin.Name }
+
+// pluginActionsPane lists the actions offered by plugins; Enter runs the
+// selected one on the repo.
+type pluginActionsPane struct {
+	plugins []plugins.Plugin
+	badges  map[string][]plugins.Badge
+}
+
+func (pluginActionsPane) title() string { return "Plugin actions" }
+
+func (p pluginActionsPane) load(repo string) ([]list.Item, error) {
+	if gitstatus.IsSSH(repo) {
+		return nil, errors.New("plugins can't run for repositories on another host")
+	}
+	var items []list.Item
+	for _, plugin := range p.plugins {
+		for _, action := range plugin.Actions {
+			items = append(items, pluginActionItem{plugin: plugin, action: action})
+		}
+	}
+	return items, nil
+}
+
+func (p pluginActionsPane) detail(repo string, item list.Item) string {
+	selected := item.(pluginActionItem)
+	var b strings.Builder
+	fmt.Fprintf(&b, "Press Enter to run %q from %s.\n", selected.action.Title, selected.plugin.Name)
+
+	// Show what each plugin currently reports for the repo
+	for i, badge := range p.badges[repo] {
+		if i >= len(p.plugins) || (badge.Text == "" && badge.Detail == "") {
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s: %s\n", p.plugins[i].Name, badge.Text)
+		if badge.Detail != "" {
+			fmt.Fprintf(&b, "%s\n", badge.Detail)
+		}
+	}
+	return b.String()
+}
+
+func (pluginActionsPane) handleKey(m *model, repo string, item list.Item, key string) (tea.Cmd, bool) {
+	if item == nil || key != "enter" {
+		return nil, false
+	}
+	selected := item.(pluginActionItem)
+	title := selected.action.Title
+	if title == "" {
+		title = selected.action.ID
+	}
+	store := m.store
+	return m.startOutputTask(repo, "plugin", "Running "+title, title, func(ctx context.Context) (string, error) {
+		output, err := selected.plugin.Run(ctx, selected.action.ID, repo)
+		if ctx.Err() == nil {
+			// The action may have changed the repo
+			store.Refresh(repo)
+		}
+		return output, err
+	}), true
+}
@@ -0,0 +1,42 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// toggleReadme switches the diff pane between the current diff and the
+// selected repo's README. Like the commit graph, it stays open while moving
+// between repos, and closes when a file or side pane item is selected.
+func (m *model) toggleReadme() {
+	if m.readme {
+		m.readme = false
+		m.syncLowerPane(true)
+		return
+	}
+	m.blame = nil
+	m.graph = false
+	m.readme = true
+	m.focused = focusDiff
+	m.updateReadme()
+}
+
+// updateReadme shows the selected repo's README in the diff pane, rendered
+// to fit its width.
+func (m *model) updateReadme() {
+	repo := m.selectedRepoPath()
+	if repo == "" {
+		m.setDiffContent("")
+		return
+	}
+	name, content, err := gitstatus.Readme(repo)
+	switch {
+	case err != nil:
+		m.setDiffContent(fmt.Sprintf("Error loading README: %s", gitstatus.ErrorSummary(err)))
+	case name == "":
+		m.setDiffContent("No README in this repository")
+	default:
+		m.setDiffContent(renderMarkdown(content, m.diffView.Width))
+	}
+}
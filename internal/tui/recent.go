@@ -0,0 +1,176 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxRecent is how many repositories the recent list remembers.
+const maxRecent = 10
+
+// recentUse is the last time a repository was worked with from gitmoni.
+type recentUse struct {
+	Repo   string    `json:"repo"`
+	Action string    `json:"action"` // e.g. "opened", "committed", "pushed"
+	Time   time.Time `json:"time"`
+}
+
+// recentRepos lists the repositories most recently opened, committed, or
+// pushed from gitmoni, most recent first, in a file that outlasts the
+// session.
+type recentRepos struct {
+	path string // "" to keep the list in memory only
+	uses []recentUse
+}
+
+// defaultRecentPath returns gitmoni/recent.json in the user's cache
+// directory.
+func defaultRecentPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gitmoni", "recent.json"), nil
+}
+
+// loadRecentRepos reads the list kept in the file at path.
+func loadRecentRepos(path string) *recentRepos {
+	r := &recentRepos{path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return r
+	}
+	if err := json.Unmarshal(data, &r.uses); err != nil {
+		slog.Warn("ignoring invalid recent repositories", "path", path, "err", err)
+	}
+	return r
+}
+
+// touch moves repo to the top of the list and saves it.
+func (r *recentRepos) touch(repo, action string) {
+	r.uses = slices.DeleteFunc(r.uses, func(u recentUse) bool { return u.Repo == repo })
+	r.uses = slices.Insert(r.uses, 0, recentUse{Repo: repo, Action: action, Time: time.Now()})
+	r.uses = r.uses[:min(len(r.uses), maxRecent)]
+	if r.path == "" {
+		return
+	}
+	data, err := json.MarshalIndent(r.uses, "", "  ")
+	if err == nil {
+		if err = os.MkdirAll(filepath.Dir(r.path), 0o755); err == nil {
+			err = os.WriteFile(r.path, data, 0o644)
+		}
+	}
+	if err != nil {
+		slog.Warn("saving recent repositories failed", "path", r.path, "err", err)
+	}
+}
+
+// forget removes repo from the list, e.g. when it is no longer monitored.
+func (r *recentRepos) forget(repo string) {
+	r.uses = slices.DeleteFunc(r.uses, func(u recentUse) bool { return u.Repo == repo })
+}
+
+// noteRecent records that repo was worked with.
+func (m *model) noteRecent(repo, action string) {
+	m.recent.touch(repo, action)
+}
+
+// recentSwitcher is a modal listing the recent repositories, for jumping
+// between the few being worked on. While it is open it receives all key
+// events.
+type recentSwitcher struct {
+	uses   []recentUse
+	cursor int
+}
+
+// openRecentSwitcher lists the recent repositories that are still
+// monitored. The cursor starts on the one used before the selected one, so
+// Ctrl+P then Enter goes back and forth between two.
+func (m *model) openRecentSwitcher() tea.Cmd {
+	var uses []recentUse
+	for _, u := range m.recent.uses {
+		if slices.Contains(m.config.Repositories, u.Repo) {
+			uses = append(uses, u)
+		}
+	}
+	if len(uses) == 0 {
+		return m.notify("No recent repositories yet: open, commit, or push one", false)
+	}
+	s := &recentSwitcher{uses: uses}
+	if len(uses) > 1 && uses[0].Repo == m.selectedRepoPath() {
+		s.cursor = 1
+	}
+	m.switcher = s
+	return nil
+}
+
+// handleSwitcherKey processes a key event while the recent switcher is
+// open. Ctrl+P moves down as well, so holding Ctrl and tapping P walks the
+// list.
+func (m *model) handleSwitcherKey(msg tea.KeyMsg) tea.Cmd {
+	s := m.switcher
+	switch key := msg.String(); key {
+	case "esc", "q":
+		m.switcher = nil
+	case "ctrl+c":
+		return tea.Quit
+	case "up", "k", "shift+tab":
+		s.cursor = (s.cursor - 1 + len(s.uses)) % len(s.uses)
+	case "down", "j", "ctrl+p", "tab":
+		s.cursor = (s.cursor + 1) % len(s.uses)
+	case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		if i := int(key[0] - '1'); i < len(s.uses) {
+			s.cursor = i
+			return m.switchToRecent()
+		}
+	case "enter":
+		return m.switchToRecent()
+	}
+	return nil
+}
+
+// switchToRecent selects the repository under the switcher's cursor.
+func (m *model) switchToRecent() tea.Cmd {
+	repo := m.switcher.uses[m.switcher.cursor].Repo
+	m.switcher = nil
+	for i, item := range m.repoList.Items() {
+		if item.(repoItem).path == repo {
+			m.focused = focusRepo
+			m.selectRepo(i)
+			return nil
+		}
+	}
+	return m.notify(filepath.Base(repo)+" is hidden by the filter", true)
+}
+
+// renderSwitcher renders the switcher box.
+func (m *model) renderSwitcher() string {
+	s := m.switcher
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#ca9ee6")).Bold(true)  // Mauve
+	hintStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#737994"))              // Overlay0
+	cursorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#8caaee")).Bold(true) // Blue
+
+	parts := []string{titleStyle.Render("Recent repositories"), ""}
+	for i, u := range s.uses {
+		name := u.Repo
+		if !m.config.DisplayFullPath {
+			name = filepath.Base(name)
+		}
+		row := fmt.Sprintf("%d %s", i+1, name) + hintStyle.Render(fmt.Sprintf("  %s %s", u.Action, formatAge(u.Time)))
+		if i == s.cursor {
+			parts = append(parts, cursorStyle.Render("> ")+row)
+		} else {
+			parts = append(parts, "  "+row)
+		}
+	}
+	parts = append(parts, "", hintStyle.Render("Enter or 1-9 to switch • ↑/↓ or Ctrl+P to move • Esc to close"))
+	return modalStyle().Render(lipgloss.JoinVertical(lipgloss.Left, parts...))
+}
@@ -0,0 +1,102 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// reflogLimit is how many reflog entries the reflog pane loads.
+const reflogLimit = 200
+
+type reflogItem struct {
+	entry gitstatus.ReflogEntry
+}
+
+func (i reflogItem) FilterValue() string { return i.entry.Subject }
+
+func (i reflogItem) Title() string {
+	hash := lipgloss.NewStyle().Foreground(lipgloss.Color("#e5c890")).Render(i.entry.Short) // Yellow
+	return hash + " " + i.entry.Subject
+}
+
+func (i reflogItem) Description() string {
+	return fmt.Sprintf("%s • %s", i.entry.Selector, formatAge(i.entry.Date))
+}
+
+// reflogPane lists recent HEAD movements and offers ways to recover a lost
+// state: check it out, branch from it, or reset the current branch to it.
+type reflogPane struct{}
+
+func (reflogPane) title() string { return "Reflog" }
+
+func (reflogPane) load(repo string) ([]list.Item, error) {
+	entries, err := gitstatus.Reflog(repo, reflogLimit)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]list.Item, 0, len(entries))
+	for _, e := range entries {
+		items = append(items, reflogItem{entry: e})
+	}
+	return items, nil
+}
+
+func (reflogPane) detail(repo string, item list.Item) string {
+	return commitDetail(repo, item.(reflogItem).entry.Hash)
+}
+
+func (reflogPane) handleKey(m *model, repo string, item list.Item, key string) (tea.Cmd, bool) {
+	if item == nil {
+		return nil, false
+	}
+	entry := item.(reflogItem).entry
+	switch key {
+	case "o":
+		// Check out the entry with a detached HEAD
+		err := gitstatus.CheckoutDetached(repo, entry.Hash)
+		m.refreshRepoStatus(repo)
+		return m.actionResult(repo, fmt.Sprintf("Checked out %s (%s)", entry.Short, entry.Selector), "Checkout failed", err), true
+	case "b":
+		// Create and check out a branch at the entry
+		m.openPrompt(promptOptions{
+			title:       fmt.Sprintf("New branch at %s (%s)", entry.Short, entry.Selector),
+			placeholder: "branch name",
+			historyKey:  "branch",
+			onSubmit: func(m *model, name string) (tea.Cmd, error) {
+				name = strings.TrimSpace(name)
+				if name == "" {
+					return nil, errors.New("branch name is required")
+				}
+				if err := gitstatus.CreateBranch(repo, name, entry.Hash); err != nil {
+					return nil, errors.New(gitstatus.ErrorSummary(err))
+				}
+				m.refreshRepoStatus(repo)
+				return m.actionResult(repo, fmt.Sprintf("Created branch %s at %s", name, entry.Short), "", nil), nil
+			},
+		})
+		return nil, true
+	case "R":
+		// Reset the current branch to the entry after confirmation
+		m.confirm("Reset to reflog entry?",
+			fmt.Sprintf("Reset the current branch of %s to %s (%s)? Uncommitted changes will be lost. %s",
+				filepath.Base(repo), entry.Short, entry.Selector, undoHint(repo)),
+			func(m *model) tea.Cmd {
+				action := fmt.Sprintf("Reset to %s (%s)", entry.Short, entry.Selector)
+				err := withBackup(repo, action, nil, func() error {
+					return gitstatus.ResetHard(repo, entry.Hash)
+				})
+				m.refreshRepoStatus(repo)
+				return m.actionResult(repo, fmt.Sprintf("Reset to %s (%s)", entry.Short, entry.Selector), "Reset failed", err)
+			})
+		return nil, true
+	}
+	return nil, false
+}
@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// renderCache holds the last rendering of a pane with the state it was
+// rendered from. The model is copied on every update, so it is shared by
+// pointer.
+type renderCache struct {
+	key  renderKey
+	view string
+}
+
+// renderKey is everything a list's rendering depends on.
+type renderKey struct {
+	version       int
+	width, height int
+	index, page   int
+	filterState   list.FilterState
+	filterValue   string
+	fullHelp      bool
+}
+
+// renderRepoList returns the repo list's view, reusing the previous
+// rendering when nothing visible has changed. Spinner ticks arrive many
+// times a second, and with hundreds of repositories most of them only
+// animate rows that are off screen.
+func (m model) renderRepoList() string {
+	key := renderKey{
+		version:     m.repoVersion,
+		width:       m.repoList.Width(),
+		height:      m.repoList.Height(),
+		index:       m.repoList.Index(),
+		page:        m.repoList.Paginator.Page,
+		filterState: m.repoList.FilterState(),
+		filterValue: m.repoList.FilterValue(),
+		fullHelp:    m.repoList.Help.ShowAll,
+	}
+	// The filter input's cursor blinks, so don't cache while typing
+	if m.repoPane.view == "" || m.repoPane.key != key || key.filterState == list.Filtering {
+		m.repoPane.key = key
+		m.repoPane.view = m.repoList.View()
+	}
+	return m.repoPane.view
+}
+
+// visibleItems returns the items on the list's current page, the only ones
+// its view renders.
+func visibleItems(l list.Model) []list.Item {
+	items := l.VisibleItems()
+	start, end := l.Paginator.GetSliceBounds(len(items))
+	return items[start:end]
+}
@@ -0,0 +1,41 @@
+package tui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// fetchTickMsg asks which repos are due to be fetched automatically.
+type fetchTickMsg struct{}
+
+// scheduleFetches returns the command sending the next fetchTickMsg.
+func scheduleFetches() tea.Cmd {
+	return tea.Tick(gitstatus.SchedulerTick, func(time.Time) tea.Msg { return fetchTickMsg{} })
+}
+
+// fetchDue starts fetching the repos whose fetch_interval has passed,
+// unless they are in their quiet hours, and schedules the next check.
+func (m *model) fetchDue() tea.Cmd {
+	due := m.scheduler.Due(m.config.Repositories, time.Now())
+	if len(due) == 0 {
+		return scheduleFetches()
+	}
+	return tea.Batch(m.startFetch(m.autoFetchable(due)), scheduleFetches())
+}
+
+// notQuiet returns the repos that aren't in their quiet hours.
+func (m *model) notQuiet(repos []string) []string {
+	var awake []string
+	now := time.Now()
+	for _, repo := range repos {
+		if m.scheduler.Quiet(repo, now) {
+			m.activity.add(repo, "Skipped fetch (quiet hours)")
+			continue
+		}
+		awake = append(awake, repo)
+	}
+	return awake
+}
@@ -0,0 +1,102 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+type stashItem struct {
+	entry gitstatus.StashEntry
+}
+
+func (i stashItem) FilterValue() string { return i.entry.Subject }
+
+func (i stashItem) Title() string {
+	ref := lipgloss.NewStyle().Foreground(lipgloss.Color("#e5c890")).Render(i.entry.Ref) // Yellow
+	return ref + " " + i.entry.Subject
+}
+
+func (i stashItem) Description() string {
+	return formatAge(i.entry.Date)
+}
+
+// stashPane lists a repo's stash entries with a preview of each entry's
+// diff, so it can be inspected before being applied or dropped.
+type stashPane struct{}
+
+func (stashPane) title() string { return "Stash" }
+
+func (stashPane) load(repo string) ([]list.Item, error) {
+	entries, err := gitstatus.Stashes(repo)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]list.Item, 0, len(entries))
+	for _, e := range entries {
+		items = append(items, stashItem{entry: e})
+	}
+	return items, nil
+}
+
+func (stashPane) detail(repo string, item list.Item) string {
+	entry := item.(stashItem).entry
+	diff, err := gitstatus.StashDiff(repo, entry.Ref)
+	if err != nil {
+		return fmt.Sprintf("Error loading %s: %s", entry.Ref, gitstatus.ErrorSummary(err))
+	}
+	return applySyntaxHighlighting(diff, "")
+}
+
+func (stashPane) handleKey(m *model, repo string, item list.Item, key string) (tea.Cmd, bool) {
+	if key == "c" {
+		// Stash all local changes
+		m.openPrompt(promptOptions{
+			title:       fmt.Sprintf("Stash changes in %s", filepath.Base(repo)),
+			placeholder: "message (optional)",
+			historyKey:  "stash",
+			onSubmit: func(m *model, message string) (tea.Cmd, error) {
+				err := gitstatus.StashPush(repo, strings.TrimSpace(message))
+				m.refreshRepoStatus(repo)
+				return m.actionResult(repo, "Stashed changes", "Stash failed", err), nil
+			},
+		})
+		return nil, true
+	}
+	if item == nil {
+		// Without an entry the pane's own keys do nothing, rather than
+		// falling through to pull or add a repository
+		return nil, key == "a" || key == "p" || key == "d"
+	}
+
+	entry := item.(stashItem).entry
+	switch key {
+	case "a", "p":
+		// Apply the entry, or pop it to also remove it from the stash
+		pop := key == "p"
+		err := gitstatus.StashApply(repo, entry.Ref, pop)
+		m.refreshRepoStatus(repo)
+		success := "Applied " + entry.Ref
+		if pop {
+			success = "Popped " + entry.Ref
+		}
+		return m.actionResult(repo, success, "Stash apply failed", err), true
+	case "d":
+		// Drop the entry after confirmation
+		m.confirm("Drop stash?",
+			fmt.Sprintf("Drop %s (%s) in %s? This cannot be undone.", entry.Ref, entry.Subject, filepath.Base(repo)),
+			func(m *model) tea.Cmd {
+				err := gitstatus.StashDrop(repo, entry.Ref)
+				m.refreshRepoStatus(repo)
+				return m.actionResult(repo, "Dropped "+entry.Ref, "Stash drop failed", err)
+			})
+		return nil, true
+	}
+	return nil, false
+}
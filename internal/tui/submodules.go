@@ -0,0 +1,111 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+type submoduleItem struct {
+	submodule gitstatus.Submodule
+}
+
+func (i submoduleItem) FilterValue() string { return i.submodule.Path }
+
+func (i submoduleItem) Title() string {
+	s := i.submodule
+	color := lipgloss.Color("#a6d189") // Green
+	switch {
+	case !s.Initialized:
+		color = lipgloss.Color("#737994") // Overlay0
+	case s.Conflict:
+		color = lipgloss.Color("#e78284") // Red
+	case s.OutOfDate() || s.Changes > 0:
+		color = lipgloss.Color("#e5c890") // Yellow
+	}
+	return lipgloss.NewStyle().Foreground(color).Render(s.Path)
+}
+
+func (i submoduleItem) Description() string {
+	s := i.submodule
+	if !s.Initialized {
+		return fmt.Sprintf("pinned %s • not initialized", shortHash(s.Pinned))
+	}
+	var parts []string
+	if s.OutOfDate() {
+		parts = append(parts, fmt.Sprintf("pinned %s • checked out %s", shortHash(s.Pinned), shortHash(s.Checkout)))
+	} else {
+		parts = append(parts, "at "+shortHash(s.Checkout))
+	}
+	if s.Conflict {
+		parts = append(parts, "conflict")
+	}
+	switch {
+	case s.Changes == 1:
+		parts = append(parts, "1 changed file")
+	case s.Changes > 1:
+		parts = append(parts, fmt.Sprintf("%d changed files", s.Changes))
+	}
+	return strings.Join(parts, " • ")
+}
+
+// submodulesPane lists a repo's submodules and updates them to their
+// pinned commits.
+type submodulesPane struct{}
+
+func (submodulesPane) title() string { return "Submodules" }
+
+func (submodulesPane) load(repo string) ([]list.Item, error) {
+	submodules, err := gitstatus.Submodules(repo)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]list.Item, 0, len(submodules))
+	for _, s := range submodules {
+		items = append(items, submoduleItem{submodule: s})
+	}
+	return items, nil
+}
+
+func (submodulesPane) detail(repo string, item list.Item) string {
+	s := item.(submoduleItem).submodule
+	if !s.Initialized {
+		return fmt.Sprintf("%s is not initialized.\n\nPress u to check out its pinned commit %s.", s.Path, shortHash(s.Pinned))
+	}
+	// Shows the commits between the pinned and checked-out commits, plus
+	// any uncommitted changes inside the submodule
+	output, err := gitstatus.Run(repo, "diff", "--submodule=log", "--", s.Path)
+	if err != nil {
+		return fmt.Sprintf("Error getting diff of %s: %s", s.Path, gitstatus.ErrorSummary(err))
+	}
+	if len(output) == 0 {
+		return fmt.Sprintf("%s is checked out at its pinned commit %s with no local changes.", s.Path, shortHash(s.Pinned))
+	}
+	return applySyntaxHighlighting(string(output), "")
+}
+
+func (submodulesPane) handleKey(m *model, repo string, item list.Item, key string) (tea.Cmd, bool) {
+	switch key {
+	case "u":
+		// Update the selected submodule to its pinned commit
+		if item == nil {
+			return nil, true
+		}
+		path := item.(submoduleItem).submodule.Path
+		return m.startAction(repo, "submodule update", "Updating "+path, "Updated "+path, func(ctx context.Context, repo string) error {
+			return gitstatus.UpdateSubmodules(ctx, repo, path)
+		}), true
+	case "U":
+		// Update all submodules to their pinned commits
+		return m.startAction(repo, "submodule update", "Updating submodules", "Updated submodules", func(ctx context.Context, repo string) error {
+			return gitstatus.UpdateSubmodules(ctx, repo)
+		}), true
+	}
+	return nil, false
+}
@@ -0,0 +1,111 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+type tagItem struct {
+	tag gitstatus.Tag
+}
+
+func (i tagItem) FilterValue() string { return i.tag.Name }
+
+func (i tagItem) Title() string {
+	name := lipgloss.NewStyle().Foreground(lipgloss.Color("#e5c890")).Render(i.tag.Name) // Yellow
+	return name + " " + i.tag.Subject
+}
+
+func (i tagItem) Description() string {
+	kind := "lightweight"
+	if i.tag.Annotated {
+		kind = "annotated"
+	}
+	return fmt.Sprintf("%s • %s • %s", i.tag.Short, kind, formatAge(i.tag.Date))
+}
+
+// tagsPane lists a repo's tags and lets them be created, deleted, and pushed.
+type tagsPane struct{}
+
+func (tagsPane) title() string { return "Tags" }
+
+func (tagsPane) load(repo string) ([]list.Item, error) {
+	tags, err := gitstatus.Tags(repo)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]list.Item, 0, len(tags))
+	for _, t := range tags {
+		items = append(items, tagItem{tag: t})
+	}
+	return items, nil
+}
+
+func (tagsPane) detail(repo string, item list.Item) string {
+	return commitDetail(repo, "refs/tags/"+item.(tagItem).tag.Name)
+}
+
+func (tagsPane) handleKey(m *model, repo string, item list.Item, key string) (tea.Cmd, bool) {
+	switch key {
+	case "c":
+		m.promptNewTag(repo)
+		return nil, true
+	case "d":
+		// Delete the local tag after confirmation
+		if item == nil {
+			return nil, true
+		}
+		tag := item.(tagItem).tag
+		m.confirm("Delete tag?",
+			fmt.Sprintf("Delete tag %s in %s? The tag is only deleted locally.", tag.Name, filepath.Base(repo)),
+			func(m *model) tea.Cmd {
+				err := gitstatus.DeleteTag(repo, tag.Name)
+				m.refreshRepoStatus(repo)
+				return m.actionResult(repo, fmt.Sprintf("Deleted tag %s", tag.Name), "Delete tag failed", err)
+			})
+		return nil, true
+	case "P":
+		return m.startAction(repo, "push tags", "Pushing tags", "Pushed tags", gitstatus.PushTags), true
+	}
+	return nil, false
+}
+
+// promptNewTag asks for a tag name and then its message, and creates an
+// annotated tag at HEAD.
+func (m *model) promptNewTag(repo string) {
+	m.openPrompt(promptOptions{
+		title:       fmt.Sprintf("New tag at HEAD of %s", filepath.Base(repo)),
+		placeholder: "tag name, e.g. v1.2.0",
+		historyKey:  "tag",
+		onSubmit: func(m *model, name string) (tea.Cmd, error) {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				return nil, errors.New("tag name is required")
+			}
+			m.openPrompt(promptOptions{
+				title:       fmt.Sprintf("Message for tag %s", name),
+				placeholder: "Release " + name,
+				multiline:   true,
+				onSubmit: func(m *model, message string) (tea.Cmd, error) {
+					if strings.TrimSpace(message) == "" {
+						message = "Release " + name
+					}
+					if err := gitstatus.CreateTag(repo, name, message); err != nil {
+						return nil, errors.New(gitstatus.ErrorSummary(err))
+					}
+					m.refreshRepoStatus(repo)
+					return m.actionResult(repo, fmt.Sprintf("Created tag %s", name), "", nil), nil
+				},
+			})
+			return nil, nil
+		},
+	})
+}
@@ -0,0 +1,246 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/cwsaylor/gitmoni/internal/crash"
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// taskResultDuration is how long a finished task's result stays visible in
+// the repository list.
+const taskResultDuration = 5 * time.Second
+
+// task is a background git operation running against a single repository,
+// such as a fetch, pull, or push. Each repo runs at most one task at a time.
+type task struct {
+	kind    string // what is being done, e.g. "fetch"; used in log messages
+	label   string // progress text shown while running, e.g. "Pulling"
+	started time.Time
+	spinner spinner.Model
+}
+
+// taskResult is the outcome of a finished task, shown in the repo's
+// description until it expires.
+type taskResult struct {
+	message  string
+	err      error
+	finished time.Time
+}
+
+// taskError remembers the most recent failed task for a repo so its full
+// details can be shown on request.
+type taskError struct {
+	kind string
+	err  error
+}
+
+// taskDoneMsg is sent when a task finishes
+type taskDoneMsg struct {
+	repo    string
+	kind    string
+	done    string // success text, e.g. "Pushed"
+	err     error
+	elapsed time.Duration
+	output  string // shown in a popup when the task succeeds, if set
+}
+
+// taskResultExpiredMsg is sent when a task result should stop being shown
+type taskResultExpiredMsg struct {
+	repo     string
+	finished time.Time
+}
+
+// newSpinner returns a spinner in the style used throughout the UI.
+func newSpinner(static bool) spinner.Model {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	if static {
+		s.Spinner = spinner.Spinner{Frames: []string{"in progress:"}, FPS: time.Second}
+	}
+	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#babbf1")) // Bright blue color
+	return s
+}
+
+// startTask runs fn against repo in the background, showing label and a
+// spinner next to the repo until it finishes. It returns nil if the repo
+// already has a task running.
+func (m *model) startTask(repo, kind, label, done string, fn func(ctx context.Context) error) tea.Cmd {
+	return m.startOutputTask(repo, kind, label, done, func(ctx context.Context) (string, error) {
+		return "", fn(ctx)
+	})
+}
+
+// startOutputTask is like startTask for a task whose output the user wants
+// to read, such as a plugin action. Non-empty output of a successful task is
+// shown in a popup.
+func (m *model) startOutputTask(repo, kind, label, done string, fn func(ctx context.Context) (string, error)) tea.Cmd {
+	if _, busy := m.tasks[repo]; busy {
+		return nil
+	}
+	t := &task{kind: kind, label: label, started: time.Now(), spinner: newSpinner(m.config.Accessible)}
+	m.tasks[repo] = t
+	delete(m.taskResults, repo)
+	m.updateRepoList()
+
+	// Track the task so shutdown can wait for its git process to exit
+	ctx, workers := m.ctx, m.workers
+	workers.Add(1)
+	return tea.Batch(m.animate(), func() tea.Msg {
+		defer workers.Done()
+		defer crash.Capture()
+		output, err := fn(ctx)
+		return taskDoneMsg{repo: repo, kind: kind, done: done, err: err, elapsed: time.Since(t.started), output: output}
+	})
+}
+
+// startAction runs a git operation against repo as a background task and
+// refreshes the repo's status in the store when it finishes.
+func (m *model) startAction(repo, kind, label, done string, action func(ctx context.Context, repo string) error) tea.Cmd {
+	store := m.store
+	return m.startTask(repo, kind, label, done, func(ctx context.Context) error {
+		return store.Run(ctx, repo, action)
+	})
+}
+
+// runningTasks counts the tasks of the given kind that are still in flight.
+func (m *model) runningTasks(kind string) int {
+	n := 0
+	for _, t := range m.tasks {
+		if t.kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// finishTask handles a completed task: it records the outcome and returns
+// any follow-up commands. The task has already refreshed the repo's status
+// in the store.
+func (m *model) finishTask(msg taskDoneMsg) tea.Cmd {
+	delete(m.tasks, msg.repo)
+	if msg.err != nil && !(msg.kind == "fetch" && gitstatus.IsSkipped(msg.err)) {
+		m.taskErrors[msg.repo] = taskError{kind: msg.kind, err: msg.err}
+	} else if m.taskErrors[msg.repo].kind == msg.kind {
+		delete(m.taskErrors, msg.repo)
+	}
+
+	if msg.kind == "fetch" {
+		return m.finishFetch(msg)
+	}
+
+	failure := fmt.Sprintf("%s failed", capitalize(msg.kind))
+	result := &taskResult{message: msg.done, err: msg.err, finished: time.Now()}
+	if msg.err != nil {
+		result.message = failure
+	}
+	m.taskResults[msg.repo] = result
+	m.updateRepoList()
+	if msg.err == nil && msg.output != "" {
+		m.showInfo(fmt.Sprintf("%s: %s", msg.done, filepath.Base(msg.repo)), msg.output)
+	}
+
+	expire := tea.Tick(taskResultDuration, func(time.Time) tea.Msg {
+		return taskResultExpiredMsg{repo: msg.repo, finished: result.finished}
+	})
+	report := m.actionResult(msg.repo,
+		fmt.Sprintf("%s in %s", msg.done, formatDuration(msg.elapsed)),
+		failure,
+		msg.err)
+	return tea.Batch(expire, report)
+}
+
+// finishFetch records a completed fetch and, once the whole batch is done,
+// reports a summary, as an error if any fetch failed.
+func (m *model) finishFetch(msg taskDoneMsg) tea.Cmd {
+	var cmds []tea.Cmd
+	if gitstatus.IsSkipped(msg.err) {
+		m.activity.add(msg.repo, "Fetch skipped after %s: %s", formatDuration(msg.elapsed), gitstatus.ErrorSummary(msg.err))
+	} else if msg.err != nil {
+		m.activity.addError(msg.repo, "Fetch failed after %s: %s", formatDuration(msg.elapsed), msg.err)
+		m.fetchFailed++
+	} else {
+		m.activity.add(msg.repo, "Fetched in %s", formatDuration(msg.elapsed))
+		// Pull requests and CI change on the server, so refresh them too
+		cmds = append(cmds, m.queryForge(msg.repo))
+		if status, _ := m.store.Status(msg.repo); status.CleanBehind() && m.config.AutoPulls(msg.repo) {
+			done := fmt.Sprintf("Auto-pulled %d commits", status.Behind)
+			if status.Behind == 1 {
+				done = "Auto-pulled 1 commit"
+			}
+			cmds = append(cmds, m.startAction(msg.repo, "pull", "Auto-pulling", done, gitstatus.Pull))
+		}
+	}
+
+	// Check if all repos are done fetching
+	if m.isFetching && m.runningTasks("fetch") == 0 {
+		m.isFetching = false
+		summary := fmt.Sprintf("Fetched %d repos in %s", m.fetchBatchSize, formatDuration(time.Since(m.fetchStarted)))
+		m.fetchSpan.SetAttr("repos", strconv.Itoa(m.fetchBatchSize))
+		m.fetchSpan.Finish(nil)
+		m.fetchSpan = nil
+		if m.fetchFailed > 0 {
+			summary += fmt.Sprintf(", %d failed", m.fetchFailed)
+		}
+		m.activity.add("", "%s", summary)
+		cmds = append(cmds, m.notify(summary, m.fetchFailed > 0))
+	}
+	m.refreshActivityView()
+	return tea.Batch(cmds...)
+}
+
+// animate starts the spinner tick loop unless it is already running. A
+// single loop drives every spinner, so the number of redraws doesn't grow
+// with the number of running tasks.
+func (m *model) animate() tea.Cmd {
+	if m.animating || m.config.Accessible {
+		return nil
+	}
+	m.animating = true
+	return m.spinner.Tick
+}
+
+// tickSpinners advances the global and per-task spinners. It returns nil once
+// nothing is animating, which stops the tick loop.
+func (m *model) tickSpinners(msg spinner.TickMsg) tea.Cmd {
+	if msg.ID != m.spinner.ID() {
+		return nil
+	}
+	if !m.isFetching && len(m.tasks) == 0 && !m.scanning() {
+		m.animating = false
+		return nil
+	}
+
+	var cmd tea.Cmd
+	m.spinner, cmd = m.spinner.Update(msg)
+	for _, t := range m.tasks {
+		// Advance the task spinner's frame without starting its own loop
+		t.spinner, _ = t.spinner.Update(spinner.TickMsg{ID: t.spinner.ID(), Time: msg.Time})
+	}
+
+	// Repo items point at their task, so only a redraw is needed, and only
+	// if one of them is on screen
+	for _, item := range visibleItems(m.repoList) {
+		if item.(repoItem).task != nil {
+			m.repoVersion++
+			break
+		}
+	}
+	return cmd
+}
+
+// capitalize upper-cases the first letter of an ASCII word.
+func capitalize(s string) string {
+	if s == "" || s[0] < 'a' || s[0] > 'z' {
+		return s
+	}
+	return string(s[0]-'a'+'A') + s[1:]
+}
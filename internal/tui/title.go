@@ -0,0 +1,43 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// updateTitle sets the terminal's window title to a summary of what needs
+// attention, such as "gitmoni: 4 dirty, 2 behind", if terminal_title is
+// set and the summary changed.
+func (m *model) updateTitle() tea.Cmd {
+	if !m.config.TerminalTitle {
+		return nil
+	}
+	title := windowTitle(gitstatus.NewSnapshot(m.store.Statuses()).Summary())
+	if title == m.title {
+		return nil
+	}
+	m.title = title
+	return tea.SetWindowTitle(title)
+}
+
+// windowTitle summarizes sum for the terminal's tab bar.
+func windowTitle(sum gitstatus.Summary) string {
+	var parts []string
+	if sum.Dirty > 0 {
+		parts = append(parts, fmt.Sprintf("%d dirty", sum.Dirty))
+	}
+	if sum.Behind > 0 {
+		parts = append(parts, fmt.Sprintf("%d behind", sum.Behind))
+	}
+	if sum.Errors > 0 {
+		parts = append(parts, fmt.Sprintf("%d failing", sum.Errors))
+	}
+	if len(parts) == 0 {
+		return "gitmoni: all clean"
+	}
+	return "gitmoni: " + strings.Join(parts, ", ")
+}
@@ -0,0 +1,119 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// toastDuration is how long a toast stays on screen before expiring.
+const toastDuration = 4 * time.Second
+
+// maxToasts limits how many toasts are stacked at once; older ones are
+// dropped when a new one arrives.
+const maxToasts = 3
+
+// toast is a short, non-blocking notification shown in the corner of the UI.
+type toast struct {
+	id      int
+	message string
+	isError bool
+}
+
+// toastExpiredMsg is sent when a toast's display time has elapsed
+type toastExpiredMsg struct {
+	id int
+}
+
+// notify shows a toast and returns the command that will expire it.
+func (m *model) notify(message string, isError bool) tea.Cmd {
+	m.nextToastID++
+	id := m.nextToastID
+	m.toasts = append(m.toasts, toast{id: id, message: message, isError: isError})
+	if len(m.toasts) > maxToasts {
+		m.toasts = m.toasts[len(m.toasts)-maxToasts:]
+	}
+	return tea.Tick(toastDuration, func(time.Time) tea.Msg {
+		return toastExpiredMsg{id: id}
+	})
+}
+
+// dismissToast removes the toast with the given id, if still present.
+func (m *model) dismissToast(id int) {
+	for i, t := range m.toasts {
+		if t.id == id {
+			m.toasts = append(m.toasts[:i], m.toasts[i+1:]...)
+			return
+		}
+	}
+}
+
+// renderToasts stacks the active toasts vertically, newest at the bottom.
+func (m *model) renderToasts() string {
+	if len(m.toasts) == 0 {
+		return ""
+	}
+
+	base := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		Padding(0, 1).
+		Foreground(lipgloss.Color("#c6d0f5")). // Text
+		Background(lipgloss.Color("#303446")). // Base
+		BorderBackground(lipgloss.Color("#303446"))
+
+	// Toasts are single-line; full details are kept in the activity log
+	maxWidth := max(m.width/2-4, 10)
+
+	rendered := make([]string, 0, len(m.toasts))
+	for _, t := range m.toasts {
+		style := base.BorderForeground(lipgloss.Color("#a6d189")) // Green
+		if t.isError {
+			style = base.BorderForeground(lipgloss.Color("#e78284")) // Red
+		}
+		message, _, _ := strings.Cut(t.message, "\n")
+		if t.isError && m.config.Accessible {
+			message = "Error: " + message
+		}
+		rendered = append(rendered, style.Render(ansi.Truncate(message, maxWidth, "…")))
+	}
+	return lipgloss.JoinVertical(lipgloss.Right, rendered...)
+}
+
+// placeOverlay draws fg on top of bg with its top-left corner at column x,
+// row y. Both strings may contain ANSI styling; cells of bg that fall outside
+// fg are preserved.
+func placeOverlay(x, y int, fg, bg string) string {
+	bgLines := strings.Split(bg, "\n")
+	fgLines := strings.Split(fg, "\n")
+
+	for i, fgLine := range fgLines {
+		row := y + i
+		if row < 0 || row >= len(bgLines) {
+			continue
+		}
+		bgLine := bgLines[row]
+		left := ansi.Truncate(bgLine, x, "")
+		if w := ansi.StringWidth(left); w < x {
+			left += strings.Repeat(" ", x-w)
+		}
+		right := ansi.TruncateLeft(bgLine, x+ansi.StringWidth(fgLine), "")
+		bgLines[row] = left + "\x1b[0m" + fgLine + "\x1b[0m" + right
+	}
+	return strings.Join(bgLines, "\n")
+}
+
+// actionResult records the outcome of a user action in the activity log and
+// shows a toast for it. failure is used as the message prefix when err is set.
+func (m *model) actionResult(repo, success, failure string, err error) tea.Cmd {
+	defer m.refreshActivityView()
+	if err != nil {
+		m.activity.addError(repo, "%s: %s", failure, err)
+		return m.notify(fmt.Sprintf("%s: %s", failure, err), true)
+	}
+	m.activity.add(repo, "%s", success)
+	return m.notify(success, false)
+}
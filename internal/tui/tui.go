@@ -0,0 +1,73 @@
+// Package tui implements gitmoni's terminal user interface: the repository,
+// file, and diff panes, and the actions that can be run on the monitored
+// repositories.
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/cwsaylor/gitmoni/internal/control"
+	"github.com/cwsaylor/gitmoni/internal/mqtt"
+	"github.com/cwsaylor/gitmoni/pkg/config"
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// Options are optional settings for Run.
+type Options struct {
+	// Trace, if set, records the timing of status checks and fetches.
+	Trace *gitstatus.Trace
+}
+
+// Run starts the TUI for the repositories in cfg and blocks until the user
+// quits or ctx is cancelled. Background git processes are then stopped and
+// waited for, so none outlive the TUI. If the user chose to open a
+// repository in the configured git client, its path is returned so the
+// caller can launch the client once the terminal has been restored.
+func Run(ctx context.Context, cfg *config.Config, opts Options) (string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	m := newModel(ctx, cfg, opts)
+	// Use the alternate screen to avoid polluting scrollback while the TUI runs.
+	// If running inside tmux, ensure: set -g alternate-screen on
+	p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithContext(ctx), tea.WithOutput(m.terminal))
+
+	// Let scripts drive this instance unless another one already is
+	if path := control.SocketPath(cfg.ControlSocket); path != "" {
+		ln, err := control.Listen(path)
+		if err != nil {
+			slog.Warn("control socket unavailable", "path", path, "err", err)
+		} else {
+			defer ln.Close()
+			go control.Serve(ctx, ln, forwardControl(p))
+		}
+	}
+	mqtt.Start(ctx, cfg, m.store)
+	finalModel, err := p.Run()
+	if cfg.TerminalTitle {
+		// Don't leave a stale summary in the tab bar
+		fmt.Print(ansi.SetWindowTitle(""))
+	}
+
+	cancel()
+	m.events.Close()
+	m.workers.Wait()
+
+	if ctx.Err() != nil && errors.Is(err, tea.ErrProgramKilled) {
+		// Shut down by the caller's context, e.g. on SIGTERM
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if result, ok := finalModel.(model); ok && result.launchLazyGit {
+		return result.lazyGitRepo, nil
+	}
+	return "", nil
+}
@@ -0,0 +1,111 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// withBackup runs a destructive action on repo, such as discarding changes,
+// after backing up the paths it touches (the whole tree if none) so it can
+// be undone from the undo pane. If the backup fails the action isn't run.
+// Repositories on other hosts aren't backed up.
+func withBackup(repo, action string, paths []string, run func() error) error {
+	if !gitstatus.IsSSH(repo) {
+		if _, err := gitstatus.CreateBackup(repo, action, paths); err != nil {
+			return fmt.Errorf("backing up before %s failed: %w", strings.ToLower(action[:1])+action[1:], err)
+		}
+	}
+	return run()
+}
+
+// undoHint finishes the confirmation of a destructive action on repo by
+// saying whether it can be undone.
+func undoHint(repo string) string {
+	if gitstatus.IsSSH(repo) {
+		return "This cannot be undone."
+	}
+	return "It can be undone from the undo pane (u)."
+}
+
+type backupItem struct {
+	backup gitstatus.Backup
+}
+
+func (i backupItem) FilterValue() string { return i.backup.Action }
+
+func (i backupItem) Title() string { return i.backup.Action }
+
+func (i backupItem) Description() string {
+	return formatAge(i.backup.Date)
+}
+
+// undoPane lists the backups taken before a repo's destructive actions,
+// newest first, so the last few can be undone.
+type undoPane struct{}
+
+func (undoPane) title() string { return "Undo" }
+
+func (undoPane) load(repo string) ([]list.Item, error) {
+	backups, err := gitstatus.Backups(repo)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]list.Item, 0, len(backups))
+	for _, b := range backups {
+		items = append(items, backupItem{backup: b})
+	}
+	return items, nil
+}
+
+func (undoPane) detail(repo string, item list.Item) string {
+	b := item.(backupItem).backup
+	diff, err := gitstatus.BackupDiff(repo, b)
+	if err != nil {
+		return fmt.Sprintf("Error loading the backup: %s", gitstatus.ErrorSummary(err))
+	}
+	header := fmt.Sprintf("Before: %s\nTaken: %s\n\n", b.Action, timeFormat.When(b.Date))
+	if strings.TrimSpace(diff) == "" {
+		return header + "No changes were saved."
+	}
+	return header + applySyntaxHighlighting(diff, "")
+}
+
+func (undoPane) handleKey(m *model, repo string, item list.Item, key string) (tea.Cmd, bool) {
+	if item == nil {
+		return nil, false
+	}
+	b := item.(backupItem).backup
+	switch key {
+	case "enter":
+		// Restore the backup after confirmation, backing up what it
+		// overwrites first
+		what := "the files it covers"
+		if len(b.Paths) == 0 {
+			what = "the branch and working tree"
+		}
+		m.confirm("Undo?",
+			fmt.Sprintf("Undo %q in %s (%s), putting back %s as they were before it? Current changes to them are backed up first.",
+				b.Action, filepath.Base(repo), formatAge(b.Date), what),
+			func(m *model) tea.Cmd {
+				err := withBackup(repo, "Undo of "+b.Action, b.Paths, func() error {
+					return gitstatus.RestoreBackup(repo, b)
+				})
+				m.refreshRepoStatus(repo)
+				m.loadSidePane(false)
+				return m.actionResult(repo, "Undid "+b.Action, "Undo failed", err)
+			})
+		return nil, true
+	case "d":
+		// Delete the backup
+		err := gitstatus.DropBackup(repo, b)
+		m.loadSidePane(true)
+		return m.actionResult(repo, "Deleted the backup", "Deleting the backup failed", err), true
+	}
+	return nil, false
+}
@@ -0,0 +1,50 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// watchedBranches describes how each of repo's watch_branches compares to
+// its upstream, e.g. "release/1.x 3 behind", with those that have drifted
+// behind or lost their upstream in peach. The checked-out branch is left
+// out, as the repo's status already covers it, and nothing is returned
+// until the repo's branches have been listed.
+func (m *model) watchedBranches(repo, current string) []string {
+	branches, ok := m.branches[repo]
+	if !ok {
+		return nil
+	}
+	drifted := lipgloss.NewStyle().Foreground(lipgloss.Color("#ef9f76")) // Peach
+	var watched []string
+	for _, name := range m.config.Settings(repo).WatchBranches {
+		if name == current {
+			continue
+		}
+		i := slices.IndexFunc(branches, func(b gitstatus.LocalBranch) bool { return b.Name == name })
+		if i < 0 {
+			watched = append(watched, name+" not found")
+			continue
+		}
+		b := branches[i]
+		switch {
+		case b.Gone:
+			watched = append(watched, drifted.Render(name+" upstream gone"))
+		case b.Upstream == "":
+			watched = append(watched, name+" has no upstream")
+		case b.Behind > 0 && b.Ahead > 0:
+			watched = append(watched, drifted.Render(fmt.Sprintf("%s %d behind, %d ahead", name, b.Behind, b.Ahead)))
+		case b.Behind > 0:
+			watched = append(watched, drifted.Render(fmt.Sprintf("%s %d behind", name, b.Behind)))
+		case b.Ahead > 0:
+			watched = append(watched, fmt.Sprintf("%s %d ahead", name, b.Ahead))
+		default:
+			watched = append(watched, name+" up to date")
+		}
+	}
+	return watched
+}
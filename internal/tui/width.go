@@ -0,0 +1,24 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Columns are measured in terminal cells, not bytes or runes: emoji and CJK
+// characters take two cells, and combining marks none.
+
+// padCells pads s with spaces to width cells.
+func padCells(s string, width int) string {
+	if w := ansi.StringWidth(s); w < width {
+		return s + strings.Repeat(" ", width-w)
+	}
+	return s
+}
+
+// fitCells truncates s to width cells, with an ellipsis, and pads it to
+// exactly width.
+func fitCells(s string, width int) string {
+	return padCells(ansi.Truncate(s, width, "…"), width)
+}
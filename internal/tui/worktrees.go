@@ -0,0 +1,159 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+type worktreeItem struct {
+	worktree gitstatus.Worktree
+}
+
+func (i worktreeItem) FilterValue() string { return i.worktree.Path }
+
+func (i worktreeItem) Title() string {
+	branch := i.worktree.Branch
+	if branch == "" {
+		branch = "detached at " + shortHash(i.worktree.Head)
+	}
+	branch = lipgloss.NewStyle().Foreground(lipgloss.Color("#a6d189")).Render(branch) // Green
+	return branch + " " + filepath.Base(i.worktree.Path)
+}
+
+func (i worktreeItem) Description() string {
+	wt := i.worktree
+	var parts []string
+	if wt.Main {
+		parts = append(parts, "main")
+	}
+	switch {
+	case wt.Prunable:
+		parts = append(parts, "missing")
+	case wt.Changes < 0:
+		parts = append(parts, "status unknown")
+	case wt.Changes == 0:
+		parts = append(parts, "clean")
+	case wt.Changes == 1:
+		parts = append(parts, "1 changed file")
+	default:
+		parts = append(parts, fmt.Sprintf("%d changed files", wt.Changes))
+	}
+	if wt.Locked {
+		parts = append(parts, "locked")
+	}
+	return strings.Join(parts, " • ")
+}
+
+// shortHash abbreviates a full commit hash for display.
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
+
+// worktreesPane lists a repo's linked worktrees and lets them be added,
+// removed, or monitored as repositories of their own.
+type worktreesPane struct{}
+
+func (worktreesPane) title() string { return "Worktrees" }
+
+func (worktreesPane) load(repo string) ([]list.Item, error) {
+	worktrees, err := gitstatus.Worktrees(repo)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]list.Item, 0, len(worktrees))
+	for _, wt := range worktrees {
+		items = append(items, worktreeItem{worktree: wt})
+	}
+	return items, nil
+}
+
+func (worktreesPane) detail(repo string, item list.Item) string {
+	wt := item.(worktreeItem).worktree
+	if wt.Prunable {
+		return fmt.Sprintf("%s\n\nThis worktree's directory no longer exists. Run `git worktree prune` to clean it up.", wt.Path)
+	}
+	output, err := gitstatus.Run(wt.Path, "--no-optional-locks", "status", "--short", "--branch")
+	if err != nil {
+		return fmt.Sprintf("Error getting status of %s: %s", wt.Path, gitstatus.ErrorSummary(err))
+	}
+	return wt.Path + "\n\n" + string(output)
+}
+
+func (worktreesPane) handleKey(m *model, repo string, item list.Item, key string) (tea.Cmd, bool) {
+	switch key {
+	case "c":
+		m.promptNewWorktree(repo)
+		return nil, true
+	case "d":
+		// Remove the linked worktree after confirmation
+		if item == nil {
+			return nil, true
+		}
+		wt := item.(worktreeItem).worktree
+		if wt.Main {
+			return m.actionResult(repo, "", "Remove worktree failed", errors.New("the main worktree cannot be removed")), true
+		}
+		m.confirm("Remove worktree?",
+			fmt.Sprintf("Remove the worktree at %s? Its branch is kept.", wt.Path),
+			func(m *model) tea.Cmd {
+				err := gitstatus.RemoveWorktree(repo, wt.Path)
+				m.refreshRepoStatus(repo)
+				return m.actionResult(repo, "Removed worktree "+wt.Path, "Remove worktree failed", err)
+			})
+		return nil, true
+	case "a":
+		// Monitor the worktree as a repository of its own
+		if item == nil {
+			return nil, true
+		}
+		cmd, err := m.addRepository(item.(worktreeItem).worktree.Path)
+		if err != nil {
+			return m.actionResult(repo, "", "Add failed", err), true
+		}
+		return cmd, true
+	}
+	return nil, false
+}
+
+// promptNewWorktree asks for a directory and a branch and adds a linked
+// worktree for repo.
+func (m *model) promptNewWorktree(repo string) {
+	m.openPrompt(promptOptions{
+		title:       fmt.Sprintf("New worktree for %s", filepath.Base(repo)),
+		placeholder: "/path/to/new/worktree",
+		value:       filepath.Dir(repo) + string(filepath.Separator),
+		historyKey:  "worktree-path",
+		complete:    completeDirectory,
+		onSubmit: func(m *model, path string) (tea.Cmd, error) {
+			path = expandHome(strings.TrimSpace(path))
+			if path == "" {
+				return nil, errors.New("path is required")
+			}
+			m.openPrompt(promptOptions{
+				title:       "Branch for " + path,
+				placeholder: "existing or new branch (empty for detached HEAD)",
+				historyKey:  "branch",
+				onSubmit: func(m *model, branch string) (tea.Cmd, error) {
+					branch = strings.TrimSpace(branch)
+					if err := gitstatus.AddWorktree(repo, path, branch); err != nil {
+						return nil, errors.New(gitstatus.ErrorSummary(err))
+					}
+					m.refreshRepoStatus(repo)
+					return m.actionResult(repo, "Added worktree "+path, "", nil), nil
+				},
+			})
+			return nil, nil
+		},
+	})
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// QuietHours are the times a repository isn't fetched automatically.
+type QuietHours struct {
+	days   []time.Weekday
+	ranges [][2]int // minutes since midnight, from and to; from > to wraps past midnight
+}
+
+// ParseQuietHours parses quiet hours given as times of day, such as
+// "18:00-09:00", which may wrap past midnight, and days of the week, such
+// as "sat" or "sunday", that are quiet all day.
+func ParseQuietHours(specs []string) (QuietHours, error) {
+	var q QuietHours
+	for _, spec := range specs {
+		spec = strings.ToLower(strings.TrimSpace(spec))
+		if day, ok := parseWeekday(spec); ok {
+			q.days = append(q.days, day)
+			continue
+		}
+		from, to, ok := strings.Cut(spec, "-")
+		if !ok {
+			return QuietHours{}, fmt.Errorf("quiet hours %q: want a day or a range such as 18:00-09:00", spec)
+		}
+		start, err := parseClock(from)
+		if err != nil {
+			return QuietHours{}, fmt.Errorf("quiet hours %q: %w", spec, err)
+		}
+		end, err := parseClock(to)
+		if err != nil {
+			return QuietHours{}, fmt.Errorf("quiet hours %q: %w", spec, err)
+		}
+		q.ranges = append(q.ranges, [2]int{start, end})
+	}
+	return q, nil
+}
+
+// Contains reports whether t is in the quiet hours, in t's location.
+func (q QuietHours) Contains(t time.Time) bool {
+	for _, day := range q.days {
+		if t.Weekday() == day {
+			return true
+		}
+	}
+	minute := t.Hour()*60 + t.Minute()
+	for _, r := range q.ranges {
+		if r[0] <= r[1] && minute >= r[0] && minute < r[1] ||
+			r[0] > r[1] && (minute >= r[0] || minute < r[1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClock parses a time of day such as "9:00" or "18:30" as minutes
+// since midnight. "24:00" is the end of the day.
+func parseClock(s string) (int, error) {
+	var h, m int
+	if _, err := fmt.Sscanf(strings.TrimSpace(s), "%d:%d", &h, &m); err != nil || h < 0 || m < 0 || m > 59 || h*60+m > 24*60 {
+		return 0, fmt.Errorf("%q isn't a time of day such as 18:00", s)
+	}
+	return h*60 + m, nil
+}
+
+// parseWeekday parses a day of the week by its English name or its first
+// three letters.
+func parseWeekday(s string) (time.Weekday, bool) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		name := strings.ToLower(day.String())
+		if s == name || s == name[:3] {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+// DefaultFetchInterval returns how often repos are fetched automatically
+// unless their repo_settings say otherwise: fetch_interval, or 0 if it is
+// unset or invalid.
+func (c *Config) DefaultFetchInterval() time.Duration {
+	interval, _ := parseInterval(c.FetchInterval)
+	return interval
+}
+
+// FetchSchedule returns how often repo is fetched automatically as its
+// repo_settings say, 0 for the default, and when it isn't, as its
+// repo_settings or else quiet_hours say. Invalid values count as unset.
+func (c *Config) FetchSchedule(repo string) (time.Duration, QuietHours) {
+	settings := c.Settings(repo)
+	interval, _ := parseInterval(settings.FetchInterval)
+	specs := c.QuietHours
+	if settings.QuietHours != nil {
+		specs = settings.QuietHours
+	}
+	quiet, _ := ParseQuietHours(specs)
+	return interval, quiet
+}
+
+// parseInterval parses a fetch interval such as "5m" or "1h"; "" is 0.
+// Intervals under a minute aren't allowed.
+func parseInterval(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < time.Minute {
+		return 0, fmt.Errorf("%s is shorter than a minute", s)
+	}
+	return d, nil
+}
+
+// warnSchedules logs fetch intervals and quiet hours that can't be parsed,
+// which FetchSchedule ignores.
+func (c *Config) warnSchedules() {
+	if _, err := parseInterval(c.FetchInterval); err != nil {
+		slog.Warn("ignoring invalid fetch_interval", "value", c.FetchInterval, "err", err)
+	}
+	if _, err := ParseQuietHours(c.QuietHours); err != nil {
+		slog.Warn("ignoring invalid quiet_hours", "err", err)
+	}
+	for key, settings := range c.RepoSettings {
+		if _, err := parseInterval(settings.FetchInterval); err != nil {
+			slog.Warn("ignoring invalid fetch_interval", "repo_settings", key, "value", settings.FetchInterval, "err", err)
+		}
+		if _, err := ParseQuietHours(settings.QuietHours); err != nil {
+			slog.Warn("ignoring invalid quiet_hours", "repo_settings", key, "err", err)
+		}
+	}
+}
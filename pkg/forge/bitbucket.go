@@ -0,0 +1,168 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// Bitbucket queries the Bitbucket Cloud REST API.
+type Bitbucket struct {
+	Token  string       // access token; public repositories work without one
+	Client *http.Client // defaults to http.DefaultClient
+	APIURL string       // defaults to https://api.bitbucket.org/2.0
+}
+
+// NewBitbucket returns a Bitbucket Cloud client authenticated with token or
+// the BITBUCKET_TOKEN environment variable.
+func NewBitbucket(token string) *Bitbucket {
+	if token == "" {
+		token = os.Getenv("BITBUCKET_TOKEN")
+	}
+	return &Bitbucket{Token: token}
+}
+
+// Handles reports whether r is on bitbucket.org.
+func (b *Bitbucket) Handles(r Remote) bool {
+	return r.Host == "bitbucket.org"
+}
+
+// PullRequest returns the open pull request from branch, or nil.
+func (b *Bitbucket) PullRequest(ctx context.Context, r Remote, branch string) (*PullRequest, error) {
+	var page struct {
+		Values []struct {
+			ID    int    `json:"id"`
+			Title string `json:"title"`
+			Draft bool   `json:"draft"`
+			Links struct {
+				HTML struct{ Href string } `json:"html"`
+			} `json:"links"`
+			Participants []struct {
+				Approved bool   `json:"approved"`
+				State    string `json:"state"`
+			} `json:"participants"`
+		} `json:"values"`
+	}
+	q := fmt.Sprintf(`source.branch.name = %q AND state = "OPEN"`, branch)
+	if err := b.get(ctx, b.repo(r)+"/pullrequests?fields=%2Bvalues.participants&q="+url.QueryEscape(q), &page); err != nil {
+		return nil, err
+	}
+	if len(page.Values) == 0 {
+		return nil, nil
+	}
+
+	v := page.Values[0]
+	pr := &PullRequest{
+		Ref:    fmt.Sprintf("PR #%d", v.ID),
+		Number: v.ID,
+		Title:  v.Title,
+		URL:    v.Links.HTML.Href,
+		Draft:  v.Draft,
+	}
+	for _, p := range v.Participants {
+		switch {
+		case p.State == "changes_requested":
+			pr.Review = "changes requested"
+		case p.Approved && pr.Review == "":
+			pr.Review = "approved"
+		}
+	}
+	return pr, nil
+}
+
+// Counts returns the number of open issues and pull requests of r.
+// Repositories without the issue tracker enabled have no issues.
+func (b *Bitbucket) Counts(ctx context.Context, r Remote) (Counts, error) {
+	counts := Counts{Kind: "PR"}
+	var page struct {
+		Size int `json:"size"`
+	}
+	if err := b.get(ctx, b.repo(r)+"/pullrequests?state=OPEN&pagelen=1&fields=size", &page); err != nil {
+		return counts, err
+	}
+	counts.PullRequests = page.Size
+
+	q := url.QueryEscape(`state = "new" OR state = "open"`)
+	if err := b.get(ctx, b.repo(r)+"/issues?pagelen=1&fields=size&q="+q, &page); err == nil {
+		counts.Issues = page.Size
+	}
+	return counts, nil
+}
+
+// CI returns the combined state of the build statuses reported for the
+// latest commit on branch.
+func (b *Bitbucket) CI(ctx context.Context, r Remote, branch string) (CI, error) {
+	var page struct {
+		Values []struct {
+			Name  string `json:"name"`
+			State string `json:"state"`
+			URL   string `json:"url"`
+		} `json:"values"`
+	}
+	if err := b.get(ctx, b.repo(r)+"/commit/"+url.PathEscape(branch)+"/statuses?pagelen=50", &page); err != nil {
+		return CI{}, err
+	}
+	if len(page.Values) == 0 {
+		return CI{}, nil
+	}
+
+	latest := page.Values[0]
+	ci := CI{State: "success", Name: latest.Name, URL: latest.URL}
+	for _, s := range page.Values {
+		switch s.State {
+		case "INPROGRESS":
+			if ci.State == "success" {
+				ci = CI{State: "running", Name: s.Name, URL: s.URL}
+			}
+		case "FAILED":
+			if ci.State != "failure" {
+				ci = CI{State: "failure", Name: s.Name, URL: s.URL}
+			}
+		}
+	}
+	return ci, nil
+}
+
+// repo returns the API path of r.
+func (b *Bitbucket) repo(r Remote) string {
+	return "repositories/" + r.Owner + "/" + r.Name
+}
+
+// get makes a GET request to an API path and decodes the response into out.
+func (b *Bitbucket) get(ctx context.Context, path string, out any) error {
+	apiURL := b.APIURL
+	if apiURL == "" {
+		apiURL = "https://api.bitbucket.org/2.0"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL+"/"+path, nil)
+	if err != nil {
+		return err
+	}
+	if b.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.Token)
+	}
+	client := b.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("bitbucket: %s", resp.Status)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("bitbucket: invalid response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package forge
+
+import (
+	"context"
+	"log/slog"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// cliTimeout bounds asking a command line tool for its token, which may
+// have to unlock the system keyring.
+const cliTimeout = 5 * time.Second
+
+// GitHubCLIToken returns the token the gh command line tool is logged in to
+// host with, or "" if gh isn't installed or logged in.
+func GitHubCLIToken(host string) string {
+	return cliToken("gh", "auth", "token", "--hostname", host)
+}
+
+// GitLabCLIToken returns the token the glab command line tool is logged in
+// to host with, or "" if glab isn't installed or logged in.
+func GitLabCLIToken(host string) string {
+	return cliToken("glab", "config", "get", "token", "--host", host)
+}
+
+func cliToken(name string, args ...string) string {
+	if _, err := exec.LookPath(name); err != nil {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cliTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, name, args...).Output()
+	if err != nil {
+		slog.Debug("no token from "+name, "err", err)
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,213 @@
+// Package forge queries code hosting services (GitHub, GitLab, Bitbucket
+// Cloud, and Gitea or Forgejo) for the state of a repository's pull
+// requests and CI runs. Repositories are identified by their remote URL;
+// support for another host is added by implementing Provider.
+package forge
+
+import (
+	"context"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+)
+
+// Remote identifies a hosted repository.
+type Remote struct {
+	Host  string // e.g. "github.com"
+	Owner string // user, organisation, or group path
+	Name  string
+}
+
+// ParseRemote parses a git remote URL in any of the forms git accepts for
+// hosted repositories: https://host/owner/name.git, ssh://git@host/owner/name,
+// or the scp-like git@host:owner/name.git.
+func ParseRemote(rawURL string) (Remote, bool) {
+	var host, path string
+	if u, err := url.Parse(rawURL); err == nil && u.Scheme != "" && u.Host != "" {
+		host, path = u.Hostname(), u.Path
+	} else if at, colon := strings.Index(rawURL, "@"), strings.Index(rawURL, ":"); colon > at && at >= 0 {
+		host, path = rawURL[at+1:colon], rawURL[colon+1:]
+	} else {
+		return Remote{}, false
+	}
+
+	path = strings.Trim(strings.TrimSuffix(strings.Trim(path, "/"), ".git"), "/")
+	slash := strings.LastIndex(path, "/")
+	if slash <= 0 || slash == len(path)-1 {
+		return Remote{}, false
+	}
+	return Remote{Host: strings.ToLower(host), Owner: path[:slash], Name: path[slash+1:]}, true
+}
+
+// Provider is a code hosting service.
+type Provider interface {
+	// Handles reports whether the provider hosts r.
+	Handles(r Remote) bool
+	// PullRequest returns the open pull request whose head is branch, or
+	// nil if there is none.
+	PullRequest(ctx context.Context, r Remote, branch string) (*PullRequest, error)
+	// Counts returns the number of open issues and pull requests of r.
+	Counts(ctx context.Context, r Remote) (Counts, error)
+	// CI returns the state of the CI runs for the latest commit on branch.
+	CI(ctx context.Context, r Remote, branch string) (CI, error)
+}
+
+// Counts are a repository's open issue and pull request counts. Pull
+// requests are called merge requests on some hosts.
+type Counts struct {
+	Issues       int
+	PullRequests int
+	Kind         string // what the host calls pull requests: "PR" or "MR"
+}
+
+// PullRequest is an open pull request.
+type PullRequest struct {
+	Ref    string // how the host refers to it, e.g. "PR #12" or "MR !12"
+	Number int
+	Title  string
+	URL    string
+	Draft  bool
+	Review string // "approved", "changes requested", "review required", or ""
+	Merge  string // "mergeable", "conflicts", or "" if unknown
+}
+
+// CI is the state of the CI runs for a branch's latest commit.
+type CI struct {
+	State string // "success", "failure", "running", or "" if nothing ran
+	Name  string // workflow or pipeline the state and URL refer to
+	URL   string // web page of the first failing run, else the latest run
+}
+
+// Kinds of hosting service, as returned by Detect.
+const (
+	KindGitHub    = "github"
+	KindGitLab    = "gitlab"
+	KindBitbucket = "bitbucket"
+	KindGitea     = "gitea" // Gitea or Forgejo
+)
+
+// Detect guesses the kind of hosting service from a remote's host name:
+// public services by name, and self-hosted instances by a host name that
+// contains the product's name, such as gitlab.example.com. It returns "" if
+// the host is not recognised.
+func Detect(host string) string {
+	host = strings.ToLower(host)
+	switch host {
+	case "github.com":
+		return KindGitHub
+	case "gitlab.com":
+		return KindGitLab
+	case "bitbucket.org":
+		return KindBitbucket
+	case "codeberg.org":
+		return KindGitea
+	}
+	switch {
+	case strings.Contains(host, "gitlab"):
+		return KindGitLab
+	case strings.Contains(host, "gitea"), strings.Contains(host, "forgejo"):
+		return KindGitea
+	case strings.Contains(host, "github"):
+		return KindGitHub
+	}
+	return ""
+}
+
+// Host maps the remotes on matching hosts to a self-hosted instance, for
+// hosts whose kind Detect can't tell from their name or whose instance
+// isn't served from https://<host>.
+type Host struct {
+	Pattern string // host name or path.Match pattern, e.g. "*.corp.example.com"
+	Kind    string // KindGitHub, KindGitLab, or KindGitea
+	BaseURL string // the instance's web address; empty for https://<host>
+	// WebURL is the template of a repository's web page, with {host},
+	// {owner}, {name}, and {branch} replaced; empty for
+	// <BaseURL>/{owner}/{name}.
+	WebURL string
+}
+
+// Matches reports whether h applies to remotes on host.
+func (h Host) Matches(host string) bool {
+	ok, _ := path.Match(strings.ToLower(h.Pattern), strings.ToLower(host))
+	return ok
+}
+
+// baseURL returns the address of h's instance serving host.
+func (h Host) baseURL(host string) string {
+	if h.BaseURL == "" {
+		return "https://" + host
+	}
+	return strings.TrimSuffix(h.BaseURL, "/")
+}
+
+// Resolver picks the provider for each remote: one of the configured
+// providers if it handles the remote, or else a provider created for the
+// remote's host if Hosts maps it or Detect recognises it.
+type Resolver struct {
+	// Providers are consulted first, in order.
+	Providers []Provider
+	// Hosts map hosts to self-hosted instances. The first match wins.
+	Hosts []Host
+	// New creates a provider of the given kind for the instance at
+	// baseURL, e.g. https://gitlab.example.com. It may return nil to leave
+	// the host unsupported. If New is nil, only Providers are used.
+	New func(kind, baseURL string) Provider
+
+	mu       sync.Mutex
+	detected map[string]Provider
+}
+
+// Provider returns the provider for r, or nil if none supports it.
+func (res *Resolver) Provider(r Remote) Provider {
+	for _, p := range res.Providers {
+		if p.Handles(r) {
+			return p
+		}
+	}
+	if res.New == nil {
+		return nil
+	}
+
+	res.mu.Lock()
+	defer res.mu.Unlock()
+	if p, ok := res.detected[r.Host]; ok {
+		return p
+	}
+	var p Provider
+	if h, ok := res.host(r.Host); ok {
+		p = res.New(h.Kind, h.baseURL(r.Host))
+	} else if kind := Detect(r.Host); kind != "" {
+		p = res.New(kind, "https://"+r.Host)
+	}
+	if res.detected == nil {
+		res.detected = make(map[string]Provider)
+	}
+	res.detected[r.Host] = p
+	return p
+}
+
+func (res *Resolver) host(host string) (Host, bool) {
+	for _, h := range res.Hosts {
+		if h.Matches(host) {
+			return h, true
+		}
+	}
+	return Host{}, false
+}
+
+// WebURL returns the address of r's web page, from the template Hosts
+// configure for r's host if there is one, which may refer to branch.
+// Otherwise it is the repository's page under the instance's address.
+func (res *Resolver) WebURL(r Remote, branch string) string {
+	h, ok := res.host(r.Host)
+	if !ok || h.WebURL == "" {
+		return h.baseURL(r.Host) + "/" + r.Owner + "/" + r.Name
+	}
+	return strings.NewReplacer(
+		"{host}", r.Host,
+		"{owner}", r.Owner,
+		"{name}", r.Name,
+		"{branch}", branch,
+	).Replace(h.WebURL)
+}
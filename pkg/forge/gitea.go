@@ -0,0 +1,180 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Gitea queries the REST API of a Gitea or Forgejo instance, such as
+// Codeberg.
+type Gitea struct {
+	BaseURL string       // web root, e.g. https://codeberg.org
+	Token   string       // access token; public repositories work without one
+	Client  *http.Client // defaults to http.DefaultClient
+}
+
+// NewGitea returns a client for the Gitea or Forgejo instance at baseURL
+// (codeberg.org if empty), authenticated with token or the GITEA_TOKEN
+// environment variable.
+func NewGitea(baseURL, token string) *Gitea {
+	if baseURL == "" {
+		baseURL = "https://codeberg.org"
+	}
+	if token == "" {
+		token = os.Getenv("GITEA_TOKEN")
+	}
+	return &Gitea{BaseURL: strings.TrimSuffix(baseURL, "/"), Token: token}
+}
+
+// Handles reports whether r is on this instance's host.
+func (g *Gitea) Handles(r Remote) bool {
+	u, err := url.Parse(g.BaseURL)
+	return err == nil && strings.EqualFold(u.Hostname(), r.Host)
+}
+
+// PullRequest returns the open pull request from branch, or nil.
+func (g *Gitea) PullRequest(ctx context.Context, r Remote, branch string) (*PullRequest, error) {
+	var pulls []struct {
+		Number    int    `json:"number"`
+		Title     string `json:"title"`
+		HTMLURL   string `json:"html_url"`
+		Draft     bool   `json:"draft"`
+		Mergeable bool   `json:"mergeable"`
+		Head      struct {
+			Ref string `json:"ref"`
+		} `json:"head"`
+	}
+	if _, err := g.get(ctx, g.repo(r)+"/pulls?state=open&limit=50", &pulls); err != nil {
+		return nil, err
+	}
+
+	for _, p := range pulls {
+		if p.Head.Ref != branch {
+			continue
+		}
+		pr := &PullRequest{
+			Ref:    fmt.Sprintf("PR #%d", p.Number),
+			Number: p.Number,
+			Title:  p.Title,
+			URL:    p.HTMLURL,
+			Draft:  p.Draft,
+			Merge:  "conflicts",
+		}
+		if p.Mergeable {
+			pr.Merge = "mergeable"
+		}
+
+		var reviews []struct {
+			State string `json:"state"`
+		}
+		if _, err := g.get(ctx, fmt.Sprintf("%s/pulls/%d/reviews", g.repo(r), p.Number), &reviews); err == nil {
+			for _, rv := range reviews {
+				switch {
+				case rv.State == "REQUEST_CHANGES":
+					pr.Review = "changes requested"
+				case rv.State == "APPROVED" && pr.Review == "":
+					pr.Review = "approved"
+				}
+			}
+		}
+		return pr, nil
+	}
+	return nil, nil
+}
+
+// Counts returns the number of open issues and pull requests of r, from
+// the total counts Gitea reports in response headers.
+func (g *Gitea) Counts(ctx context.Context, r Remote) (Counts, error) {
+	counts := Counts{Kind: "PR"}
+	var none []struct{}
+	header, err := g.get(ctx, g.repo(r)+"/pulls?state=open&limit=1", &none)
+	if err != nil {
+		return counts, err
+	}
+	counts.PullRequests, _ = strconv.Atoi(header.Get("X-Total-Count"))
+	header, err = g.get(ctx, g.repo(r)+"/issues?state=open&type=issues&limit=1", &none)
+	if err != nil {
+		return counts, err
+	}
+	counts.Issues, _ = strconv.Atoi(header.Get("X-Total-Count"))
+	return counts, nil
+}
+
+// CI returns the combined commit status of the latest commit on branch.
+func (g *Gitea) CI(ctx context.Context, r Remote, branch string) (CI, error) {
+	var combined struct {
+		State    string `json:"state"`
+		Statuses []struct {
+			Context   string `json:"context"`
+			Status    string `json:"status"`
+			TargetURL string `json:"target_url"`
+		} `json:"statuses"`
+	}
+	if _, err := g.get(ctx, g.repo(r)+"/commits/"+url.PathEscape(branch)+"/status", &combined); err != nil {
+		return CI{}, err
+	}
+	if len(combined.Statuses) == 0 {
+		return CI{}, nil
+	}
+
+	ci := CI{Name: combined.Statuses[0].Context, URL: combined.Statuses[0].TargetURL}
+	switch combined.State {
+	case "success":
+		ci.State = "success"
+	case "failure", "error":
+		ci.State = "failure"
+	case "pending":
+		ci.State = "running"
+	}
+	for _, s := range combined.Statuses {
+		if s.Status == "failure" || s.Status == "error" {
+			ci.Name, ci.URL = s.Context, s.TargetURL
+			break
+		}
+	}
+	return ci, nil
+}
+
+// repo returns the API path of r.
+func (g *Gitea) repo(r Remote) string {
+	return "repos/" + r.Owner + "/" + r.Name
+}
+
+// get makes a GET request to an API path, decodes the response into out,
+// and returns the response headers.
+func (g *Gitea) get(ctx context.Context, path string, out any) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.BaseURL+"/api/v1/"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if g.Token != "" {
+		req.Header.Set("Authorization", "token "+g.Token)
+	}
+	client := g.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("gitea: %s", resp.Status)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return nil, fmt.Errorf("gitea: invalid response: %w", err)
+	}
+	return resp.Header, nil
+}
@@ -0,0 +1,316 @@
+package forge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// GitHub queries the GitHub GraphQL API, either directly with a token or
+// through the gh command line tool, reusing its login.
+type GitHub struct {
+	Token  string       // API token; if empty, requests go through gh
+	Client *http.Client // used with Token; defaults to http.DefaultClient
+	APIURL string       // GraphQL endpoint; defaults to https://api.github.com/graphql
+}
+
+// NewGitHub returns a GitHub client using token, the GITHUB_TOKEN or
+// GH_TOKEN environment variables, or the gh CLI's login, in that order. If
+// gh is too old to print its token, requests go through gh instead. It
+// reports false if none is available.
+func NewGitHub(token string) (*GitHub, bool) {
+	for _, t := range []string{token, os.Getenv("GITHUB_TOKEN"), os.Getenv("GH_TOKEN"), GitHubCLIToken("github.com")} {
+		if t != "" {
+			return &GitHub{Token: t}, true
+		}
+	}
+	if _, err := exec.LookPath("gh"); err == nil {
+		return &GitHub{}, true
+	}
+	return nil, false
+}
+
+// NewGitHubEnterprise returns a client for the GitHub Enterprise Server at
+// baseURL using token, the GH_ENTERPRISE_TOKEN or GITHUB_ENTERPRISE_TOKEN
+// environment variables, gh's login to the host, or the GITHUB_TOKEN or
+// GH_TOKEN environment variables, in that order. It reports false if none
+// is available.
+func NewGitHubEnterprise(baseURL, token string) (*GitHub, bool) {
+	var host string
+	if u, err := url.Parse(baseURL); err == nil {
+		host = u.Host
+	}
+	for _, t := range []string{token, os.Getenv("GH_ENTERPRISE_TOKEN"), os.Getenv("GITHUB_ENTERPRISE_TOKEN"), GitHubCLIToken(host), os.Getenv("GITHUB_TOKEN"), os.Getenv("GH_TOKEN")} {
+		if t != "" {
+			return &GitHub{Token: t, APIURL: strings.TrimSuffix(baseURL, "/") + "/api/graphql"}, true
+		}
+	}
+	return nil, false
+}
+
+// Handles reports whether r is on github.com.
+func (g *GitHub) Handles(r Remote) bool {
+	return r.Host == "github.com"
+}
+
+const pullRequestQuery = `query($owner: String!, $name: String!, $branch: String!) {
+  repository(owner: $owner, name: $name) {
+    ref(qualifiedName: $branch) {
+      associatedPullRequests(states: OPEN, first: 1) {
+        nodes { number title url isDraft reviewDecision mergeable }
+      }
+    }
+  }
+}`
+
+// PullRequest returns the open pull request whose head is branch, or nil.
+func (g *GitHub) PullRequest(ctx context.Context, r Remote, branch string) (*PullRequest, error) {
+	var data struct {
+		Repository *struct {
+			Ref *struct {
+				AssociatedPullRequests struct {
+					Nodes []struct {
+						Number         int
+						Title          string
+						URL            string
+						IsDraft        bool
+						ReviewDecision string
+						Mergeable      string
+					}
+				}
+			}
+		}
+	}
+	vars := map[string]string{"owner": r.Owner, "name": r.Name, "branch": "refs/heads/" + branch}
+	if err := g.query(ctx, pullRequestQuery, vars, &data); err != nil {
+		return nil, err
+	}
+	if data.Repository == nil {
+		return nil, fmt.Errorf("repository %s/%s not found", r.Owner, r.Name)
+	}
+	ref := data.Repository.Ref
+	if ref == nil || len(ref.AssociatedPullRequests.Nodes) == 0 {
+		return nil, nil
+	}
+
+	n := ref.AssociatedPullRequests.Nodes[0]
+	pr := &PullRequest{
+		Ref:    fmt.Sprintf("PR #%d", n.Number),
+		Number: n.Number,
+		Title:  n.Title,
+		URL:    n.URL,
+		Draft:  n.IsDraft,
+		Review: strings.ToLower(strings.ReplaceAll(n.ReviewDecision, "_", " ")),
+	}
+	switch n.Mergeable {
+	case "MERGEABLE":
+		pr.Merge = "mergeable"
+	case "CONFLICTING":
+		pr.Merge = "conflicts"
+	}
+	return pr, nil
+}
+
+const countsQuery = `query($owner: String!, $name: String!) {
+  repository(owner: $owner, name: $name) {
+    issues(states: OPEN) { totalCount }
+    pullRequests(states: OPEN) { totalCount }
+  }
+}`
+
+// Counts returns the number of open issues and pull requests of r.
+func (g *GitHub) Counts(ctx context.Context, r Remote) (Counts, error) {
+	var data struct {
+		Repository *struct {
+			Issues       struct{ TotalCount int }
+			PullRequests struct{ TotalCount int }
+		}
+	}
+	vars := map[string]string{"owner": r.Owner, "name": r.Name}
+	if err := g.query(ctx, countsQuery, vars, &data); err != nil {
+		return Counts{}, err
+	}
+	if data.Repository == nil {
+		return Counts{}, fmt.Errorf("repository %s/%s not found", r.Owner, r.Name)
+	}
+	return Counts{
+		Issues:       data.Repository.Issues.TotalCount,
+		PullRequests: data.Repository.PullRequests.TotalCount,
+		Kind:         "PR",
+	}, nil
+}
+
+// CI returns the combined state of the workflow runs for the latest commit
+// on branch that has any: running if any run is in progress, failure if
+// any failed, and success otherwise.
+func (g *GitHub) CI(ctx context.Context, r Remote, branch string) (CI, error) {
+	var data struct {
+		WorkflowRuns []struct {
+			Name       string
+			HeadSHA    string `json:"head_sha"`
+			Status     string
+			Conclusion string
+			HTMLURL    string `json:"html_url"`
+		} `json:"workflow_runs"`
+	}
+	path := fmt.Sprintf("repos/%s/%s/actions/runs?branch=%s&per_page=20", r.Owner, r.Name, url.QueryEscape(branch))
+	if err := g.rest(ctx, path, &data); err != nil {
+		return CI{}, err
+	}
+	if len(data.WorkflowRuns) == 0 {
+		return CI{}, nil
+	}
+
+	// Runs are newest first; only those for the newest commit count
+	latest := data.WorkflowRuns[0]
+	ci := CI{State: "success", Name: latest.Name, URL: latest.HTMLURL}
+	for _, run := range data.WorkflowRuns {
+		if run.HeadSHA != latest.HeadSHA {
+			continue
+		}
+		switch {
+		case run.Status != "completed":
+			if ci.State == "success" {
+				ci = CI{State: "running", Name: run.Name, URL: run.HTMLURL}
+			}
+		case run.Conclusion == "failure" || run.Conclusion == "timed_out" || run.Conclusion == "startup_failure":
+			if ci.State != "failure" {
+				ci = CI{State: "failure", Name: run.Name, URL: run.HTMLURL}
+			}
+		}
+	}
+	return ci, nil
+}
+
+// query runs a GraphQL query and decodes its data into out.
+func (g *GitHub) query(ctx context.Context, query string, vars map[string]string, out any) error {
+	var body []byte
+	var err error
+	if g.Token != "" {
+		body, err = g.post(ctx, query, vars)
+	} else {
+		body, err = g.gh(ctx, query, vars)
+	}
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		Data   json.RawMessage
+		Errors []struct{ Message string }
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("github: invalid response: %w", err)
+	}
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("github: %s", resp.Errors[0].Message)
+	}
+	return json.Unmarshal(resp.Data, out)
+}
+
+// rest makes a GET request to a REST API path and decodes the response
+// into out.
+func (g *GitHub) rest(ctx context.Context, path string, out any) error {
+	var body []byte
+	var err error
+	if g.Token != "" {
+		var req *http.Request
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, g.restURL()+"/"+path, nil)
+		if err != nil {
+			return err
+		}
+		body, err = g.do(req)
+	} else {
+		body, err = g.runGH(ctx, "api", path)
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("github: invalid response: %w", err)
+	}
+	return nil
+}
+
+// restURL returns the REST API root matching APIURL.
+func (g *GitHub) restURL() string {
+	if g.APIURL == "" {
+		return "https://api.github.com"
+	}
+	root := strings.TrimSuffix(g.APIURL, "/graphql")
+	if strings.HasSuffix(root, "/api") {
+		// GitHub Enterprise Server: /api/graphql and /api/v3
+		return root + "/v3"
+	}
+	return root
+}
+
+func (g *GitHub) post(ctx context.Context, query string, vars map[string]string) ([]byte, error) {
+	payload, err := json.Marshal(map[string]any{"query": query, "variables": vars})
+	if err != nil {
+		return nil, err
+	}
+	apiURL := g.APIURL
+	if apiURL == "" {
+		apiURL = "https://api.github.com/graphql"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return g.do(req)
+}
+
+// do sends an authenticated request and returns the response body.
+func (g *GitHub) do(req *http.Request) ([]byte, error) {
+	req.Header.Set("Authorization", "bearer "+g.Token)
+	client := g.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(resp.Body); err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("github: %s", resp.Status)
+	}
+	return buf.Bytes(), nil
+}
+
+// gh runs the query with "gh api graphql", which uses gh's stored login.
+func (g *GitHub) gh(ctx context.Context, query string, vars map[string]string) ([]byte, error) {
+	args := []string{"api", "graphql", "-f", "query=" + query}
+	for k, v := range vars {
+		args = append(args, "-f", k+"="+v)
+	}
+	return g.runGH(ctx, args...)
+}
+
+// runGH runs the gh CLI and returns its output.
+func (g *GitHub) runGH(ctx context.Context, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "gh", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, errors.New("gh: " + msg)
+		}
+		return nil, fmt.Errorf("gh: %w", err)
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,176 @@
+package forge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// GitLab queries the REST API of gitlab.com or a self-hosted GitLab.
+type GitLab struct {
+	BaseURL string       // web root, e.g. https://gitlab.com
+	Token   string       // access token; public projects work without one
+	Client  *http.Client // defaults to http.DefaultClient
+}
+
+// NewGitLab returns a client for the GitLab at baseURL (gitlab.com if
+// empty), authenticated with token, the GITLAB_TOKEN environment variable,
+// or the glab CLI's login to the instance.
+func NewGitLab(baseURL, token string) *GitLab {
+	if baseURL == "" {
+		baseURL = "https://gitlab.com"
+	}
+	if token == "" {
+		token = os.Getenv("GITLAB_TOKEN")
+	}
+	if u, err := url.Parse(baseURL); err == nil && token == "" {
+		token = GitLabCLIToken(u.Host)
+	}
+	return &GitLab{BaseURL: strings.TrimSuffix(baseURL, "/"), Token: token}
+}
+
+// Handles reports whether r is on this GitLab's host.
+func (g *GitLab) Handles(r Remote) bool {
+	u, err := url.Parse(g.BaseURL)
+	return err == nil && strings.EqualFold(u.Hostname(), r.Host)
+}
+
+// PullRequest returns the open merge request from branch, or nil.
+func (g *GitLab) PullRequest(ctx context.Context, r Remote, branch string) (*PullRequest, error) {
+	project := g.project(r)
+	var mrs []struct {
+		IID                 int    `json:"iid"`
+		Title               string `json:"title"`
+		WebURL              string `json:"web_url"`
+		Draft               bool   `json:"draft"`
+		HasConflicts        bool   `json:"has_conflicts"`
+		DetailedMergeStatus string `json:"detailed_merge_status"`
+	}
+	if _, err := g.get(ctx, project+"/merge_requests?state=opened&source_branch="+url.QueryEscape(branch), &mrs); err != nil {
+		return nil, err
+	}
+	if len(mrs) == 0 {
+		return nil, nil
+	}
+
+	mr := mrs[0]
+	pr := &PullRequest{
+		Ref:    fmt.Sprintf("MR !%d", mr.IID),
+		Number: mr.IID,
+		Title:  mr.Title,
+		URL:    mr.WebURL,
+		Draft:  mr.Draft,
+	}
+	switch {
+	case mr.HasConflicts:
+		pr.Merge = "conflicts"
+	case mr.DetailedMergeStatus == "mergeable":
+		pr.Merge = "mergeable"
+	}
+
+	// Approval rules need the approvals endpoint; older or restricted
+	// instances may not offer it, so leave the review state unknown then
+	var approvals struct {
+		Approved      bool `json:"approved"`
+		ApprovalsLeft int  `json:"approvals_left"`
+	}
+	if _, err := g.get(ctx, fmt.Sprintf("%s/merge_requests/%d/approvals", project, mr.IID), &approvals); err == nil {
+		switch {
+		case approvals.ApprovalsLeft > 0:
+			pr.Review = "review required"
+		case approvals.Approved:
+			pr.Review = "approved"
+		}
+	}
+	return pr, nil
+}
+
+// Counts returns the number of open issues and merge requests of r, from
+// the total counts GitLab reports in response headers.
+func (g *GitLab) Counts(ctx context.Context, r Remote) (Counts, error) {
+	counts := Counts{Kind: "MR"}
+	var none []struct{}
+	header, err := g.get(ctx, g.project(r)+"/merge_requests?state=opened&per_page=1", &none)
+	if err != nil {
+		return counts, err
+	}
+	counts.PullRequests, _ = strconv.Atoi(header.Get("X-Total"))
+	header, err = g.get(ctx, g.project(r)+"/issues?state=opened&per_page=1", &none)
+	if err != nil {
+		return counts, err
+	}
+	counts.Issues, _ = strconv.Atoi(header.Get("X-Total"))
+	return counts, nil
+}
+
+// CI returns the state of the latest pipeline on branch.
+func (g *GitLab) CI(ctx context.Context, r Remote, branch string) (CI, error) {
+	var pipelines []struct {
+		ID     int    `json:"id"`
+		Status string `json:"status"`
+		WebURL string `json:"web_url"`
+	}
+	if _, err := g.get(ctx, g.project(r)+"/pipelines?per_page=1&ref="+url.QueryEscape(branch), &pipelines); err != nil {
+		return CI{}, err
+	}
+	if len(pipelines) == 0 {
+		return CI{}, nil
+	}
+
+	p := pipelines[0]
+	ci := CI{Name: fmt.Sprintf("pipeline #%d", p.ID), URL: p.WebURL}
+	switch p.Status {
+	case "success":
+		ci.State = "success"
+	case "failed":
+		ci.State = "failure"
+	case "created", "waiting_for_resource", "preparing", "pending", "running", "scheduled":
+		ci.State = "running"
+	}
+	return ci, nil
+}
+
+// project returns the API path of r's project.
+func (g *GitLab) project(r Remote) string {
+	return "projects/" + url.PathEscape(r.Owner+"/"+r.Name)
+}
+
+// get makes a GET request to an API path, decodes the response into out,
+// and returns the response headers.
+func (g *GitLab) get(ctx context.Context, path string, out any) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.BaseURL+"/api/v4/"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if g.Token != "" {
+		// Bearer accepts both personal access tokens and the OAuth tokens
+		// glab logs in with
+		req.Header.Set("Authorization", "Bearer "+g.Token)
+	}
+	client := g.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("gitlab: %s", resp.Status)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return nil, fmt.Errorf("gitlab: invalid response: %w", err)
+	}
+	return resp.Header, nil
+}
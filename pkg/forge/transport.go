@@ -0,0 +1,322 @@
+package forge
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	minBackoff = 30 * time.Second
+	maxBackoff = 30 * time.Minute
+)
+
+// Transport is an http.RoundTripper that keeps the providers within their
+// hosts' API quotas. Requests to each host are rate limited, successful
+// responses are cached for TTL (and revalidated with their ETag after
+// that), and a host that answers 403 or 429 is left alone with exponential
+// backoff, or until its rate limit resets if it says when. While a host is
+// backing off, requests are answered from the cache if possible and fail
+// otherwise. A Transport is safe for concurrent use and is shared by the
+// providers' Clients.
+type Transport struct {
+	Base      http.RoundTripper // defaults to http.DefaultTransport
+	PerMinute int               // requests per host and minute; 0 for no limit
+	TTL       time.Duration     // how long responses are reused; 0 disables caching
+
+	mu    sync.Mutex
+	hosts map[string]*hostState
+	cache map[string]*cached
+}
+
+// hostState is the rate limit and backoff of one host.
+type hostState struct {
+	tokens   float64   // requests that may be made right away
+	refilled time.Time // when tokens was last topped up
+	failures int       // consecutive 403 and 429 responses
+	until    time.Time // no requests before this time
+}
+
+// cached is a response kept for reuse.
+type cached struct {
+	status  int
+	header  http.Header
+	body    []byte
+	fetched time.Time
+}
+
+// NewTransport returns a Transport that allows perMinute requests to each
+// host per minute and caches responses for ttl.
+func NewTransport(perMinute int, ttl time.Duration) *Transport {
+	return &Transport{PerMinute: perMinute, TTL: ttl}
+}
+
+// RoundTrip implements http.RoundTripper. GET requests and GraphQL POST
+// requests, which only query, are cached; the cache key includes the
+// credentials, so providers with different tokens don't share responses.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key, body, err := cacheKey(req)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	host := req.URL.Host
+	entry, fresh := t.lookup(key)
+	if fresh {
+		return entry.response(req), nil
+	}
+	if until, ok := t.backingOff(host); ok {
+		if entry != nil {
+			return entry.response(req), nil
+		}
+		return nil, fmt.Errorf("%s is rate limiting requests, retrying after %s", host, until.Format(time.TimeOnly))
+	}
+	if err := t.wait(req.Context(), host); err != nil {
+		return nil, err
+	}
+
+	if entry != nil && entry.header.Get("ETag") != "" {
+		// Conditional requests that come back 304 don't count against
+		// GitHub's quota
+		req = req.Clone(req.Context())
+		if body != nil {
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		req.Header.Set("If-None-Match", entry.header.Get("ETag"))
+	}
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	t.record(host, resp)
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && entry != nil:
+		resp.Body.Close()
+		t.store(key, entry.status, entry.header, entry.body)
+		return entry.response(req), nil
+	case resp.StatusCode == http.StatusOK && key != "" && t.TTL > 0:
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		t.store(key, resp.StatusCode, resp.Header, data)
+		resp.Body = io.NopCloser(bytes.NewReader(data))
+	}
+	return resp, nil
+}
+
+// cacheKey returns the key req's response is cached under, or "" if it
+// isn't cached, and req's body, which has to be read to tell.
+func cacheKey(req *http.Request) (string, []byte, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	switch {
+	case req.Method == http.MethodGet:
+	case req.Method == http.MethodPost && bytes.Contains(body, []byte(`"query"`)) && !bytes.Contains(body, []byte("mutation")):
+	default:
+		return "", body, nil
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n%s\n", req.Method, req.URL, req.Header.Get("Authorization"))
+	h.Write(body)
+	return string(h.Sum(nil)), body, nil
+}
+
+// lookup returns the cached response under key, if any, and whether it is
+// younger than TTL.
+func (t *Transport) lookup(key string) (*cached, bool) {
+	if key == "" || t.TTL <= 0 {
+		return nil, false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry := t.cache[key]
+	if entry == nil {
+		return nil, false
+	}
+	return entry, time.Since(entry.fetched) < t.TTL
+}
+
+func (t *Transport) store(key string, status int, header http.Header, body []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.cache == nil {
+		t.cache = make(map[string]*cached)
+	}
+	now := time.Now()
+	for k, entry := range t.cache {
+		// Entries this old would be refetched in full anyway
+		if now.Sub(entry.fetched) > t.TTL+maxBackoff {
+			delete(t.cache, k)
+		}
+	}
+	t.cache[key] = &cached{status: status, header: header.Clone(), body: body, fetched: now}
+}
+
+// response returns a copy of the cached response as the answer to req.
+func (c *cached) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", c.status, http.StatusText(c.status)),
+		StatusCode:    c.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        c.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(c.body)),
+		ContentLength: int64(len(c.body)),
+		Request:       req,
+	}
+}
+
+// host returns the state of host. t.mu must be held.
+func (t *Transport) host(host string) *hostState {
+	if t.hosts == nil {
+		t.hosts = make(map[string]*hostState)
+	}
+	s := t.hosts[host]
+	if s == nil {
+		s = &hostState{tokens: float64(t.PerMinute), refilled: time.Now()}
+		t.hosts[host] = s
+	}
+	return s
+}
+
+// backingOff reports whether requests to host are held back, and until when.
+func (t *Transport) backingOff(host string) (time.Time, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := t.host(host)
+	return s.until, time.Now().Before(s.until)
+}
+
+// wait blocks until a request to host is within the rate limit, or ctx is
+// done.
+func (t *Transport) wait(ctx context.Context, host string) error {
+	if t.PerMinute <= 0 {
+		return nil
+	}
+	rate := float64(t.PerMinute) / float64(time.Minute)
+	for {
+		t.mu.Lock()
+		s := t.host(host)
+		now := time.Now()
+		s.tokens = min(s.tokens+float64(now.Sub(s.refilled))*rate, float64(t.PerMinute))
+		s.refilled = now
+		if s.tokens >= 1 {
+			s.tokens--
+			t.mu.Unlock()
+			return nil
+		}
+		delay := time.Duration((1 - s.tokens) / rate)
+		t.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// record updates host's backoff from resp: a rate limited response doubles
+// it, any other response clears it, and a quota reported as used up holds
+// requests back until it resets.
+func (t *Transport) record(host string, resp *http.Response) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := t.host(host)
+	now := time.Now()
+
+	if !rateLimited(resp) {
+		s.failures = 0
+		if remaining(resp.Header) == 0 {
+			if reset, ok := resetTime(resp.Header, now); ok {
+				s.until = reset
+				slog.Warn("API quota used up", "host", host, "until", reset)
+			}
+		}
+		return
+	}
+
+	backoff := minBackoff << min(s.failures, 6)
+	s.failures++
+	until := now.Add(min(backoff, maxBackoff))
+	if reset, ok := resetTime(resp.Header, now); ok {
+		until = reset
+	}
+	s.until = until
+	slog.Warn("API rate limited", "host", host, "status", resp.StatusCode, "until", until)
+}
+
+// rateLimited reports whether resp refuses a request for going over a rate
+// limit: a 429, or a 403 saying so with an exhausted quota or Retry-After,
+// as GitHub's secondary rate limits do. Other 403s, such as a token without
+// access to a repository, aren't the host's to back off from.
+func rateLimited(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusForbidden:
+		return remaining(resp.Header) == 0 || resp.Header.Get("Retry-After") != ""
+	}
+	return false
+}
+
+// remaining returns the number of requests left in the quota as reported
+// by GitHub and Gitea (X-RateLimit-Remaining) or GitLab (RateLimit-Remaining),
+// or -1 if the response doesn't say.
+func remaining(h http.Header) int {
+	for _, name := range []string{"X-RateLimit-Remaining", "RateLimit-Remaining"} {
+		if n, err := strconv.Atoi(h.Get(name)); err == nil {
+			return n
+		}
+	}
+	return -1
+}
+
+// resetTime returns when requests may be made again according to the
+// Retry-After header, or the quota's reset time as a Unix timestamp.
+func resetTime(h http.Header, now time.Time) (time.Time, bool) {
+	if s := h.Get("Retry-After"); s != "" {
+		if secs, err := strconv.Atoi(s); err == nil {
+			return now.Add(time.Duration(secs) * time.Second), true
+		}
+		if t, err := http.ParseTime(s); err == nil {
+			return t, true
+		}
+	}
+	for _, name := range []string{"X-RateLimit-Reset", "RateLimit-Reset"} {
+		if secs, err := strconv.ParseInt(h.Get(name), 10, 64); err == nil && secs > 0 {
+			reset := time.Unix(secs, 0)
+			if reset.After(now) {
+				return reset, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
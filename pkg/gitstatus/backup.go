@@ -0,0 +1,184 @@
+package gitstatus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// backupRefs is where backups are kept. Refs there aren't branches or tags,
+// so they stay out of the way, but keep the snapshots from being garbage
+// collected.
+const backupRefs = "refs/gitmoni/backups/"
+
+// maxBackups is how many backups are kept per repository; older ones are
+// deleted as new ones are taken.
+const maxBackups = 20
+
+// Backup is a snapshot of a repository's working tree, untracked files
+// included, taken before a destructive action so the action can be undone.
+// It is a commit whose parent is HEAD at the time.
+type Backup struct {
+	Ref    string // e.g. refs/gitmoni/backups/1767225600000000000
+	Hash   string
+	Date   time.Time
+	Action string   // what was about to be done, e.g. "Discard main.go"
+	Head   string   // HEAD when it was taken; empty if there were no commits
+	Paths  []string // what the action touched; empty for the whole tree
+}
+
+// CreateBackup snapshots repoPath's working tree before action, which
+// touches paths, or the whole tree if there are none. The index and working
+// tree are left as they are. Repositories on other hosts can't be backed
+// up.
+func CreateBackup(repoPath, action string, paths []string) (Backup, error) {
+	return CreateBackupContext(context.Background(), repoPath, action, paths)
+}
+
+// CreateBackupContext is like CreateBackup but runs git with ctx.
+func CreateBackupContext(ctx context.Context, repoPath, action string, paths []string) (Backup, error) {
+	if IsSSH(repoPath) {
+		return Backup{}, errors.New("backups on another host are not supported")
+	}
+	b := Backup{Action: action, Paths: paths, Date: time.Now()}
+	if out, err := RunContext(ctx, repoPath, "rev-parse", "--verify", "--quiet", "HEAD"); err == nil {
+		b.Head = strings.TrimSpace(string(out))
+	}
+
+	// Stage everything in a temporary index, which leaves the real one and
+	// what is staged in it alone
+	dir, err := os.MkdirTemp("", "gitmoni-backup-")
+	if err != nil {
+		return Backup{}, err
+	}
+	defer os.RemoveAll(dir)
+	index := filepath.Join(dir, "index")
+	if b.Head != "" {
+		if _, err := runWithIndex(ctx, repoPath, index, "read-tree", b.Head); err != nil {
+			return Backup{}, err
+		}
+	}
+	if _, err := runWithIndex(ctx, repoPath, index, "add", "--all"); err != nil {
+		return Backup{}, err
+	}
+	tree, err := runWithIndex(ctx, repoPath, index, "write-tree")
+	if err != nil {
+		return Backup{}, err
+	}
+
+	message := action + "\n"
+	if len(paths) > 0 {
+		message += "\n"
+		for _, p := range paths {
+			message += "Path: " + p + "\n"
+		}
+	}
+	// The snapshot is gitmoni's, whoever is configured to author commits
+	args := []string{"-c", "user.name=gitmoni", "-c", "user.email=gitmoni@localhost", "commit-tree", strings.TrimSpace(string(tree)), "-m", message}
+	if b.Head != "" {
+		args = append(args, "-p", b.Head)
+	}
+	out, err := RunContext(ctx, repoPath, args...)
+	if err != nil {
+		return Backup{}, err
+	}
+	b.Hash = strings.TrimSpace(string(out))
+	b.Ref = backupRefs + strconv.FormatInt(b.Date.UnixNano(), 10)
+	if _, err := RunContext(ctx, repoPath, "update-ref", b.Ref, b.Hash); err != nil {
+		return Backup{}, err
+	}
+
+	// Forget the oldest backups
+	if backups, err := Backups(repoPath); err == nil {
+		for _, old := range backups[min(len(backups), maxBackups):] {
+			if err := DropBackup(repoPath, old); err != nil {
+				return b, err
+			}
+		}
+	}
+	return b, nil
+}
+
+// runWithIndex runs git in repoPath with index as its index file.
+func runWithIndex(ctx context.Context, repoPath, index string, args ...string) ([]byte, error) {
+	return run(ctx, Command{Dir: repoPath, Args: args, Env: []string{"GIT_INDEX_FILE=" + index}})
+}
+
+// Backups returns repoPath's backups, newest first.
+func Backups(repoPath string) ([]Backup, error) {
+	if IsSSH(repoPath) {
+		return nil, nil
+	}
+	out, err := Run(repoPath, "for-each-ref", "--sort=-refname", "--format=%(refname)%1f%(objectname)%1f%(parent)%1f%(contents)%1e", backupRefs)
+	if err != nil {
+		return nil, err
+	}
+	var backups []Backup
+	for _, record := range strings.Split(string(out), "\x1e") {
+		fields := strings.Split(strings.TrimPrefix(record, "\n"), "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+		nanos, err := strconv.ParseInt(strings.TrimPrefix(fields[0], backupRefs), 10, 64)
+		if err != nil {
+			continue
+		}
+		b := Backup{Ref: fields[0], Hash: fields[1], Head: fields[2], Date: time.Unix(0, nanos)}
+		for i, line := range strings.Split(strings.TrimSpace(fields[3]), "\n") {
+			if i == 0 {
+				b.Action = line
+			} else if p, ok := strings.CutPrefix(line, "Path: "); ok {
+				b.Paths = append(b.Paths, p)
+			}
+		}
+		backups = append(backups, b)
+	}
+	return backups, nil
+}
+
+// BackupDiff returns the stat and patch of what b saved: how the paths it
+// covers differed from HEAD when it was taken.
+func BackupDiff(repoPath string, b Backup) (string, error) {
+	from := b.Head
+	if from == "" {
+		// The empty tree
+		from = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+	}
+	args := []string{"diff", "--stat", "--patch", from, b.Hash, "--"}
+	out, err := Run(repoPath, append(args, b.Paths...)...)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// RestoreBackup puts the paths b covers back as they were when it was
+// taken, leaving them unstaged. A backup of the whole tree, taken before a
+// reset, also moves the current branch back to where HEAD was.
+func RestoreBackup(repoPath string, b Backup) error {
+	paths := b.Paths
+	if len(paths) == 0 {
+		if b.Head != "" {
+			if _, err := Run(repoPath, "reset", "--quiet", "--hard", b.Head); err != nil {
+				return err
+			}
+		}
+		paths = []string{":/"}
+	}
+	_, err := Run(repoPath, append([]string{"restore", "--source=" + b.Hash, "--worktree", "--"}, paths...)...)
+	if err != nil {
+		return fmt.Errorf("restoring %s: %w", b.Ref, err)
+	}
+	return nil
+}
+
+// DropBackup deletes b.
+func DropBackup(repoPath string, b Backup) error {
+	_, err := Run(repoPath, "update-ref", "-d", b.Ref)
+	return err
+}
@@ -0,0 +1,82 @@
+package gitstatus
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BlameLine is one line of git blame output.
+type BlameLine struct {
+	Hash    string
+	Author  string
+	Date    time.Time
+	Summary string
+	Number  int
+	Content string
+}
+
+// IsUncommitted reports whether the line has local changes not yet committed.
+func (l BlameLine) IsUncommitted() bool {
+	return strings.Trim(l.Hash, "0") == ""
+}
+
+// Blame returns per-line blame information for a file in the working tree.
+func Blame(repoPath, filePath string) ([]BlameLine, error) {
+	output, err := Run(repoPath, "blame", "--porcelain", "--", filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Porcelain output only describes each commit the first time it
+	// appears, so remember commit details by hash.
+	type commitInfo struct {
+		author  string
+		date    time.Time
+		summary string
+	}
+	commits := make(map[string]*commitInfo)
+
+	var lines []BlameLine
+	var current *BlameLine
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.HasPrefix(line, "\t") {
+			if current != nil {
+				info := commits[current.Hash]
+				current.Author, current.Date, current.Summary = info.author, info.date, info.summary
+				current.Content = line[1:]
+				lines = append(lines, *current)
+				current = nil
+			}
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if current == nil {
+			// Header: <hash> <orig-line> <final-line> [<group-size>], with
+			// a SHA-1 or, in SHA-256 repositories, a SHA-256 hash
+			if len(fields) < 3 || len(fields[0]) != 40 && len(fields[0]) != 64 {
+				continue
+			}
+			number, _ := strconv.Atoi(fields[2])
+			current = &BlameLine{Hash: fields[0], Number: number}
+			if commits[current.Hash] == nil {
+				commits[current.Hash] = &commitInfo{}
+			}
+			continue
+		}
+
+		info := commits[current.Hash]
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "author":
+			info.author = value
+		case "author-time":
+			unix, _ := strconv.ParseInt(value, 10, 64)
+			info.date = time.Unix(unix, 0)
+		case "summary":
+			info.summary = value
+		}
+	}
+	return lines, nil
+}
@@ -0,0 +1,128 @@
+package gitstatus
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RemoteBranch is a remote-tracking branch and its latest commit.
+type RemoteBranch struct {
+	Name    string // e.g. "origin/feature"
+	Short   string
+	Author  string
+	Date    time.Time
+	Subject string
+}
+
+// RemoteBranches returns the repo's remote-tracking branches, most
+// recently committed first. Symbolic refs such as origin/HEAD are skipped.
+func RemoteBranches(repoPath string) ([]RemoteBranch, error) {
+	output, err := Run(repoPath, "for-each-ref", "--sort=-committerdate",
+		"--format=%(refname:short)%1f%(objectname:short)%1f%(authorname)%1f%(committerdate:unix)%1f%(contents:subject)%1f%(symref)",
+		"refs/remotes")
+	if err != nil {
+		return nil, err
+	}
+
+	var branches []RemoteBranch
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 6 || fields[5] != "" {
+			continue
+		}
+		unix, _ := strconv.ParseInt(fields[3], 10, 64)
+		branches = append(branches, RemoteBranch{
+			Name:    fields[0],
+			Short:   fields[1],
+			Author:  fields[2],
+			Date:    time.Unix(unix, 0),
+			Subject: fields[4],
+		})
+	}
+	return branches, nil
+}
+
+// LocalBranch is a local branch, its latest commit, and how it compares to
+// its upstream.
+type LocalBranch struct {
+	Name     string
+	Upstream string // e.g. "origin/main", or "" if none is set
+	Gone     bool   // the upstream was deleted on the remote
+	Ahead    int
+	Behind   int
+	Date     time.Time
+	Subject  string
+}
+
+// LocalBranches returns the repo's local branches, most recently committed
+// first.
+func LocalBranches(repoPath string) ([]LocalBranch, error) {
+	output, err := Run(repoPath, "for-each-ref", "--sort=-committerdate",
+		"--format=%(refname:short)%1f%(upstream:short)%1f%(upstream:track,nobracket)%1f%(committerdate:unix)%1f%(contents:subject)",
+		"refs/heads")
+	if err != nil {
+		return nil, err
+	}
+
+	var branches []LocalBranch
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 5 {
+			continue
+		}
+		unix, _ := strconv.ParseInt(fields[3], 10, 64)
+		b := LocalBranch{
+			Name:     fields[0],
+			Upstream: fields[1],
+			Date:     time.Unix(unix, 0),
+			Subject:  fields[4],
+		}
+		// The track field reads e.g. "ahead 1, behind 2" or "gone"
+		for _, part := range strings.Split(fields[2], ", ") {
+			kind, count, _ := strings.Cut(part, " ")
+			n, _ := strconv.Atoi(count)
+			switch kind {
+			case "gone":
+				b.Gone = true
+			case "ahead":
+				b.Ahead = n
+			case "behind":
+				b.Behind = n
+			}
+		}
+		branches = append(branches, b)
+	}
+	return branches, nil
+}
+
+// BranchCreated returns when branch was created, judged by the oldest entry
+// of its reflog. It reports false if the reflog has no entries, such as
+// when they have expired.
+func BranchCreated(repoPath, branch string) (time.Time, bool) {
+	// Each entry reads e.g. "main@{1700000000}"
+	output, err := Run(repoPath, "reflog", "show", "--date=unix", "--format=%gd", "refs/heads/"+branch, "--")
+	if err != nil {
+		return time.Time{}, false
+	}
+	lines := strings.Fields(string(output))
+	if len(lines) == 0 {
+		return time.Time{}, false
+	}
+	oldest := lines[len(lines)-1]
+	_, date, _ := strings.Cut(oldest, "@{")
+	unix, err := strconv.ParseInt(strings.TrimSuffix(date, "}"), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(unix, 0), true
+}
+
+// RemoteURL returns the fetch URL of remote in repo, e.g. "origin".
+func RemoteURL(repo, remote string) (string, error) {
+	output, err := Run(repo, "remote", "get-url", remote)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,231 @@
+package gitstatus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// CommitOptions are the command line options of a commit.
+type CommitOptions struct {
+	NoVerify bool // skip the pre-commit and commit-msg hooks
+	Signoff  bool // add a Signed-off-by trailer
+	Sign     bool // sign the commit with the key git is configured with
+}
+
+// CreateCommit commits the staged changes in repoPath with message, staging every
+// change first if nothing is staged. Hooks run as they would for git
+// commit on the command line; if the repository is set up for the
+// pre-commit framework but its hook isn't installed, "pre-commit run" is
+// run too. The output of hooks and git is copied to output as it is
+// printed. A failing hook aborts the commit.
+func CreateCommit(ctx context.Context, repoPath, message string, opts CommitOptions, output io.Writer) error {
+	if IsSSH(repoPath) {
+		return errors.New("committing on another host is not supported")
+	}
+	if strings.TrimSpace(message) == "" {
+		return errors.New("empty commit message")
+	}
+
+	// "diff --cached --quiet" exits 1 when something is staged. A
+	// repository scoped to a subdirectory only stages changes in it.
+	if _, err := RunContext(ctx, repoPath, append([]string{"diff", "--cached", "--quiet"}, pathspec(repoPath)...)...); err == nil {
+		if _, err := RunContext(ctx, repoPath, append([]string{"add", "--all"}, pathspec(repoPath)...)...); err != nil {
+			return err
+		}
+	}
+
+	if !opts.NoVerify && needsPreCommitRun(repoPath) {
+		if err := runStreaming(ctx, repoPath, nil, output, "pre-commit", "run"); err != nil {
+			return err
+		}
+	}
+
+	args := []string{"commit", "--cleanup=strip", "--file=-"}
+	if opts.NoVerify {
+		args = append(args, "--no-verify")
+	}
+	if opts.Signoff {
+		args = append(args, "--signoff")
+	}
+	if opts.Sign {
+		args = append(args, "--gpg-sign")
+	}
+	return runStreaming(ctx, repoPath, strings.NewReader(message), output, "git", args...)
+}
+
+// UserEmail returns the user.email that commits in repoPath are made with,
+// from its own config or the global one, or "" if none is set.
+func UserEmail(repoPath string) string {
+	out, err := Run(repoPath, "config", "user.email")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// CommitTemplate returns the contents of the file git's commit.template
+// setting names for repoPath, or "" if there is none.
+func CommitTemplate(repoPath string) string {
+	if IsSSH(repoPath) {
+		return ""
+	}
+	out, err := Run(repoPath, "config", "--path", "commit.template")
+	if err != nil {
+		return ""
+	}
+	path := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(RepoDir(repoPath), path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		slog.Debug("commit template unreadable", "repo", repoPath, "err", err)
+		return ""
+	}
+	return string(data)
+}
+
+// needsPreCommitRun reports whether repoPath has a pre-commit framework
+// configuration that git won't run by itself because no pre-commit hook is
+// installed, and the framework is available to run it.
+func needsPreCommitRun(repoPath string) bool {
+	if _, err := os.Stat(filepath.Join(RepoDir(repoPath), ".pre-commit-config.yaml")); err != nil {
+		return false
+	}
+	// --git-path follows core.hooksPath
+	out, err := Run(repoPath, "rev-parse", "--git-path", "hooks/pre-commit")
+	if err != nil {
+		return false
+	}
+	hook := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(hook) {
+		hook = filepath.Join(RepoDir(repoPath), hook)
+	}
+	if _, err := os.Stat(hook); err == nil {
+		return false
+	}
+	_, err = exec.LookPath("pre-commit")
+	return err == nil
+}
+
+// runStreaming runs name with args in dir like RunContext, but copies its
+// stdout and stderr to output as they are written. If git fails, the error
+// is a *Error whose Stderr holds the combined output.
+func runStreaming(ctx context.Context, dir string, stdin io.Reader, output io.Writer, name string, args ...string) error {
+	cmd := Command{Dir: dir, Args: args, Stdin: stdin, Output: output}
+	if cmd.Output == nil {
+		cmd.Output = io.Discard
+	}
+	if name != "git" {
+		cmd.Program = name
+	}
+	_, err := run(ctx, cmd)
+	var gitErr *Error
+	if name != "git" && errors.As(err, &gitErr) {
+		return fmt.Errorf("%s failed: %w\n%s", name, gitErr.Err, gitErr.Stderr)
+	}
+	return err
+}
+
+// scissors marks the end of a commit message being edited; git and gitmoni
+// ignore everything below it, which is where the diff is shown.
+const scissors = "# ------------------------ >8 ------------------------"
+
+// EditorCommand returns the editor git would open for a commit message in
+// repoPath: GIT_EDITOR, core.editor, VISUAL, or EDITOR, falling back to vi.
+// It is a shell command, run with the file to edit as its argument.
+func EditorCommand(repoPath string) (string, error) {
+	out, err := Run(repoPath, "var", "GIT_EDITOR")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// PrepareCommitMessage writes message to the repository's COMMIT_EDITMSG
+// for editing, followed as in git commit by comments listing what will be
+// committed and, unless git's commit.verbose is false, the diff, and
+// returns the file's path.
+func PrepareCommitMessage(repoPath, message string) (string, error) {
+	if IsSSH(repoPath) {
+		return "", errors.New("committing on another host is not supported")
+	}
+	out, err := Run(repoPath, "rev-parse", "--git-path", "COMMIT_EDITMSG")
+	if err != nil {
+		return "", err
+	}
+	path := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(RepoDir(repoPath), path)
+	}
+
+	// CreateCommit stages everything if nothing is staged
+	staged := true
+	if _, err := Run(repoPath, append([]string{"diff", "--cached", "--quiet"}, pathspec(repoPath)...)...); err == nil {
+		staged = false
+	}
+	out, err = Run(repoPath, append([]string{"status", "--short"}, pathspec(repoPath)...)...)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.TrimRight(message, "\n"))
+	b.WriteString("\n\n")
+	b.WriteString("# Please enter the commit message for your changes. Lines starting\n")
+	b.WriteString("# with '#' will be ignored, and an empty message aborts the commit.\n#\n")
+	b.WriteString("# Changes to be committed:\n")
+	for _, line := range strings.Split(strings.TrimRight(string(out), "\n"), "\n") {
+		if len(line) < 3 || staged && (line[0] == ' ' || line[0] == '?') {
+			continue
+		}
+		b.WriteString("#\t" + line + "\n")
+	}
+	if !staged {
+		b.WriteString("#\n# Nothing is staged, so all of these will be committed.\n")
+	}
+
+	if verbose, err := Run(repoPath, "config", "--bool", "commit.verbose"); err != nil || strings.TrimSpace(string(verbose)) != "false" {
+		args := []string{"diff", "--no-color", "--no-ext-diff", "HEAD"}
+		if staged {
+			args = []string{"diff", "--no-color", "--no-ext-diff", "--cached"}
+		}
+		if diff, err := Run(repoPath, append(args, pathspec(repoPath)...)...); err == nil && len(diff) > 0 {
+			b.WriteString(scissors + "\n")
+			b.WriteString("# Do not modify or remove the line above.\n")
+			b.WriteString("# Everything below it will be ignored.\n")
+			b.Write(diff)
+		}
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// ReadCommitMessage reads a message written by PrepareCommitMessage back
+// after editing, without the comments and the diff below the scissors
+// line. It returns "" if nothing but comments is left.
+func ReadCommitMessage(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == scissors {
+			break
+		}
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, strings.TrimRight(line, " \t\r"))
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")), nil
+}
@@ -0,0 +1,106 @@
+package gitstatus
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DirtyTracker remembers when each repository last went from clean to
+// having uncommitted changes, in a file shared by every gitmoni process, so
+// a repository's dirty age survives restarts and doesn't depend on the
+// modification times of its files, which editing or checking out resets.
+type DirtyTracker struct {
+	path  string
+	mu    sync.Mutex
+	since map[string]time.Time
+}
+
+// DefaultDirtyPath returns gitmoni/dirty.json in the user's cache directory.
+func DefaultDirtyPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gitmoni", "dirty.json"), nil
+}
+
+// NewDirtyTracker returns a tracker kept in the file at path.
+func NewDirtyTracker(path string) *DirtyTracker {
+	t := &DirtyTracker{path: path}
+	t.since = t.load()
+	return t
+}
+
+// Observe sets status.DirtySince from the record of its repository,
+// starting a record if the repository has just become dirty and removing
+// it if the repository is clean. A repository already dirty when it is
+// first seen is dated by its oldest changed file. Statuses with errors
+// leave the record alone.
+func (t *DirtyTracker) Observe(status *Status) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	since, known := t.since[status.Path]
+	switch {
+	case status.HasError, len(status.Files) > 0 && known:
+		status.DirtySince = since
+		return
+	case len(status.Files) == 0 && !known:
+		return
+	}
+
+	// The repository went dirty or clean: update the file, keeping what
+	// other processes recorded in the meantime
+	t.since = t.load()
+	if len(status.Files) == 0 {
+		delete(t.since, status.Path)
+	} else if since, ok := t.since[status.Path]; ok {
+		status.DirtySince = since
+		return
+	} else {
+		status.DirtySince = time.Now().Add(-DirtyAge(*status)).Truncate(time.Second)
+		t.since[status.Path] = status.DirtySince
+	}
+	if err := t.save(); err != nil {
+		slog.Warn("saving dirty times failed", "path", t.path, "err", err)
+	}
+}
+
+func (t *DirtyTracker) load() map[string]time.Time {
+	since := make(map[string]time.Time)
+	data, err := os.ReadFile(t.path)
+	if err != nil {
+		return since
+	}
+	if err := json.Unmarshal(data, &since); err != nil {
+		slog.Warn("ignoring invalid dirty times", "path", t.path, "err", err)
+	}
+	return since
+}
+
+// save writes the records to the file, replacing it atomically.
+func (t *DirtyTracker) save() error {
+	data, err := json.MarshalIndent(t.since, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(t.path), ".dirty-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), t.path)
+}
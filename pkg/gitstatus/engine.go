@@ -0,0 +1,43 @@
+package gitstatus
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// CheckAll checks the status of each repository concurrently and returns
+// the results keyed by path. A panic while checking a repository is
+// re-raised on the calling goroutine, where the caller can recover it.
+func CheckAll(repos []string) map[string]Status {
+	return checkAll(repos, Check)
+}
+
+func checkAll(repos []string, check func(repo string) Status) map[string]Status {
+	statuses := make(map[string]Status, len(repos))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var panicked any
+	for _, repo := range repos {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					mu.Lock()
+					panicked = fmt.Sprintf("checking %s: %v\n\n%s", repo, r, debug.Stack())
+					mu.Unlock()
+				}
+			}()
+			status := check(repo)
+			mu.Lock()
+			statuses[repo] = status
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if panicked != nil {
+		panic(panicked)
+	}
+	return statuses
+}
@@ -0,0 +1,117 @@
+package gitstatus
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// ErrorKind is the category of a failed git command, which says more about
+// what to do about it than git's message does.
+type ErrorKind string
+
+// Error kinds. A failure that fits none of them has no kind.
+const (
+	ErrorAuth    ErrorKind = "auth"    // credentials missing or rejected
+	ErrorNetwork ErrorKind = "network" // the host can't be reached
+	ErrorTimeout ErrorKind = "timeout" // the host or git took too long
+	ErrorCorrupt ErrorKind = "corrupt" // the repository's objects or index are damaged
+	ErrorBusy    ErrorKind = "busy"    // another process holds a lock in the repository
+)
+
+// Message describes the kind of failure for the user.
+func (k ErrorKind) Message() string {
+	switch k {
+	case ErrorAuth:
+		return "authentication required"
+	case ErrorNetwork:
+		return "host unreachable"
+	case ErrorTimeout:
+		return "timed out"
+	case ErrorCorrupt:
+		return "repository corrupt"
+	case ErrorBusy:
+		return "repo busy (locked by another process)"
+	}
+	return ""
+}
+
+// errorPatterns are the phrases in git's, ssh's, and curl's messages that
+// give away each kind of failure, in lower case. Kinds are tried in order,
+// as a message can contain phrases of several.
+var errorPatterns = []struct {
+	kind    ErrorKind
+	phrases []string
+}{
+	{ErrorBusy, []string{".lock': file exists"}},
+	{ErrorAuth, []string{
+		"authentication failed",
+		"permission denied (publickey",
+		"could not read username",
+		"could not read password",
+		"terminal prompts disabled",
+		"invalid username or password",
+		"host key verification failed",
+		"http basic: access denied",
+		"the requested url returned error: 401",
+		"the requested url returned error: 403",
+		"authentication required", // go-git
+		"authorization failed",
+	}},
+	{ErrorTimeout, []string{"timed out", "timeout"}},
+	{ErrorNetwork, []string{
+		"could not resolve host",
+		"could not resolve hostname",
+		"name or service not known",
+		"temporary failure in name resolution",
+		"connection refused",
+		"connection reset",
+		"connection closed",
+		"network is unreachable",
+		"no route to host",
+		"failed to connect to",
+	}},
+	{ErrorCorrupt, []string{
+		"corrupt",
+		"bad object",
+		"bad tree",
+		"bad index file",
+		"index file smaller than expected",
+		"unknown index entry format",
+		"loose object",
+		"object file",
+		"invalid sha1 pointer",
+		"did not receive expected object",
+		"missing blob",
+		"missing tree",
+	}},
+}
+
+// Kind classifies the failure by git's message, or by a deadline that ran
+// out, and returns "" if it fits no kind.
+func (e *Error) Kind() ErrorKind {
+	stderr := strings.ToLower(e.Stderr)
+	for _, p := range errorPatterns {
+		for _, phrase := range p.phrases {
+			if strings.Contains(stderr, phrase) {
+				return p.kind
+			}
+		}
+	}
+	if errors.Is(e.Err, context.DeadlineExceeded) {
+		return ErrorTimeout
+	}
+	return ""
+}
+
+// ClassifyError returns the kind of err if it is a *Error, or else "".
+func ClassifyError(err error) ErrorKind {
+	var gitErr *Error
+	if errors.As(err, &gitErr) {
+		return gitErr.Kind()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorTimeout
+	}
+	return ""
+}
@@ -0,0 +1,143 @@
+package gitstatus
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Git's file modes, as shown in diffs.
+const (
+	ModeFile       = "100644"
+	ModeExecutable = "100755"
+	ModeSymlink    = "120000"
+)
+
+// FileChange is how a changed file's mode and size differ from HEAD.
+type FileChange struct {
+	OldMode string // empty if the file isn't in HEAD
+	NewMode string // empty if the file was deleted
+	OldSize int64  // -1 if unknown
+	NewSize int64  // -1 if unknown
+}
+
+// ModeChanged reports whether the file's mode changed, e.g. it was made
+// executable or replaced by a symlink.
+func (c FileChange) ModeChanged() bool {
+	return c.OldMode != "" && c.NewMode != "" && c.OldMode != c.NewMode
+}
+
+// FileChanges returns the mode and size changes of the changed files of a
+// local repository, by path. Repositories on other hosts return nil, as
+// their working trees can't be read directly.
+func FileChanges(repoPath string, files []File) (map[string]FileChange, error) {
+	return FileChangesContext(context.Background(), repoPath, files)
+}
+
+// FileChangesContext is like FileChanges but runs git with ctx.
+func FileChangesContext(ctx context.Context, repoPath string, files []File) (map[string]FileChange, error) {
+	if IsSSH(repoPath) || len(files) == 0 {
+		return nil, nil
+	}
+	root := RepoDir(repoPath)
+
+	// Tracked files: modes from the diff against HEAD, sizes of the old
+	// blobs from cat-file
+	out, err := RunContext(ctx, repoPath, append([]string{"diff", "HEAD", "--raw", "-z", "--no-renames", "--no-abbrev"}, pathspec(repoPath)...)...)
+	if err != nil {
+		return nil, err
+	}
+	changes := map[string]FileChange{}
+	var blobs []string
+	var blobPaths []string
+	fields := strings.Split(string(out), "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+		// :oldmode newmode oldsha newsha status NUL path NUL
+		meta := strings.Fields(strings.TrimPrefix(fields[i], ":"))
+		if len(meta) < 5 {
+			continue
+		}
+		path := fields[i+1]
+		change := FileChange{OldMode: meta[0], NewMode: meta[1], OldSize: -1, NewSize: -1}
+		if change.OldMode == "000000" {
+			change.OldMode = ""
+		} else {
+			blobs = append(blobs, meta[2])
+			blobPaths = append(blobPaths, path)
+		}
+		if change.NewMode == "000000" {
+			change.NewMode = ""
+		}
+		changes[path] = change
+	}
+	if sizes, err := blobSizes(ctx, root, blobs); err == nil {
+		for i, path := range blobPaths {
+			change := changes[path]
+			change.OldSize = sizes[i]
+			changes[path] = change
+		}
+	}
+
+	// New sizes come from the working tree, along with the modes of
+	// untracked files
+	for _, f := range files {
+		path := strings.TrimSuffix(f.Path, "/")
+		change, tracked := changes[path]
+		if !tracked {
+			if f.Status != "??" {
+				continue
+			}
+			change = FileChange{OldSize: -1, NewSize: -1}
+		}
+		info, err := os.Lstat(filepath.Join(root, filepath.FromSlash(path)))
+		if err != nil || info.IsDir() {
+			changes[path] = change
+			continue
+		}
+		change.NewSize = info.Size()
+		if !tracked {
+			change.NewMode = ModeFile
+			if info.Mode()&os.ModeSymlink != 0 {
+				change.NewMode = ModeSymlink
+			} else if info.Mode()&0o111 != 0 {
+				change.NewMode = ModeExecutable
+			}
+		}
+		changes[path] = change
+	}
+	return changes, nil
+}
+
+// blobSizes returns the sizes of the blobs with the given ids, in order.
+func blobSizes(ctx context.Context, root string, ids []string) ([]int64, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var out bytes.Buffer
+	stdin := strings.NewReader(strings.Join(ids, "\n") + "\n")
+	if err := runStreaming(ctx, root, stdin, &out, "git", "cat-file", "--batch-check=%(objectsize)"); err != nil {
+		return nil, err
+	}
+	sizes := make([]int64, len(ids))
+	scanner := bufio.NewScanner(&out)
+	for i := range sizes {
+		sizes[i] = -1
+		if !scanner.Scan() {
+			continue
+		}
+		if n, err := strconv.ParseInt(scanner.Text(), 10, 64); err == nil {
+			sizes[i] = n
+		}
+	}
+	return sizes, nil
+}
+
+// FileAtHead returns the content of the file at path, relative to the
+// repository's root, as committed at HEAD.
+func FileAtHead(repoPath, path string) ([]byte, error) {
+	return Run(repoPath, "show", "HEAD:"+path)
+}
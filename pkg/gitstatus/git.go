@@ -0,0 +1,672 @@
+// Package gitstatus inspects and acts on git repositories by running the git
+// command line. It provides the multi-repository status engine behind
+// gitmoni: working tree and upstream status, diffs, history, refs, and the
+// git operations the TUI offers.
+//
+// Every function takes the path of a repository's working tree, or an
+// ssh:// URL for one on another machine. Failed git commands are reported
+// as *Error values carrying the command and stderr. Commands run through a
+// Runner, which a context can carry; see WithRunner.
+package gitstatus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Status is a snapshot of a repository's working tree and upstream state.
+type Status struct {
+	Path         string    `json:"path"`
+	Branch       string    `json:"branch"`
+	Files        []File    `json:"files"`
+	Ignored      int       `json:"ignored,omitempty"` // changed files left out by ignore patterns or as untracked
+	IsRepo       bool      `json:"is_repo"`
+	HasError     bool      `json:"has_error"`
+	Error        string    `json:"error,omitempty"`
+	ErrorDetail  *Error    `json:"-"`                    // Failed command behind Error, if any
+	ErrorKind    ErrorKind `json:"error_kind,omitempty"` // kind of Error, or of a failed fetch in RemoteStatus
+	HasRemote    bool      `json:"has_remote"`
+	NeedsPull    bool      `json:"needs_pull"`
+	RemoteStatus string    `json:"remote_status"`
+	Ahead        int       `json:"ahead"`  // commits not on the upstream
+	Behind       int       `json:"behind"` // upstream commits not merged
+	// DefaultBranch is the branch origin/HEAD points to, if known
+	DefaultBranch string `json:"default_branch,omitempty"`
+	// DirtySince is when the working tree last went from clean to changed,
+	// if a DirtyTracker has seen it
+	DirtySince time.Time `json:"dirty_since,omitzero"`
+}
+
+// OffDefault reports whether a branch other than the remote's default
+// branch is checked out.
+func (s Status) OffDefault() bool {
+	return s.Branch != "" && s.DefaultBranch != "" && s.Branch != s.DefaultBranch
+}
+
+// CleanBehind reports whether the repository is behind its upstream with
+// nothing of its own in the way: no changed files, ignored or not, and no
+// commits to push, so pulling is a fast-forward that can't lose work.
+func (s Status) CleanBehind() bool {
+	return !s.HasError && s.Behind > 0 && s.Ahead == 0 && len(s.Files) == 0 && s.Ignored == 0
+}
+
+// File is a changed file in a working tree. Status is the two-letter
+// porcelain status code with surrounding spaces trimmed, e.g. "M" or "??".
+type File struct {
+	Path   string `json:"path"`
+	Status string `json:"status"`
+}
+
+// Paths returns the paths f's change touches: for a rename or copy, whose
+// Path is "old -> new", both, and otherwise its Path.
+func (f File) Paths() []string {
+	if from, to, ok := strings.Cut(f.Path, " -> "); ok {
+		return []string{from, to}
+	}
+	return []string{f.Path}
+}
+
+// Error describes a failed git invocation with enough context to show
+// the user what actually went wrong.
+type Error struct {
+	Dir    string
+	Args   []string
+	Stderr string
+	Err    error
+	Time   time.Time
+}
+
+// Error returns the underlying error followed by git's stderr.
+func (e *Error) Error() string {
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		return fmt.Sprintf("%s: %s", e.Err, stderr)
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the error from running the git process.
+func (e *Error) Unwrap() error { return e.Err }
+
+// Command returns the command line that failed.
+func (e *Error) Command() string {
+	return "git " + strings.Join(e.Args, " ")
+}
+
+// Busy reports whether git failed because another process, such as an
+// editor or another git command, holds one of the repository's lock files.
+func (e *Error) Busy() bool {
+	return e.Kind() == ErrorBusy
+}
+
+// Summary returns a single line suitable for list descriptions: the first
+// line of stderr, or the underlying error if git printed nothing, after
+// the kind of failure if it is known.
+func (e *Error) Summary() string {
+	kind := e.Kind()
+	if kind == ErrorBusy {
+		return kind.Message()
+	}
+	line := e.Err.Error()
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		line, _, _ = strings.Cut(stderr, "\n")
+	}
+	if kind != "" {
+		return kind.Message() + ": " + line
+	}
+	return line
+}
+
+// ErrorSummary returns a one-line description of err.
+func ErrorSummary(err error) string {
+	var gitErr *Error
+	if errors.As(err, &gitErr) {
+		return gitErr.Summary()
+	}
+	line, _, _ := strings.Cut(err.Error(), "\n")
+	return line
+}
+
+// IsBusy reports whether err is a *Error for a repository locked by another
+// process.
+func IsBusy(err error) bool {
+	var gitErr *Error
+	return errors.As(err, &gitErr) && gitErr.Busy()
+}
+
+// Run runs git with args in dir and returns its stdout. On failure the
+// error is a *Error carrying the command, stderr, and time of failure.
+func Run(dir string, args ...string) ([]byte, error) {
+	return RunContext(context.Background(), dir, args...)
+}
+
+// gitWaitDelay is how long a cancelled git process is given to exit after
+// being interrupted before it is killed.
+const gitWaitDelay = 3 * time.Second
+
+// lockRetries is how many more times a git command is run when the
+// repository is locked by another process, waiting lockRetryDelay longer
+// before each attempt.
+const (
+	lockRetries    = 3
+	lockRetryDelay = 200 * time.Millisecond
+)
+
+// RunContext is like Run but stops git when ctx is cancelled. git is first
+// interrupted so it can clean up lock files, then killed if it has not
+// exited after a short delay. If dir is an ssh:// URL, git runs on its host.
+// A repository scoped to a subdirectory runs git in the repository's root.
+// Commands run through the Runner ctx carries, if any.
+//
+// Commands that fail because another process holds a lock in the
+// repository are retried briefly; if it stays locked the error is Busy.
+func RunContext(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	return run(ctx, Command{Dir: dir, Args: args})
+}
+
+// run runs cmd through the Runner ctx carries, like RunContext. Commands
+// that read stdin or stream their output aren't retried, as neither can be
+// repeated.
+func run(ctx context.Context, cmd Command) (out []byte, err error) {
+	if len(cmd.Args) > 0 {
+		// Name the span after the subcommand rather than options before it
+		name := cmd.Args[0]
+		for _, arg := range cmd.Args {
+			if !strings.HasPrefix(arg, "-") {
+				name = arg
+				break
+			}
+		}
+		var span *Span
+		ctx, span = StartSpan(ctx, cmd.name()+" "+name, "repo", cmd.Dir, "git.args", strings.Join(cmd.Args, " "))
+		defer func() { span.Finish(err) }()
+	}
+	retries := lockRetries
+	if cmd.Stdin != nil || cmd.Output != nil {
+		retries = 0
+	}
+	runner := runnerFrom(ctx)
+	for attempt := 1; ; attempt++ {
+		out, err = runner.Run(ctx, cmd)
+		if err == nil || attempt > retries || !IsBusy(err) {
+			return out, err
+		}
+		slog.Debug("git: repository locked, retrying", "dir", cmd.Dir, "args", cmd.Args, "attempt", attempt)
+		select {
+		case <-ctx.Done():
+			return out, err
+		case <-time.After(time.Duration(attempt) * lockRetryDelay):
+		}
+	}
+}
+
+// Check returns the status of the repository at repoPath. Problems are
+// reported in the returned Status rather than as an error, so a status is
+// always available to display. Remotes are not fetched; see Fetch.
+func Check(repoPath string) Status {
+	return CheckContext(context.Background(), repoPath)
+}
+
+// CheckContext is like Check but runs git with ctx, which also carries the
+// span the check's git commands are traced under.
+func CheckContext(ctx context.Context, repoPath string) Status {
+	if IsSSH(repoPath) {
+		return checkSSH(ctx, repoPath)
+	}
+	result := Status{
+		Path:   repoPath,
+		Files:  []File{},
+		IsRepo: false,
+	}
+
+	if !IsRepository(repoPath) {
+		result.HasError = true
+		result.Error = "Not a git repository"
+		return result
+	}
+
+	result.IsRepo = true
+
+	output, err := RunContext(ctx, repoPath, append([]string{"--no-optional-locks", "status", "--porcelain"}, pathspec(repoPath)...)...)
+	if err != nil {
+		result.HasError = true
+		result.Error = ErrorSummary(err)
+		result.ErrorKind = ClassifyError(err)
+		errors.As(err, &result.ErrorDetail)
+		return result
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		if len(line) >= 3 {
+			status := strings.TrimSpace(line[:2])
+			path := strings.TrimSpace(line[2:])
+
+			// Remove quotes if git added them for paths with special characters
+			if strings.HasPrefix(path, "\"") && strings.HasSuffix(path, "\"") {
+				path = path[1 : len(path)-1]
+			}
+
+			result.Files = append(result.Files, File{
+				Path:   path,
+				Status: status,
+			})
+		}
+	}
+
+	// Get current branch
+	if branchOutput, branchErr := RunContext(ctx, repoPath, "branch", "--show-current"); branchErr == nil {
+		result.Branch = strings.TrimSpace(string(branchOutput))
+	}
+
+	// Check remote status
+	checkRemoteStatus(ctx, &result)
+
+	return result
+}
+
+// IsRepository reports whether path contains a .git directory or file. For
+// a repository scoped to a subdirectory, it is the repository that counts.
+func IsRepository(path string) bool {
+	gitPath := filepath.Join(RepoDir(path), ".git")
+	_, err := os.Stat(gitPath)
+	return err == nil
+}
+
+// ValidateRepository resolves path to an absolute path and checks that it
+// is an existing git repository. An ssh:// URL is checked on its host.
+func ValidateRepository(path string) (string, error) {
+	if repo, subdir := SplitSubdir(path); subdir != "" {
+		repo, err := ValidateRepository(repo)
+		if err != nil {
+			return "", err
+		}
+		if !IsSSH(repo) {
+			if info, err := os.Stat(filepath.Join(repo, filepath.FromSlash(subdir))); err != nil || !info.IsDir() {
+				return "", fmt.Errorf("no directory %s in %s", subdir, repo)
+			}
+		}
+		return repo + ":" + subdir, nil
+	}
+	if IsSSH(path) {
+		if _, err := Run(path, "--no-optional-locks", "rev-parse", "--git-dir"); err != nil {
+			return "", fmt.Errorf("not a git repository: %s: %s", path, ErrorSummary(err))
+		}
+		return path, nil
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve absolute path: %w", err)
+	}
+
+	// Check if directory exists
+	if _, err := os.Stat(absPath); os.IsNotExist(err) {
+		return "", fmt.Errorf("directory does not exist: %s", absPath)
+	}
+
+	// Check if it's a git repository
+	gitDir := filepath.Join(absPath, ".git")
+	if _, err := os.Stat(gitDir); os.IsNotExist(err) {
+		return "", fmt.Errorf("not a git repository: %s", absPath)
+	}
+
+	return absPath, nil
+}
+
+// isBinary reports whether content appears to be binary by checking
+// for null bytes in the first 8KB (same heuristic git uses).
+func isBinary(data []byte) bool {
+	n := len(data)
+	if n > 8192 {
+		n = 8192
+	}
+	for i := 0; i < n; i++ {
+		if data[i] == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// FileDiff returns the diff of filePath against HEAD, falling back to its
+// staged changes, or its content if it is untracked. Binary files are
+// summarised rather than returned.
+func FileDiff(repoPath, filePath string) (string, error) {
+	return FileDiffContext(context.Background(), repoPath, filePath)
+}
+
+// FileDiffContext is like FileDiff but runs git with ctx.
+func FileDiffContext(ctx context.Context, repoPath, filePath string) (string, error) {
+	if IsSSH(repoPath) {
+		return sshFileDiff(ctx, repoPath, filePath)
+	}
+	// First try working directory changes
+	output, err := RunContext(ctx, repoPath, "diff", "HEAD", "--", filePath)
+
+	// If no working directory changes, try staged changes
+	if err != nil || len(output) == 0 {
+		output, err = RunContext(ctx, repoPath, "diff", "--cached", "--", filePath)
+
+		// If no staged changes and file is untracked, show file content
+		if err != nil || len(output) == 0 {
+			statusOutput, statusErr := RunContext(ctx, repoPath, "--no-optional-locks", "status", "--porcelain", "--", filePath)
+			repoPath := RepoDir(repoPath)
+			if statusErr == nil && strings.HasPrefix(strings.TrimSpace(string(statusOutput)), "??") {
+				// File is untracked, show its content using os.ReadFile
+				// Sanitize path to prevent directory traversal
+				cleanPath := filepath.Join(repoPath, filepath.Clean(filePath))
+				if strings.HasPrefix(cleanPath, filepath.Clean(repoPath)+string(filepath.Separator)) {
+					content, contentErr := os.ReadFile(cleanPath)
+					if contentErr == nil {
+						if isBinary(content) {
+							return fmt.Sprintf("Binary file: %s", filePath), nil
+						}
+						return fmt.Sprintf("New file: %s\n\n%s", filePath, string(content)), nil
+					}
+				}
+			}
+		}
+	}
+
+	if err != nil {
+		return "", err
+	}
+	if isBinary(output) {
+		return fmt.Sprintf("Binary file: %s", filePath), nil
+	}
+	return string(output), nil
+}
+
+// FirstChangedLine returns the line of filePath where its first change
+// against HEAD starts, or 1 if it is new or the line can't be told.
+func FirstChangedLine(repoPath, filePath string) int {
+	output, err := Run(repoPath, "diff", "--unified=0", "--no-color", "HEAD", "--", filePath)
+	if err != nil {
+		return 1
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		// @@ -12,3 +14,5 @@; a pure deletion has no lines of its own and
+		// reports the one before it
+		var oldStart, newStart int
+		if _, err := fmt.Sscanf(line, "@@ -%d", &oldStart); err != nil {
+			continue
+		}
+		if _, plus, ok := strings.Cut(line, " +"); ok {
+			fmt.Sscanf(plus, "%d", &newStart)
+		}
+		return max(newStart, 1)
+	}
+	return 1
+}
+
+// DirtyAge returns how long the oldest uncommitted change in status has been
+// left: since DirtySince if it is known, or else judged by the modification
+// times of the changed files. Files that no longer exist, such as
+// deletions, are not counted.
+func DirtyAge(status Status) time.Duration {
+	if !status.DirtySince.IsZero() {
+		return time.Since(status.DirtySince)
+	}
+	var oldest time.Time
+	for _, f := range status.Files {
+		path := f.Path
+		if _, to, ok := strings.Cut(path, " -> "); ok {
+			path = to
+		}
+		info, err := os.Lstat(filepath.Join(RepoDir(status.Path), path))
+		if err != nil {
+			continue
+		}
+		if oldest.IsZero() || info.ModTime().Before(oldest) {
+			oldest = info.ModTime()
+		}
+	}
+	if oldest.IsZero() {
+		return 0
+	}
+	return time.Since(oldest)
+}
+
+func checkRemoteStatus(ctx context.Context, status *Status) {
+	// Check if there's a remote configured
+	output, err := RunContext(ctx, status.Path, "remote")
+	if err != nil || strings.TrimSpace(string(output)) == "" {
+		status.HasRemote = false
+		return
+	}
+
+	status.HasRemote = true
+
+	// origin/HEAD is set by clone and "git remote set-head"
+	if head, err := RunContext(ctx, status.Path, "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD"); err == nil {
+		status.DefaultBranch = strings.TrimPrefix(strings.TrimSpace(string(head)), "origin/")
+	}
+
+	// Get current branch
+	branchOutput, err := RunContext(ctx, status.Path, "branch", "--show-current")
+	if err != nil {
+		status.RemoteStatus = "Unable to get current branch"
+		return
+	}
+
+	currentBranch := strings.TrimSpace(string(branchOutput))
+	if currentBranch == "" {
+		status.RemoteStatus = "No current branch"
+		return
+	}
+
+	// Check if branch has upstream
+	upstreamOutput, err := RunContext(ctx, status.Path, "--no-optional-locks", "rev-parse", "--abbrev-ref", currentBranch+"@{upstream}")
+	if err != nil {
+		status.RemoteStatus = "No upstream branch"
+		return
+	}
+
+	upstream := strings.TrimSpace(string(upstreamOutput))
+
+	// Skip automatic fetch to avoid performance issues
+	// Remote status will be based on last fetch time
+
+	// Count the commits on each side
+	countOutput, err := RunContext(ctx, status.Path, "rev-list", "--left-right", "--count", currentBranch+"..."+upstream)
+	if err != nil {
+		status.RemoteStatus = "Unable to check remote status"
+		return
+	}
+	fmt.Sscan(string(countOutput), &status.Ahead, &status.Behind)
+
+	if status.Behind > 0 {
+		status.NeedsPull = true
+		if status.Behind == 1 {
+			status.RemoteStatus = "1 commit behind"
+		} else {
+			status.RemoteStatus = fmt.Sprintf("%d commits behind", status.Behind)
+		}
+	} else {
+		status.NeedsPull = false
+		status.RemoteStatus = "Up to date"
+	}
+}
+
+// Fetch updates the repository's remote-tracking branches.
+func Fetch(ctx context.Context, repoPath string) error {
+	_, err := RunContext(ctx, repoPath, "fetch", "--quiet")
+	return err
+}
+
+// DiscardFile reverts a single file to its state at HEAD. Untracked
+// files are deleted and newly added files are removed from the index and
+// working tree. A rename is undone by removing the new path and restoring
+// the old one; a copy, by removing the copy.
+func DiscardFile(repoPath string, file File) error {
+	var err error
+	switch {
+	case file.Status == "??":
+		_, err = Run(repoPath, "clean", "-f", "--", file.Path)
+	case file.Status == "A" || file.Status == "AM" || file.Status == "AD":
+		_, err = Run(repoPath, "rm", "-f", "--", file.Path)
+	case strings.HasPrefix(file.Status, "R") || strings.HasPrefix(file.Status, "C"):
+		from, to, ok := strings.Cut(file.Path, " -> ")
+		if !ok {
+			return fmt.Errorf("%s: %s without the original path", file.Path, file.Status)
+		}
+		_, err = Run(repoPath, "rm", "-f", "--", to)
+		if err == nil && file.Status[0] == 'R' {
+			_, err = Run(repoPath, "restore", "--source=HEAD", "--staged", "--worktree", "--", from)
+		}
+	default:
+		_, err = Run(repoPath, "restore", "--source=HEAD", "--staged", "--worktree", "--", file.Path)
+	}
+	return err
+}
+
+// CleanUntracked deletes all untracked files and directories, leaving
+// ignored files alone, in the subdirectory repoPath is scoped to if any.
+func CleanUntracked(repoPath string) error {
+	_, err := Run(repoPath, append([]string{"clean", "-fd"}, pathspec(repoPath)...)...)
+	return err
+}
+
+// UntrackedPaths returns what CleanUntracked would delete: untracked files,
+// and untracked directories as a whole, with a trailing slash.
+func UntrackedPaths(repoPath string) ([]string, error) {
+	out, err := Run(repoPath, append([]string{"ls-files", "-z", "--others", "--exclude-standard", "--directory", "--no-empty-directory", "--full-name"}, pathspec(repoPath)...)...)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, p := range strings.Split(string(out), "\x00") {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths, nil
+}
+
+// Pull fast-forwards the current branch from its upstream. It never
+// creates merge commits; diverged branches are reported as an error.
+func Pull(ctx context.Context, repoPath string) error {
+	_, err := RunContext(ctx, repoPath, "pull", "--ff-only", "--quiet")
+	return err
+}
+
+// Push pushes the current branch to its configured upstream.
+func Push(ctx context.Context, repoPath string) error {
+	_, err := RunContext(ctx, repoPath, "push", "--quiet")
+	return err
+}
+
+// CheckoutDetached checks out rev with a detached HEAD.
+func CheckoutDetached(repoPath, rev string) error {
+	_, err := Run(repoPath, "checkout", "--quiet", "--detach", rev)
+	return err
+}
+
+// CreateBranch creates and checks out a new branch named name at rev.
+func CreateBranch(repoPath, name, rev string) error {
+	_, err := Run(repoPath, "checkout", "--quiet", "-b", name, rev)
+	return err
+}
+
+// ResetHard moves the current branch to rev, discarding all local changes.
+func ResetHard(repoPath, rev string) error {
+	_, err := Run(repoPath, "reset", "--quiet", "--hard", rev)
+	return err
+}
+
+// CreateTag creates an annotated tag named name at HEAD.
+func CreateTag(repoPath, name, message string) error {
+	_, err := Run(repoPath, "tag", "--annotate", "--message", message, name)
+	return err
+}
+
+// DeleteTag deletes a local tag. Tags already pushed are left on the remote.
+func DeleteTag(repoPath, name string) error {
+	_, err := Run(repoPath, "tag", "--delete", name)
+	return err
+}
+
+// PushTags pushes all local tags to the default remote.
+func PushTags(ctx context.Context, repoPath string) error {
+	_, err := RunContext(ctx, repoPath, "push", "--quiet", "--tags")
+	return err
+}
+
+// CheckoutRemoteBranch creates and checks out a local branch tracking the
+// remote-tracking branch remoteBranch (e.g. "origin/feature").
+func CheckoutRemoteBranch(repoPath, remoteBranch string) error {
+	_, err := Run(repoPath, "checkout", "--quiet", "--track", remoteBranch)
+	return err
+}
+
+// AddWorktree creates a linked worktree at path. An empty branch checks out
+// a detached HEAD; a branch that does not exist yet is created from HEAD.
+func AddWorktree(repoPath, path, branch string) error {
+	args := []string{"worktree", "add", "--quiet"}
+	switch {
+	case branch == "":
+		args = append(args, "--detach", path)
+	case BranchExists(repoPath, branch):
+		args = append(args, path, branch)
+	default:
+		args = append(args, "-b", branch, path)
+	}
+	_, err := Run(repoPath, args...)
+	return err
+}
+
+// RemoveWorktree removes a linked worktree. git refuses if it has local
+// changes.
+func RemoveWorktree(repoPath, path string) error {
+	_, err := Run(repoPath, "worktree", "remove", path)
+	return err
+}
+
+// BranchExists reports whether a local branch named branch exists.
+func BranchExists(repoPath, branch string) bool {
+	_, err := Run(repoPath, "show-ref", "--verify", "--quiet", "refs/heads/"+branch)
+	return err == nil
+}
+
+// UpdateSubmodules initializes and checks out the pinned commit of the given
+// submodule paths, or of all submodules when none are given.
+func UpdateSubmodules(ctx context.Context, repoPath string, paths ...string) error {
+	args := append([]string{"submodule", "--quiet", "update", "--init", "--recursive", "--"}, paths...)
+	_, err := RunContext(ctx, repoPath, args...)
+	return err
+}
+
+// StashPush stashes all local changes, including untracked files, in the
+// subdirectory repoPath is scoped to if any.
+func StashPush(repoPath, message string) error {
+	args := []string{"stash", "push", "--quiet", "--include-untracked"}
+	if message != "" {
+		args = append(args, "--message", message)
+	}
+	_, err := Run(repoPath, append(args, pathspec(repoPath)...)...)
+	return err
+}
+
+// StashApply applies a stash entry, removing it from the stash if pop is set.
+func StashApply(repoPath, ref string, pop bool) error {
+	action := "apply"
+	if pop {
+		action = "pop"
+	}
+	_, err := Run(repoPath, "stash", action, "--quiet", ref)
+	return err
+}
+
+// StashDrop deletes a stash entry.
+func StashDrop(repoPath, ref string) error {
+	_, err := Run(repoPath, "stash", "drop", "--quiet", ref)
+	return err
+}
@@ -0,0 +1,136 @@
+package gitstatus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// GoGitRunner is a Runner that answers the commands a status check and a
+// fetch are made of with go-git, in process, instead of starting git for
+// each: "status --porcelain", "branch --show-current", "remote", and
+// "fetch --quiet", which fetches origin. Every other command, and any for a
+// repository on another host or scoped to a subdirectory, goes to Fallback,
+// or ExecRunner if it is nil.
+//
+// go-git doesn't read git's credential helpers or ssh config, so fetches
+// from hosts that need them fail to authenticate where git's wouldn't.
+type GoGitRunner struct {
+	Fallback Runner
+}
+
+// Run implements Runner.
+func (g GoGitRunner) Run(ctx context.Context, c Command) ([]byte, error) {
+	args := c.Args
+	if len(args) > 0 && args[0] == "--no-optional-locks" {
+		args = args[1:]
+	}
+	var answer func(*git.Repository) (string, error)
+	switch strings.Join(args, " ") {
+	case "status --porcelain":
+		answer = goGitStatus
+	case "branch --show-current":
+		answer = goGitBranch
+	case "remote":
+		answer = goGitRemotes
+	case "fetch --quiet":
+		answer = func(repo *git.Repository) (string, error) { return "", goGitFetch(ctx, repo) }
+	}
+	if answer == nil || c.Program != "" || len(c.Env) > 0 || c.Stdin != nil || c.Output != nil || IsSSH(c.Dir) {
+		fallback := g.Fallback
+		if fallback == nil {
+			fallback = ExecRunner{}
+		}
+		return fallback.Run(ctx, c)
+	}
+
+	start := time.Now()
+	repo, err := git.PlainOpenWithOptions(RepoDir(c.Dir), &git.PlainOpenOptions{EnableDotGitCommonDir: true})
+	out := ""
+	if err == nil {
+		out, err = answer(repo)
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	slog.Debug("go-git", "dir", c.Dir, "args", c.Args, "duration", time.Since(start), "err", err)
+	if err != nil {
+		// "fatal:" as git would put it, so the error is classified the same
+		return nil, &Error{Dir: c.Dir, Args: c.Args, Stderr: "fatal: " + err.Error(), Err: err, Time: time.Now()}
+	}
+	return []byte(out), nil
+}
+
+// goGitStatus returns the worktree's status as "git status --porcelain"
+// prints it.
+func goGitStatus(repo *git.Repository) (string, error) {
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return "", err
+	}
+	status, err := worktree.Status()
+	if err != nil {
+		return "", err
+	}
+	paths := make([]string, 0, len(status))
+	for path := range status {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	var b strings.Builder
+	for _, path := range paths {
+		file := status[path]
+		if file.Staging == git.Unmodified && file.Worktree == git.Unmodified {
+			continue
+		}
+		if file.Staging == git.Renamed && file.Extra != "" {
+			path = file.Extra + " -> " + path
+		}
+		fmt.Fprintf(&b, "%c%c %s\n", file.Staging, file.Worktree, path)
+	}
+	return b.String(), nil
+}
+
+// goGitBranch returns the checked out branch, even one without commits, or
+// "" if HEAD is detached.
+func goGitBranch(repo *git.Repository) (string, error) {
+	head, err := repo.Reference(plumbing.HEAD, false)
+	if err != nil {
+		return "", err
+	}
+	if head.Type() != plumbing.SymbolicReference {
+		return "", nil
+	}
+	return head.Target().Short() + "\n", nil
+}
+
+// goGitRemotes returns the names of the remotes, one per line.
+func goGitRemotes(repo *git.Repository) (string, error) {
+	remotes, err := repo.Remotes()
+	if err != nil {
+		return "", err
+	}
+	var names []string
+	for _, remote := range remotes {
+		names = append(names, remote.Config().Name+"\n")
+	}
+	slices.Sort(names)
+	return strings.Join(names, ""), nil
+}
+
+// goGitFetch fetches origin. Having nothing to fetch, or no origin to fetch
+// from, isn't a failure, as it isn't for git.
+func goGitFetch(ctx context.Context, repo *git.Repository) error {
+	err := repo.FetchContext(ctx, &git.FetchOptions{RemoteName: "origin"})
+	if errors.Is(err, git.NoErrAlreadyUpToDate) || errors.Is(err, git.ErrRemoteNotFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,95 @@
+package gitstatus
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Health sums up how much a repository needs looking after, from a Score
+// of 100 with nothing to do down to 0, graded A to F.
+type Health struct {
+	Score   int      `json:"score"`
+	Grade   string   `json:"grade"`
+	Reasons []string `json:"reasons,omitempty"` // what lowered the score, worst first
+}
+
+// HealthOf scores a repository by its status and its local branches, as
+// listed by LocalBranches: uncommitted changes, more so the longer they are
+// left, commits to pull, failing fetches, branches with commits not pushed,
+// and branches other than the checked-out one whose upstream was deleted or
+// that have no commits in staleAfter. A status with an error scores 0.
+func HealthOf(status Status, branches []LocalBranch, staleAfter time.Duration) Health {
+	if status.HasError {
+		return Health{Score: 0, Grade: "F", Reasons: []string{status.Error}}
+	}
+
+	type penalty struct {
+		points int
+		reason string
+	}
+	var penalties []penalty
+	if strings.HasPrefix(status.RemoteStatus, "Fetch failed") {
+		penalties = append(penalties, penalty{25, "fetch failing"})
+	}
+	if len(status.Files) > 0 {
+		days := int(DirtyAge(status).Hours() / 24)
+		reason := "uncommitted changes"
+		if days > 0 {
+			reason = fmt.Sprintf("dirty for %s", countOf(days, "day"))
+		}
+		penalties = append(penalties, penalty{min(5+3*days, 30), reason})
+	}
+	if status.Behind > 0 {
+		penalties = append(penalties, penalty{min(5+status.Behind, 20), countOf(status.Behind, "commit") + " behind"})
+	}
+	var unpushed, stale int
+	for _, b := range branches {
+		switch {
+		case b.Ahead > 0 || b.Upstream == "" && status.HasRemote:
+			unpushed++
+		case b.Name != status.Branch && (b.Gone || staleAfter > 0 && time.Since(b.Date) > staleAfter):
+			stale++
+		}
+	}
+	if unpushed > 0 {
+		penalties = append(penalties, penalty{min(5*unpushed, 20), countOf(unpushed, "unpushed branch")})
+	}
+	if stale > 0 {
+		penalties = append(penalties, penalty{min(2*stale, 10), countOf(stale, "stale branch")})
+	}
+
+	// Worst first; the order above breaks ties
+	slices.SortStableFunc(penalties, func(a, b penalty) int { return b.points - a.points })
+	h := Health{Score: 100}
+	for _, p := range penalties {
+		h.Score -= p.points
+		h.Reasons = append(h.Reasons, p.reason)
+	}
+	h.Score = max(h.Score, 0)
+	switch {
+	case h.Score >= 90:
+		h.Grade = "A"
+	case h.Score >= 75:
+		h.Grade = "B"
+	case h.Score >= 60:
+		h.Grade = "C"
+	case h.Score >= 40:
+		h.Grade = "D"
+	default:
+		h.Grade = "F"
+	}
+	return h
+}
+
+// countOf renders n and noun, e.g. "1 day" or "3 unpushed branches".
+func countOf(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	if strings.HasSuffix(noun, "ch") {
+		noun += "e"
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,339 @@
+package gitstatus
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of history event. They come in pairs of opposite states, except
+// HistoryPushed.
+const (
+	HistoryDirty       = "dirty"        // uncommitted changes appeared
+	HistoryClean       = "clean"        // the changes were committed or discarded
+	HistoryBehind      = "behind"       // the upstream gained commits not merged
+	HistoryUpToDate    = "up_to_date"   // the upstream's commits were merged
+	HistoryFetchFailed = "fetch_failed" // fetching started failing
+	HistoryFetchOK     = "fetch_ok"     // fetching succeeded again
+	HistoryPushed      = "pushed"       // the branch's commits reached its upstream
+)
+
+// historyPairs are the pairs of opposite event kinds. A repository is in
+// one state of each pair, and an event is recorded when it changes.
+var historyPairs = [][2]string{
+	{HistoryClean, HistoryDirty},
+	{HistoryUpToDate, HistoryBehind},
+	{HistoryFetchOK, HistoryFetchFailed},
+}
+
+// HistoryOpposite returns the kind of event that undoes kind, e.g.
+// HistoryClean for HistoryDirty, or "" if there is none.
+func HistoryOpposite(kind string) string {
+	for _, pair := range historyPairs {
+		switch kind {
+		case pair[0]:
+			return pair[1]
+		case pair[1]:
+			return pair[0]
+		}
+	}
+	return ""
+}
+
+// DescribeHistoryKind names an event kind in words, e.g. "fell behind".
+func DescribeHistoryKind(kind string) string {
+	switch kind {
+	case HistoryDirty:
+		return "went dirty"
+	case HistoryClean:
+		return "became clean"
+	case HistoryBehind:
+		return "fell behind"
+	case HistoryUpToDate:
+		return "caught up"
+	case HistoryFetchFailed:
+		return "fetch started failing"
+	case HistoryFetchOK:
+		return "fetch recovered"
+	case HistoryPushed:
+		return "pushed"
+	}
+	return kind
+}
+
+// historyLimit is how many events the history file keeps; older ones are
+// dropped once it has grown by a tenth more.
+const historyLimit = 10000
+
+// HistoryEvent is a recorded change of a repository's state.
+type HistoryEvent struct {
+	Time   time.Time `json:"time"`
+	Repo   string    `json:"repo"`
+	Kind   string    `json:"kind"`
+	Detail string    `json:"detail,omitempty"` // e.g. the fetch error
+}
+
+// History records state changes of repositories in a file of JSON lines
+// shared by every gitmoni process. Each process compares the statuses it
+// sees with the last state the file records, so a change is recorded once
+// however many processes see it, and changes made while no process was
+// running are recorded when the next one starts.
+type History struct {
+	path  string
+	mu    sync.Mutex
+	state map[string]map[string]string // last kind of each pair, by repo
+}
+
+// DefaultHistoryPath returns gitmoni/history.jsonl in the user's cache
+// directory.
+func DefaultHistoryPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gitmoni", "history.jsonl"), nil
+}
+
+// NewHistory returns a history kept in the file at path.
+func NewHistory(path string) *History {
+	h := &History{path: path}
+	events, _ := ReadHistory(path)
+	h.state = historyState(events)
+	return h
+}
+
+// ReadHistory returns the events recorded in the file at path, oldest
+// first. A missing file has no events.
+func ReadHistory(path string) ([]HistoryEvent, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var events []HistoryEvent
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var e HistoryEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			// A line cut short by a crash; skip it
+			continue
+		}
+		events = append(events, e)
+	}
+	return events, scanner.Err()
+}
+
+// historyState returns the last kind of each pair recorded for each repo.
+func historyState(events []HistoryEvent) map[string]map[string]string {
+	state := make(map[string]map[string]string)
+	for _, e := range events {
+		for _, pair := range historyPairs {
+			if e.Kind == pair[0] || e.Kind == pair[1] {
+				if state[e.Repo] == nil {
+					state[e.Repo] = make(map[string]string)
+				}
+				state[e.Repo][pair[0]] = e.Kind
+			}
+		}
+	}
+	return state
+}
+
+// currentKinds returns the kind of each pair that describes status, keyed
+// by the pair's first kind, with the event's detail.
+func currentKinds(status Status) map[string]HistoryEvent {
+	kinds := make(map[string]HistoryEvent, len(historyPairs))
+	add := func(pair [2]string, second bool, detail string) {
+		e := HistoryEvent{Repo: status.Path, Kind: pair[0]}
+		if second {
+			e.Kind, e.Detail = pair[1], detail
+		}
+		kinds[pair[0]] = e
+	}
+	files := fmt.Sprintf("%d changed files", len(status.Files))
+	if len(status.Files) == 1 {
+		files = "1 changed file"
+	}
+	add(historyPairs[0], len(status.Files) > 0, files)
+	if status.HasRemote {
+		add(historyPairs[1], status.NeedsPull, status.RemoteStatus)
+		add(historyPairs[2], strings.HasPrefix(status.RemoteStatus, "Fetch failed"), status.RemoteStatus)
+	}
+	return kinds
+}
+
+// Observe records the changes between a repository's previous status, if
+// seen, and its current status. Where the file has no record of the
+// repository's state, the previous status stands in for it; without either,
+// the current status is the starting point. Statuses with errors are
+// ignored, as they say nothing about the repository's state.
+func (h *History) Observe(prev Status, seen bool, cur Status) {
+	if cur.HasError {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	seen = seen && !prev.HasError
+	// Commits leaving the ahead count on the same branch went upstream
+	pushed := seen && prev.Branch == cur.Branch && prev.Ahead > 0 && cur.Ahead == 0 && cur.HasRemote
+	pending := h.changes(prev, seen, cur)
+	if len(pending) == 0 && !pushed {
+		return
+	}
+	// Another process may have recorded the same changes since the file
+	// was read
+	events, err := ReadHistory(h.path)
+	if err != nil {
+		slog.Warn("reading history failed", "path", h.path, "err", err)
+	}
+	h.state = historyState(events)
+	pending = h.changes(prev, seen, cur)
+	if pushed && !recentlyPushed(events, cur.Path) {
+		pending = append(pending, HistoryEvent{Repo: cur.Path, Kind: HistoryPushed, Detail: cur.Branch})
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	now := time.Now()
+	for i := range pending {
+		pending[i].Time = now
+		for _, pair := range historyPairs {
+			if pending[i].Kind == pair[0] || pending[i].Kind == pair[1] {
+				if h.state[cur.Path] == nil {
+					h.state[cur.Path] = make(map[string]string)
+				}
+				h.state[cur.Path][pair[0]] = pending[i].Kind
+			}
+		}
+	}
+	if err := h.append(pending, len(events)); err != nil {
+		slog.Warn("recording history failed", "path", h.path, "err", err)
+	}
+}
+
+// changes returns the events that take the repository from its recorded
+// state to cur's. States without a record start from prev if seen, or else
+// from cur, and are remembered as such.
+func (h *History) changes(prev Status, seen bool, cur Status) []HistoryEvent {
+	state := h.state[cur.Path]
+	if state == nil {
+		state = make(map[string]string)
+		h.state[cur.Path] = state
+	}
+	var before map[string]HistoryEvent
+	if seen {
+		before = currentKinds(prev)
+	}
+	after := currentKinds(cur)
+	var events []HistoryEvent
+	for _, pair := range historyPairs {
+		now, ok := after[pair[0]]
+		if !ok {
+			continue
+		}
+		last := state[pair[0]]
+		if last == "" {
+			if b, ok := before[pair[0]]; ok {
+				last = b.Kind
+			} else {
+				state[pair[0]] = now.Kind
+				continue
+			}
+		}
+		if last != now.Kind {
+			events = append(events, now)
+		}
+	}
+	return events
+}
+
+// recentlyPushed reports whether a push of repo was recorded in the last
+// minute, by another process seeing the same push.
+func recentlyPushed(events []HistoryEvent, repo string) bool {
+	for i := len(events) - 1; i >= 0; i-- {
+		e := events[i]
+		if time.Since(e.Time) > time.Minute {
+			break
+		}
+		if e.Repo == repo && e.Kind == HistoryPushed {
+			return true
+		}
+	}
+	return false
+}
+
+// append adds events to the file, which holds count events already, and
+// trims it when it has outgrown historyLimit.
+func (h *History) append(events []HistoryEvent, count int) error {
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o755); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	for _, e := range events {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	// Appends of a few lines are atomic, so processes can't interleave
+	f, err := os.OpenFile(h.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if count+len(events) > historyLimit+historyLimit/10 {
+		return h.trim()
+	}
+	return nil
+}
+
+// trim drops the oldest events beyond historyLimit, replacing the file
+// atomically.
+func (h *History) trim() error {
+	events, err := ReadHistory(h.path)
+	if err != nil {
+		return err
+	}
+	events = events[max(len(events)-historyLimit, 0):]
+	var buf bytes.Buffer
+	for _, e := range events {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(h.path), ".history-*.jsonl")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), h.path)
+}
@@ -0,0 +1,71 @@
+package gitstatus
+
+import (
+	"path"
+	"strings"
+)
+
+// IgnoredPath reports whether name, a path relative to the repository as
+// listed in Status.Files, matches one of patterns. Patterns use a subset of
+// .gitignore syntax: one ending in / only matches directories, such as
+// "dist/"; one without any other slash matches a name at any depth, such as
+// "*.generated.go"; and any other is matched against the path from the
+// repository's root, such as "web/static/*.js". A matching directory
+// matches everything in it.
+func IgnoredPath(name string, patterns []string) bool {
+	if _, to, ok := strings.Cut(name, " -> "); ok {
+		name = to
+	}
+	// Untracked directories are listed with a trailing slash
+	isDir := strings.HasSuffix(name, "/")
+	parts := strings.Split(strings.TrimSuffix(name, "/"), "/")
+	for _, pattern := range patterns {
+		pattern, dirOnly := strings.CutSuffix(pattern, "/")
+		anchored := strings.Contains(pattern, "/")
+		pattern = strings.TrimPrefix(pattern, "/")
+		for i := range parts {
+			if dirOnly && i == len(parts)-1 && !isDir {
+				break
+			}
+			candidate := parts[i]
+			if anchored {
+				candidate = strings.Join(parts[:i+1], "/")
+			}
+			if ok, _ := path.Match(pattern, candidate); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Ignore leaves out the changed files matching patterns, as decided by
+// IgnoredPath, and counts them in Ignored.
+func (s *Status) Ignore(patterns []string) {
+	if len(patterns) == 0 {
+		return
+	}
+	kept := make([]File, 0, len(s.Files))
+	for _, f := range s.Files {
+		if IgnoredPath(f.Path, patterns) {
+			s.Ignored++
+		} else {
+			kept = append(kept, f)
+		}
+	}
+	s.Files = kept
+}
+
+// IgnoreUntracked leaves out the untracked files, and counts them in
+// Ignored.
+func (s *Status) IgnoreUntracked() {
+	kept := make([]File, 0, len(s.Files))
+	for _, f := range s.Files {
+		if f.Status == "??" {
+			s.Ignored++
+		} else {
+			kept = append(kept, f)
+		}
+	}
+	s.Files = kept
+}
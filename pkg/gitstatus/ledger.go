@@ -0,0 +1,104 @@
+package gitstatus
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// staleLock is how old a fetch lock must be before it is assumed to belong
+// to a process that died without releasing it.
+const staleLock = 5 * time.Minute
+
+// FetchLedger coordinates fetches between processes sharing a directory,
+// such as several gitmoni instances open at once. Fetched refs live in the
+// repository itself, so once one process has fetched a repository the
+// others only need to re-check its local status. The ledger records when
+// each repository was last fetched and holds an advisory lock while a fetch
+// is running, so each repository is fetched once per window instead of once
+// per process.
+type FetchLedger struct {
+	dir    string
+	window time.Duration
+}
+
+// NewFetchLedger returns a ledger kept in dir that treats a fetch by any
+// process within window as fresh.
+func NewFetchLedger(dir string, window time.Duration) *FetchLedger {
+	return &FetchLedger{dir: dir, window: window}
+}
+
+// DefaultLedgerDir returns gitmoni/fetch in the user's cache directory.
+func DefaultLedgerDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gitmoni", "fetch"), nil
+}
+
+// Begin claims repo for fetching. If it returns true, the caller should
+// fetch and then call release, passing whether the fetch succeeded. If it
+// returns false, another process fetched repo within the window, or was
+// fetching it and has now finished successfully, and the caller can skip
+// the fetch.
+// Begin waits for another process's fetch until ctx is done. If the ledger
+// directory is unusable, Begin always lets the caller fetch.
+func (l *FetchLedger) Begin(ctx context.Context, repo string) (release func(ok bool), fetch bool) {
+	key := sha256.Sum256([]byte(repo))
+	base := filepath.Join(l.dir, hex.EncodeToString(key[:8]))
+	stamp, lock := base+".fetched", base+".lock"
+	noop := func(bool) {}
+
+	if err := os.MkdirAll(l.dir, 0o755); err != nil {
+		return noop, true
+	}
+
+	for {
+		if l.fresh(stamp) {
+			return noop, false
+		}
+		f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			fmt.Fprintln(f, os.Getpid())
+			f.Close()
+			return func(ok bool) {
+				if ok {
+					os.WriteFile(stamp, []byte(strconv.Itoa(os.Getpid())), 0o644)
+				}
+				os.Remove(lock)
+			}, true
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return noop, true
+		}
+		if info, err := os.Stat(lock); err == nil && time.Since(info.ModTime()) > staleLock {
+			os.Remove(lock)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return noop, false
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+}
+
+// fresh reports whether another process fetched within the window. The
+// stamp holds the fetching process's ID, so this process's own earlier
+// fetches never suppress a new one.
+func (l *FetchLedger) fresh(stamp string) bool {
+	info, err := os.Stat(stamp)
+	if err != nil || time.Since(info.ModTime()) >= l.window {
+		return false
+	}
+	data, err := os.ReadFile(stamp)
+	return err == nil && string(data) != strconv.Itoa(os.Getpid())
+}
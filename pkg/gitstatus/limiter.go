@@ -0,0 +1,188 @@
+package gitstatus
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FetchLimiter spreads fetches out by the host of each repository's origin,
+// so many repositories on one server don't all connect to it at the same
+// instant, which trips the rate limiting of some servers. At most limit
+// fetches from a host run at once, and each starts a random gap of up to
+// jitter after the one before it. Fetches from different hosts, or of
+// repositories whose origin is on this machine, don't wait for each other.
+// A FetchLimiter is safe for concurrent use.
+type FetchLimiter struct {
+	limit  int // 0 for no limit
+	jitter time.Duration
+	mu     sync.Mutex
+	hosts  map[string]*hostQueue
+	origin map[string]string // host of each repository's origin, once looked up
+}
+
+// hostQueue is the state of the fetches from one host.
+type hostQueue struct {
+	slots chan struct{} // one per running fetch; nil for no limit
+	next  time.Time     // when the next fetch may start
+
+	pending    int  // fetches waiting or running
+	authFailed bool // one of them failed to authenticate
+}
+
+// NewFetchLimiter returns a limiter running at most limit fetches from a
+// host at once, or any number if limit is 0, started up to jitter apart.
+func NewFetchLimiter(limit int, jitter time.Duration) *FetchLimiter {
+	return &FetchLimiter{limit: max(limit, 0), jitter: jitter, hosts: map[string]*hostQueue{}, origin: map[string]string{}}
+}
+
+// Wait blocks until repo may be fetched or ctx is done. If it returns nil,
+// the caller must call done with the fetch's result once it has finished.
+// Once a fetch from a host fails to authenticate, fetches from the host that
+// were already waiting or are asked for before they have all finished are
+// skipped with an error IsSkipped reports, rather than trying the same
+// credentials again for every repository there and getting the account
+// locked out. The next batch of fetches from the host tries again.
+func (l *FetchLimiter) Wait(ctx context.Context, repo string) (done func(err error), err error) {
+	host := l.host(repo)
+	if host == "" {
+		return func(error) {}, nil
+	}
+	l.mu.Lock()
+	q, ok := l.hosts[host]
+	if !ok {
+		q = &hostQueue{}
+		if l.limit > 0 {
+			q.slots = make(chan struct{}, l.limit)
+		}
+		l.hosts[host] = q
+	}
+	if q.authFailed {
+		l.mu.Unlock()
+		return nil, &authSkipError{host: host}
+	}
+	q.pending++
+	l.mu.Unlock()
+
+	acquired := false
+	finish := func(err error) {
+		if acquired {
+			<-q.slots
+		}
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if ClassifyError(err) == ErrorAuth {
+			q.authFailed = true
+		}
+		if q.pending--; q.pending == 0 {
+			q.authFailed = false
+		}
+	}
+	if q.slots != nil {
+		select {
+		case q.slots <- struct{}{}:
+			acquired = true
+		case <-ctx.Done():
+			finish(nil)
+			return nil, ctx.Err()
+		}
+	}
+
+	// Take the next start time and push the one after it back by a random
+	// gap, so the fetches waiting for this host start one by one
+	l.mu.Lock()
+	start := time.Now()
+	if q.next.After(start) {
+		start = q.next
+	}
+	q.next = start
+	if l.jitter > 0 {
+		q.next = start.Add(rand.N(l.jitter))
+	}
+	l.mu.Unlock()
+	if wait := time.Until(start); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			finish(nil)
+			return nil, ctx.Err()
+		}
+	}
+
+	// Another fetch from the host may have failed while this one waited
+	l.mu.Lock()
+	failed := q.authFailed
+	l.mu.Unlock()
+	if failed {
+		finish(nil)
+		return nil, &authSkipError{host: host}
+	}
+	return finish, nil
+}
+
+// authSkipError is the error of a fetch skipped because another fetch from
+// the same host failed to authenticate.
+type authSkipError struct {
+	host string
+}
+
+func (e *authSkipError) Error() string {
+	return "authenticating with " + e.host + " failed for another repository"
+}
+
+// IsSkipped reports whether err is the error of a fetch that wasn't run,
+// because the repository was locked by another process or a fetch from its
+// host failed to authenticate, rather than one that failed. The
+// repository's previous fetch result still stands.
+func IsSkipped(err error) bool {
+	var skip *authSkipError
+	return IsBusy(err) || errors.As(err, &skip)
+}
+
+// host returns the host of repo's origin, looking it up the first time.
+func (l *FetchLimiter) host(repo string) string {
+	l.mu.Lock()
+	host, ok := l.origin[repo]
+	l.mu.Unlock()
+	if ok {
+		return host
+	}
+	if url, err := RemoteURL(repo, "origin"); err == nil {
+		host = RemoteHost(url)
+	}
+	l.mu.Lock()
+	l.origin[repo] = host
+	l.mu.Unlock()
+	return host
+}
+
+// RemoteHost returns the lowercased host name in a remote URL, such as
+// "git.example.com" for https://git.example.com/team/app.git or
+// git@git.example.com:team/app.git, or "" if the remote is a path on this
+// machine.
+func RemoteHost(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && strings.Contains(rawURL, "://") {
+		if u.Scheme == "file" {
+			return ""
+		}
+		return strings.ToLower(u.Hostname())
+	}
+	// The scp-like [user@]host:path, as long as no slash comes before the
+	// colon and it isn't a Windows drive
+	colon := strings.Index(rawURL, ":")
+	if colon <= 0 || strings.Contains(rawURL[:colon], "/") || filepath.VolumeName(rawURL) != "" {
+		return ""
+	}
+	host := rawURL[:colon]
+	if at := strings.LastIndex(host, "@"); at >= 0 {
+		host = host[at+1:]
+	}
+	return strings.ToLower(host)
+}
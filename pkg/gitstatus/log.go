@@ -0,0 +1,137 @@
+package gitstatus
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Commit is a single entry from git log.
+type Commit struct {
+	Hash    string
+	Short   string
+	Author  string
+	Date    time.Time
+	Subject string
+}
+
+// Log returns up to limit commits reachable from HEAD, newest first.
+func Log(repoPath string, limit int) ([]Commit, error) {
+	output, err := Run(repoPath, "log", "-n", strconv.Itoa(limit),
+		"--format=%H%x1f%h%x1f%an%x1f%at%x1f%s")
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 5 {
+			continue
+		}
+		unix, _ := strconv.ParseInt(fields[3], 10, 64)
+		commits = append(commits, Commit{
+			Hash:    fields[0],
+			Short:   fields[1],
+			Author:  fields[2],
+			Date:    time.Unix(unix, 0),
+			Subject: fields[4],
+		})
+	}
+	return commits, nil
+}
+
+// CommitCount returns the number of commits on local branches made since
+// since, only counting those by author if it isn't empty.
+func CommitCount(repoPath string, since time.Time, author string) (int, error) {
+	args := []string{"rev-list", "--count", "--branches", "--since=" + strconv.FormatInt(since.Unix(), 10)}
+	if author != "" {
+		args = append(args, "--author="+author)
+	}
+	output, err := Run(repoPath, args...)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(output)))
+}
+
+// LastCommitDate returns the committer date of the newest commit on any
+// local branch, or the zero time if there are no commits.
+func LastCommitDate(repoPath string) (time.Time, error) {
+	return newestCommitDate(repoPath, "refs/heads")
+}
+
+// LastActivity returns when a repository last saw work: the committer date
+// of the newest commit on any local branch or, as last fetched, on any
+// remote-tracking branch. It is the zero time if there are no commits.
+func LastActivity(repoPath string) (time.Time, error) {
+	return newestCommitDate(repoPath, "refs/heads", "refs/remotes")
+}
+
+// newestCommitDate returns the newest committer date of the refs matching
+// patterns, or the zero time if there are none.
+func newestCommitDate(repoPath string, patterns ...string) (time.Time, error) {
+	args := append([]string{"for-each-ref", "--sort=-committerdate", "--count=1", "--format=%(committerdate:unix)"}, patterns...)
+	output, err := Run(repoPath, args...)
+	if err != nil {
+		return time.Time{}, err
+	}
+	unix, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil {
+		return time.Time{}, nil
+	}
+	return time.Unix(unix, 0), nil
+}
+
+// conventionalSubject matches a Conventional Commits subject, capturing its
+// scope, e.g. "api" in "fix(api)!: handle timeouts".
+var conventionalSubject = regexp.MustCompile(`^[a-z]+\(([^()]+)\)!?: `)
+
+// CommitScopes returns the Conventional Commits scopes used in the last
+// limit commits, most used first.
+func CommitScopes(repoPath string, limit int) ([]string, error) {
+	commits, err := Log(repoPath, limit)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for _, c := range commits {
+		if m := conventionalSubject.FindStringSubmatch(c.Subject); m != nil {
+			counts[m[1]]++
+		}
+	}
+	scopes := make([]string, 0, len(counts))
+	for scope := range counts {
+		scopes = append(scopes, scope)
+	}
+	sort.Slice(scopes, func(i, j int) bool {
+		if counts[scopes[i]] != counts[scopes[j]] {
+			return counts[scopes[i]] > counts[scopes[j]]
+		}
+		return scopes[i] < scopes[j]
+	})
+	return scopes, nil
+}
+
+// Show returns the full message, file stat, and patch of a commit.
+// Binary file contents are never included since git summarises them.
+func Show(repoPath, hash string) (string, error) {
+	output, err := Run(repoPath, "show", "--stat", "--patch", "--format=fuller", hash, "--")
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
+
+// Graph returns git's ASCII graph of all branches, newest first,
+// with branch and tag names decorated and git's own colouring.
+func Graph(repoPath string, limit int) (string, error) {
+	output, err := Run(repoPath, "log", "--graph", "--oneline", "--decorate", "--all",
+		"--color=always", "-n", strconv.Itoa(limit))
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
@@ -0,0 +1,65 @@
+package gitstatus
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// EnableMaintenance registers the local repositories among repos with git
+// maintenance, which runs housekeeping such as gc, commit-graph updates,
+// and prefetching in the background so that status stays fast, and makes
+// sure git's scheduler is set up to run it. Repositories already registered
+// are left alone, so once all are this costs a single git command. It
+// returns the repositories it registered.
+func EnableMaintenance(ctx context.Context, repos []string) ([]string, error) {
+	// "git config --get-all" exits 1 when the key isn't set
+	output, err := RunContext(ctx, "", "config", "--global", "--get-all", "maintenance.repo")
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		return nil, err
+	}
+	registered := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		registered[line] = true
+	}
+
+	var pending []string
+	for _, repo := range repos {
+		if IsSSH(repo) || !IsRepository(repo) {
+			continue
+		}
+		// git records the repository's real path
+		dir := RepoDir(repo)
+		if real, err := filepath.EvalSymlinks(dir); err == nil {
+			dir = real
+		}
+		if !registered[dir] {
+			registered[dir] = true
+			pending = append(pending, dir)
+		}
+	}
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	// "start" sets up the scheduler, which runs maintenance on every
+	// registered repository, and then registers the repository it runs in.
+	// It fails before registering anything if there is no scheduler, which
+	// matters as registering turns off git's automatic gc after commands.
+	if _, err := RunContext(ctx, pending[0], "maintenance", "start"); err != nil {
+		return nil, err
+	}
+	added := pending[:1]
+	var errs []error
+	for _, dir := range pending[1:] {
+		if _, err := RunContext(ctx, dir, "maintenance", "register"); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		added = append(added, dir)
+	}
+	return added, errors.Join(errs...)
+}
@@ -0,0 +1,79 @@
+package gitstatus
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// readmeNames are the file names Readme looks for, most preferred first.
+// Case is ignored.
+var readmeNames = []string{"readme.md", "readme.markdown", "readme", "readme.txt", "readme.rst"}
+
+// Readme returns the name and content of the README in the repository, or
+// in the subdirectory it is scoped to. It reads the working tree, so local
+// edits show; for a repository on another host it reads the README at HEAD.
+// name is empty if there is no README.
+func Readme(repo string) (name, content string, err error) {
+	if IsSSH(repo) {
+		return sshReadme(repo)
+	}
+
+	dir := WorkDir(repo)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", "", err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	if name = pickReadme(names); name == "" {
+		return "", "", nil
+	}
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return "", "", err
+	}
+	return name, string(data), nil
+}
+
+// sshReadme is Readme for a repository on another host.
+func sshReadme(repo string) (name, content string, err error) {
+	_, subdir := SplitSubdir(repo)
+	prefix := ""
+	if subdir != "" {
+		prefix = subdir + "/"
+	}
+	out, err := Run(repo, "ls-tree", "--name-only", "HEAD", prefix)
+	if err != nil {
+		return "", "", err
+	}
+	var names []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		names = append(names, path.Base(line))
+	}
+	if name = pickReadme(names); name == "" {
+		return "", "", nil
+	}
+	data, err := Run(repo, "show", "HEAD:"+prefix+name)
+	if err != nil {
+		return "", "", err
+	}
+	return name, string(data), nil
+}
+
+// pickReadme returns the most preferred README among names, or "".
+func pickReadme(names []string) string {
+	for _, want := range readmeNames {
+		for _, name := range names {
+			if strings.EqualFold(name, want) {
+				return name
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,49 @@
+package gitstatus
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReflogEntry is a single movement of HEAD recorded in the reflog.
+type ReflogEntry struct {
+	Hash     string
+	Short    string
+	Selector string // e.g. "HEAD@{3}"
+	Subject  string // e.g. "reset: moving to HEAD~2"
+	Date     time.Time
+}
+
+// Reflog returns up to limit HEAD reflog entries, newest first.
+func Reflog(repoPath string, limit int) ([]ReflogEntry, error) {
+	// With --date=unix the selector is printed as HEAD@{<timestamp>}, which
+	// gives the time of the movement rather than of the commit.
+	output, err := Run(repoPath, "log", "--walk-reflogs", "-n", strconv.Itoa(limit),
+		"--date=unix", "--format=%H%x1f%h%x1f%gd%x1f%gs", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []ReflogEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+		var date time.Time
+		if start := strings.Index(fields[2], "@{"); start >= 0 {
+			unix, _ := strconv.ParseInt(strings.TrimSuffix(fields[2][start+2:], "}"), 10, 64)
+			date = time.Unix(unix, 0)
+		}
+		entries = append(entries, ReflogEntry{
+			Hash:     fields[0],
+			Short:    fields[1],
+			Selector: fmt.Sprintf("HEAD@{%d}", len(entries)),
+			Subject:  fields[3],
+			Date:     date,
+		})
+	}
+	return entries, nil
+}
@@ -0,0 +1,190 @@
+package gitstatus
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Command is a command for a Runner to run: git, unless Program names
+// another, with Args in the repository Dir.
+type Command struct {
+	Dir     string // the repository, as the package's functions take it
+	Args    []string
+	Program string   // run instead of git, e.g. "pre-commit"; "" for git
+	Env     []string // added to the environment, e.g. "GIT_INDEX_FILE=..."
+	Stdin   io.Reader
+	// Output, if set, receives stdout and stderr as they are written, and
+	// nothing is returned; a failure's Stderr holds both
+	Output io.Writer
+}
+
+// name returns the program the command runs.
+func (c Command) name() string {
+	if c.Program != "" {
+		return c.Program
+	}
+	return "git"
+}
+
+// Runner runs commands for the package: every status check, fetch, diff,
+// and action goes through one. ExecRunner is used unless a context carries
+// another, put there with WithRunner or by a Store set up with UseRunner,
+// so code built on the package can be exercised with a FakeRunner, without
+// repositories or a git executable.
+type Runner interface {
+	// Run runs cmd once and returns its stdout. A failure should be a
+	// *Error, so it is classified and summarised like git's own.
+	Run(ctx context.Context, cmd Command) ([]byte, error)
+}
+
+type runnerKey struct{}
+
+// WithRunner returns a copy of ctx in which the package's functions run
+// commands through r.
+func WithRunner(ctx context.Context, r Runner) context.Context {
+	return context.WithValue(ctx, runnerKey{}, r)
+}
+
+// runnerFrom returns the runner ctx carries, or ExecRunner.
+func runnerFrom(ctx context.Context) Runner {
+	if r, ok := ctx.Value(runnerKey{}).(Runner); ok && r != nil {
+		return r
+	}
+	return ExecRunner{}
+}
+
+// ExecRunner runs the git executable, in the repository's root, or on its
+// host through ssh for an ssh:// URL. A cancelled command is interrupted
+// so git can clean up its lock files, then killed if it hasn't exited after
+// a short delay.
+type ExecRunner struct{}
+
+// Run implements Runner.
+func (ExecRunner) Run(ctx context.Context, c Command) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.name(), c.Args...)
+	cmd.Dir = RepoDir(c.Dir)
+	if IsSSH(c.Dir) {
+		if c.Program != "" || len(c.Env) > 0 {
+			return nil, &Error{Dir: c.Dir, Args: c.Args, Err: errors.New("not supported on another host"), Time: time.Now()}
+		}
+		var err error
+		if cmd, err = sshCommand(ctx, RepoDir(c.Dir), c.Args...); err != nil {
+			return nil, &Error{Dir: c.Dir, Args: c.Args, Err: err, Time: time.Now()}
+		}
+	}
+	if len(c.Env) > 0 {
+		cmd.Env = append(os.Environ(), c.Env...)
+	}
+	cmd.Stdin = c.Stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if c.Output != nil {
+		// stderr collects both, in the order they are written
+		w := io.MultiWriter(&stderr, c.Output)
+		cmd.Stdout = w
+		cmd.Stderr = w
+	}
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = gitWaitDelay
+	start := time.Now()
+	err := cmd.Run()
+	slog.Debug(c.name(), "dir", c.Dir, "args", c.Args, "duration", time.Since(start), "err", err)
+	if err != nil {
+		return stdout.Bytes(), &Error{
+			Dir:    c.Dir,
+			Args:   c.Args,
+			Stderr: stderr.String(),
+			Err:    err,
+			Time:   time.Now(),
+		}
+	}
+	return stdout.Bytes(), nil
+}
+
+// FakeRunner is a Runner that answers commands with canned output instead
+// of running them, and records the commands it was given. Commands are
+// matched by their arguments joined with spaces, e.g. "fetch --quiet",
+// after the program if it isn't git, e.g. "pre-commit run"; any other
+// command fails as git does for an unknown one. A FakeRunner is safe for
+// concurrent use.
+type FakeRunner struct {
+	mu        sync.Mutex
+	responses map[string]fakeResponse
+	calls     []Command
+}
+
+type fakeResponse struct {
+	stdout string
+	stderr string
+	fail   bool
+}
+
+// NewFakeRunner returns a FakeRunner without any responses.
+func NewFakeRunner() *FakeRunner {
+	return &FakeRunner{responses: map[string]fakeResponse{}}
+}
+
+// Respond makes the command args succeed with stdout.
+func (f *FakeRunner) Respond(args, stdout string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses[args] = fakeResponse{stdout: stdout}
+}
+
+// Fail makes the command args fail with stderr, which is classified like
+// git's, e.g. "fatal: Authentication failed" as ErrorAuth. stderr may be
+// empty, as for "diff --quiet" finding differences.
+func (f *FakeRunner) Fail(args, stderr string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses[args] = fakeResponse{stderr: stderr, fail: true}
+}
+
+// Calls returns the commands run so far, oldest first.
+func (f *FakeRunner) Calls() []Command {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Command(nil), f.calls...)
+}
+
+// Run implements Runner.
+func (f *FakeRunner) Run(ctx context.Context, c Command) ([]byte, error) {
+	key := strings.Join(c.Args, " ")
+	if c.Program != "" {
+		key = c.Program + " " + key
+	}
+	c.Args = append([]string(nil), c.Args...)
+	f.mu.Lock()
+	f.calls = append(f.calls, c)
+	r, ok := f.responses[key]
+	f.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, &Error{Dir: c.Dir, Args: c.Args, Err: err, Time: time.Now()}
+	}
+	if !ok {
+		r = fakeResponse{stderr: c.name() + ": '" + key + "' has no fake response", fail: true}
+	}
+	if c.Output != nil {
+		io.WriteString(c.Output, r.stdout+r.stderr)
+	}
+	if r.fail {
+		return nil, &Error{Dir: c.Dir, Args: c.Args, Stderr: r.stderr, Err: errFakeFailure, Time: time.Now()}
+	}
+	if c.Output != nil {
+		return nil, nil
+	}
+	return []byte(r.stdout), nil
+}
+
+// errFakeFailure is the underlying error of commands a FakeRunner fails,
+// standing in for git's exit status.
+var errFakeFailure = errors.New("exit status 128")
@@ -0,0 +1,212 @@
+package gitstatus
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+)
+
+// fakeRepo returns a directory that passes for a repository, and a
+// FakeRunner answering the commands a check of it runs.
+func fakeRepo(t *testing.T) (string, *FakeRunner) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	fake := NewFakeRunner()
+	fake.Respond("--no-optional-locks status --porcelain", " M main.go\n?? notes.txt\n")
+	fake.Respond("branch --show-current", "main\n")
+	fake.Respond("remote", "origin\n")
+	fake.Respond("symbolic-ref --quiet --short refs/remotes/origin/HEAD", "origin/main\n")
+	fake.Respond("--no-optional-locks rev-parse --abbrev-ref main@{upstream}", "origin/main\n")
+	fake.Respond("rev-list --left-right --count main...origin/main", "1\t2\n")
+	return dir, fake
+}
+
+func TestCheckWithFakeRunner(t *testing.T) {
+	dir, fake := fakeRepo(t)
+	status := CheckContext(WithRunner(context.Background(), fake), dir)
+
+	if status.HasError {
+		t.Fatalf("check failed: %s", status.Error)
+	}
+	want := []File{{Path: "main.go", Status: "M"}, {Path: "notes.txt", Status: "??"}}
+	if !slices.Equal(status.Files, want) {
+		t.Errorf("Files = %v, want %v", status.Files, want)
+	}
+	if status.Branch != "main" || status.DefaultBranch != "main" {
+		t.Errorf("Branch, DefaultBranch = %q, %q, want main, main", status.Branch, status.DefaultBranch)
+	}
+	if status.Ahead != 1 || status.Behind != 2 || !status.NeedsPull {
+		t.Errorf("Ahead, Behind, NeedsPull = %d, %d, %t, want 1, 2, true", status.Ahead, status.Behind, status.NeedsPull)
+	}
+	if status.RemoteStatus != "2 commits behind" {
+		t.Errorf("RemoteStatus = %q", status.RemoteStatus)
+	}
+}
+
+func TestCheckReportsFailedStatus(t *testing.T) {
+	dir, fake := fakeRepo(t)
+	fake.Fail("--no-optional-locks status --porcelain", "fatal: index file smaller than expected")
+	status := CheckContext(WithRunner(context.Background(), fake), dir)
+
+	if !status.HasError || status.ErrorKind != ErrorCorrupt {
+		t.Errorf("HasError, ErrorKind = %t, %q, want true, %q", status.HasError, status.ErrorKind, ErrorCorrupt)
+	}
+	if status.ErrorDetail == nil || status.ErrorDetail.Command() != "git --no-optional-locks status --porcelain" {
+		t.Errorf("ErrorDetail = %v", status.ErrorDetail)
+	}
+}
+
+func TestStoreFetchWithFakeRunner(t *testing.T) {
+	dir, fake := fakeRepo(t)
+	fake.Respond("fetch --quiet", "")
+	store := NewStore()
+	store.UseRunner(fake)
+
+	if err := store.Fetch(context.Background(), dir); err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	calls := fake.Calls()
+	if len(calls) == 0 || strings.Join(calls[0].Args, " ") != "fetch --quiet" || calls[0].Dir != dir {
+		t.Errorf("first call = %+v, want fetch --quiet in %s", calls[0], dir)
+	}
+	status, ok := store.Status(dir)
+	if !ok || status.Behind != 2 {
+		t.Errorf("status after fetch = %+v, %t", status, ok)
+	}
+}
+
+func TestStoreFetchFailure(t *testing.T) {
+	dir, fake := fakeRepo(t)
+	fake.Fail("fetch --quiet", "fatal: Authentication failed for 'https://example.com/app.git/'")
+	store := NewStore()
+	store.UseRunner(fake)
+
+	err := store.Fetch(context.Background(), dir)
+	if ClassifyError(err) != ErrorAuth {
+		t.Fatalf("Fetch error = %v, want an authentication failure", err)
+	}
+	status, _ := store.Status(dir)
+	if !strings.HasPrefix(status.RemoteStatus, "Fetch failed: authentication required") || status.ErrorKind != ErrorAuth {
+		t.Errorf("RemoteStatus, ErrorKind = %q, %q", status.RemoteStatus, status.ErrorKind)
+	}
+	if status.HasError {
+		t.Errorf("a failed fetch marked the repository as failing: %s", status.Error)
+	}
+}
+
+func TestFileDiffWithFakeRunner(t *testing.T) {
+	dir, fake := fakeRepo(t)
+	ctx := WithRunner(context.Background(), fake)
+
+	fake.Respond("diff HEAD -- main.go", "diff --git a/main.go b/main.go\n")
+	if diff, err := FileDiffContext(ctx, dir, "main.go"); err != nil || diff != "diff --git a/main.go b/main.go\n" {
+		t.Errorf("working tree diff = %q, %v", diff, err)
+	}
+
+	// Nothing against HEAD, as in a repository without commits, falls back
+	// to what is staged
+	fake.Respond("diff HEAD -- staged.go", "")
+	fake.Respond("diff --cached -- staged.go", "+staged\n")
+	if diff, err := FileDiffContext(ctx, dir, "staged.go"); err != nil || diff != "+staged\n" {
+		t.Errorf("staged diff = %q, %v", diff, err)
+	}
+
+	// An untracked file is shown whole
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("remember\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fake.Respond("diff HEAD -- notes.txt", "")
+	fake.Respond("diff --cached -- notes.txt", "")
+	fake.Respond("--no-optional-locks status --porcelain -- notes.txt", "?? notes.txt\n")
+	if diff, err := FileDiffContext(ctx, dir, "notes.txt"); err != nil || diff != "New file: notes.txt\n\nremember\n" {
+		t.Errorf("untracked diff = %q, %v", diff, err)
+	}
+
+	fake.Respond("diff HEAD -- logo.png", "\x00PNG")
+	if diff, err := FileDiffContext(ctx, dir, "logo.png"); err != nil || diff != "Binary file: logo.png" {
+		t.Errorf("binary diff = %q, %v", diff, err)
+	}
+}
+
+func TestStoreFileDiffUsesRunner(t *testing.T) {
+	dir, fake := fakeRepo(t)
+	fake.Respond("diff HEAD -- main.go", "+fake\n")
+	store := NewStore()
+	store.UseRunner(fake)
+
+	if diff, err := store.FileDiff(dir, "main.go"); err != nil || diff != "+fake\n" {
+		t.Errorf("diff = %q, %v", diff, err)
+	}
+}
+
+func TestCreateCommitWithFakeRunner(t *testing.T) {
+	dir, fake := fakeRepo(t)
+	// "diff --cached --quiet" fails when something is staged
+	fake.Fail("diff --cached --quiet", "")
+	fake.Respond("commit --cleanup=strip --file=- --signoff", "[main 1a2b3c4] Fix the build\n")
+	ctx := WithRunner(context.Background(), fake)
+
+	var output strings.Builder
+	if err := CreateCommit(ctx, dir, "Fix the build", CommitOptions{Signoff: true}, &output); err != nil {
+		t.Fatalf("CreateCommit: %v", err)
+	}
+	if output.String() != "[main 1a2b3c4] Fix the build\n" {
+		t.Errorf("output = %q", output.String())
+	}
+	calls := fake.Calls()
+	commit := calls[len(calls)-1]
+	message, _ := io.ReadAll(commit.Stdin)
+	if string(message) != "Fix the build" {
+		t.Errorf("commit message = %q", message)
+	}
+	for _, call := range calls {
+		if call.Args[0] == "add" {
+			t.Errorf("staged everything although something was staged: %v", call.Args)
+		}
+	}
+}
+
+func TestGoGitRunner(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := git.PlainInit(dir, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Commands go-git doesn't answer go to the fallback
+	fallback := NewFakeRunner()
+	fallback.Respond("symbolic-ref --quiet --short refs/remotes/origin/HEAD", "origin/main\n")
+	ctx := WithRunner(context.Background(), GoGitRunner{Fallback: fallback})
+
+	out, err := RunContext(ctx, dir, "--no-optional-locks", "status", "--porcelain")
+	if err != nil || string(out) != "?? main.go\n" {
+		t.Errorf("status = %q, %v", out, err)
+	}
+	out, err = RunContext(ctx, dir, "branch", "--show-current")
+	if err != nil || string(out) != "master\n" {
+		t.Errorf("branch = %q, %v", out, err)
+	}
+	out, err = RunContext(ctx, dir, "remote")
+	if err != nil || string(out) != "" {
+		t.Errorf("remote = %q, %v", out, err)
+	}
+	if err := Fetch(ctx, dir); err != nil {
+		t.Errorf("fetch without a remote: %v", err)
+	}
+	if len(fallback.Calls()) != 0 {
+		t.Errorf("fallback ran %v", fallback.Calls())
+	}
+	if _, err := RunContext(ctx, dir, "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD"); err != nil || len(fallback.Calls()) != 1 {
+		t.Errorf("symbolic-ref wasn't passed on: %v, %v", err, fallback.Calls())
+	}
+}
@@ -0,0 +1,91 @@
+package gitstatus
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ScanDepth is how many directories below its root Scan looks by default.
+const ScanDepth = 4
+
+// FoundRepo is a repository found by Scan.
+type FoundRepo struct {
+	Path      string
+	Parent    string // closest enclosing repository, if nested inside one
+	Submodule bool   // a submodule working copy of Parent
+}
+
+// scanSkip lists directories Scan never looks in: they hold installed
+// dependencies rather than checkouts worth monitoring.
+var scanSkip = map[string]bool{
+	"node_modules": true,
+}
+
+// Scan looks for git repositories in root and the directories up to depth
+// levels below it, skipping hidden directories. Repositories are returned
+// in walk order, so one comes before those nested inside it, such as
+// vendored checkouts and submodule working copies, which have Parent set.
+func Scan(ctx context.Context, root string, depth int) ([]FoundRepo, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []FoundRepo
+	var parents []string // enclosing repositories, innermost last
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			// Unreadable directories below the root are left out
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root {
+			if strings.HasPrefix(d.Name(), ".") || scanSkip[d.Name()] {
+				return filepath.SkipDir
+			}
+			rel, _ := filepath.Rel(root, path)
+			if strings.Count(rel, string(filepath.Separator)) >= depth {
+				return filepath.SkipDir
+			}
+		}
+
+		info, err := os.Lstat(filepath.Join(path, ".git"))
+		if err != nil {
+			return nil
+		}
+		for len(parents) > 0 && !strings.HasPrefix(path, parents[len(parents)-1]+string(filepath.Separator)) {
+			parents = parents[:len(parents)-1]
+		}
+		repo := FoundRepo{Path: path}
+		if len(parents) > 0 {
+			repo.Parent = parents[len(parents)-1]
+			repo.Submodule = !info.IsDir() && isSubmoduleCheckout(path)
+		}
+		found = append(found, repo)
+		parents = append(parents, path)
+		return nil
+	})
+	return found, err
+}
+
+// isSubmoduleCheckout reports whether the .git file in path points into
+// another repository's modules directory, as submodule working copies do.
+func isSubmoduleCheckout(path string) bool {
+	data, err := os.ReadFile(filepath.Join(path, ".git"))
+	if err != nil {
+		return false
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+	return ok && strings.Contains(filepath.ToSlash(gitDir), "/modules/")
+}
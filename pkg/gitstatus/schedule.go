@@ -0,0 +1,72 @@
+package gitstatus
+
+import (
+	"sync"
+	"time"
+)
+
+// FetchPlan is how a repository is fetched automatically.
+type FetchPlan struct {
+	Interval time.Duration        // 0 for the scheduler's default
+	Quiet    func(time.Time) bool // reports whether the repository isn't fetched at a time; nil for never
+}
+
+// FetchScheduler decides which repositories are due to be fetched
+// automatically: each one every interval of its plan, except in its quiet
+// hours.
+type FetchScheduler struct {
+	interval time.Duration // for plans without one; 0 for only those with one
+	plan     func(repo string) FetchPlan
+	mu       sync.Mutex
+	last     map[string]time.Time
+}
+
+// NewFetchScheduler returns a scheduler taking each repository's plan from
+// plan. Repositories whose plan has no interval are fetched every interval,
+// or never if it is 0.
+func NewFetchScheduler(interval time.Duration, plan func(repo string) FetchPlan) *FetchScheduler {
+	return &FetchScheduler{interval: interval, plan: plan, last: map[string]time.Time{}}
+}
+
+// Due returns the repos that are due at now and records them as fetched.
+// A repository not fetched before is due unless it is in its quiet hours.
+func (s *FetchScheduler) Due(repos []string, now time.Time) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []string
+	for _, repo := range repos {
+		plan := s.plan(repo)
+		interval := plan.Interval
+		if interval == 0 {
+			interval = s.interval
+		}
+		if interval == 0 || plan.Quiet != nil && plan.Quiet(now) {
+			continue
+		}
+		// A tick arriving a little early still counts
+		if last, ok := s.last[repo]; ok && now.Sub(last) < interval-SchedulerTick/2 {
+			continue
+		}
+		s.last[repo] = now
+		due = append(due, repo)
+	}
+	return due
+}
+
+// Quiet reports whether repo is in its quiet hours at t.
+func (s *FetchScheduler) Quiet(repo string, t time.Time) bool {
+	plan := s.plan(repo)
+	return plan.Quiet != nil && plan.Quiet(t)
+}
+
+// Fetched records that repo was just fetched some other way, e.g. because
+// the user asked, which restarts its interval.
+func (s *FetchScheduler) Fetched(repo string, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.last[repo] = now
+}
+
+// SchedulerTick is how often a FetchScheduler should be asked which
+// repositories are due.
+const SchedulerTick = time.Minute
@@ -0,0 +1,229 @@
+package gitstatus
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// Snapshot is the status of every repository at one point in time, as
+// saved by a background gitmoni process for quick reading by others, such
+// as status bar integrations.
+type Snapshot struct {
+	Time     time.Time `json:"time"`
+	Statuses []Status  `json:"statuses"`
+}
+
+// DefaultSnapshotPath returns gitmoni/status.json in the user's cache
+// directory.
+func DefaultSnapshotPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gitmoni", "status.json"), nil
+}
+
+// NewSnapshot returns a snapshot of statuses taken now, ordered by
+// repository path.
+func NewSnapshot(statuses map[string]Status) Snapshot {
+	snap := Snapshot{Time: time.Now(), Statuses: make([]Status, 0, len(statuses))}
+	for _, status := range statuses {
+		snap.Statuses = append(snap.Statuses, status)
+	}
+	sort.Slice(snap.Statuses, func(i, j int) bool {
+		return snap.Statuses[i].Path < snap.Statuses[j].Path
+	})
+	return snap
+}
+
+// WriteSnapshot saves statuses to path, ordered by repository path. The file
+// is replaced atomically, so readers never see a partial snapshot.
+func WriteSnapshot(path string, statuses map[string]Status) error {
+	data, err := json.MarshalIndent(NewSnapshot(statuses), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".status-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ReadSnapshot loads the snapshot saved at path.
+func ReadSnapshot(path string) (Snapshot, error) {
+	var snap Snapshot
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return snap, err
+	}
+	err = json.Unmarshal(data, &snap)
+	return snap, err
+}
+
+// Summary counts the repositories in a snapshot that need attention. A
+// repository can be both dirty and behind.
+type Summary struct {
+	Repos  int
+	Dirty  int // with uncommitted changes
+	Behind int // behind their upstream
+	Errors int // whose status could not be checked
+}
+
+// Summary counts the snapshot's repositories by what needs attention.
+func (s Snapshot) Summary() Summary {
+	sum := Summary{Repos: len(s.Statuses)}
+	for _, status := range s.Statuses {
+		switch {
+		case status.HasError:
+			sum.Errors++
+			continue
+		case len(status.Files) > 0:
+			sum.Dirty++
+		}
+		if status.NeedsPull {
+			sum.Behind++
+		}
+	}
+	return sum
+}
+
+// Clean reports whether no repository needs attention.
+func (s Summary) Clean() bool {
+	return s.Dirty == 0 && s.Behind == 0 && s.Errors == 0
+}
+
+// validSnapshotName matches the names snapshots can be saved under.
+var validSnapshotName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// SnapshotDir returns gitmoni/snapshots in the user's cache directory, where
+// named snapshots are kept.
+func SnapshotDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gitmoni", "snapshots"), nil
+}
+
+// NamedSnapshotPath returns the file of the snapshot called name in
+// SnapshotDir. Names are made of letters, digits, dots, dashes, and
+// underscores.
+func NamedSnapshotPath(name string) (string, error) {
+	if !validSnapshotName.MatchString(name) {
+		return "", fmt.Errorf("invalid snapshot name %q: use letters, digits, '.', '-', and '_'", name)
+	}
+	dir, err := SnapshotDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+".json"), nil
+}
+
+// RepoDiff is how a repository's status differs between two snapshots.
+// Fields prefixed Old are from the earlier snapshot.
+type RepoDiff struct {
+	Path      string `json:"path"`
+	Added     bool   `json:"added,omitempty"`   // only in the later snapshot
+	Removed   bool   `json:"removed,omitempty"` // only in the earlier snapshot
+	OldBranch string `json:"old_branch,omitempty"`
+	Branch    string `json:"branch,omitempty"`
+	NewFiles  []File `json:"new_files,omitempty"`  // changed files that weren't, or had another status
+	GoneFiles []File `json:"gone_files,omitempty"` // changed files that no longer are
+	OldAhead  int    `json:"old_ahead"`
+	Ahead     int    `json:"ahead"`
+	OldBehind int    `json:"old_behind"`
+	Behind    int    `json:"behind"`
+	OldError  string `json:"old_error,omitempty"`
+	Error     string `json:"error,omitempty"`
+	OldRemote string `json:"old_remote_status,omitempty"`
+	Remote    string `json:"remote_status,omitempty"`
+}
+
+// CompareSnapshots returns how each repository's status changed from
+// before to after, ordered by path. Repositories that didn't change are
+// left out.
+func CompareSnapshots(before, after Snapshot) []RepoDiff {
+	old := make(map[string]Status, len(before.Statuses))
+	for _, status := range before.Statuses {
+		old[status.Path] = status
+	}
+	var diffs []RepoDiff
+	for _, cur := range after.Statuses {
+		prev, ok := old[cur.Path]
+		delete(old, cur.Path)
+		if !ok {
+			diffs = append(diffs, RepoDiff{Path: cur.Path, Added: true, Branch: cur.Branch, NewFiles: cur.Files,
+				Ahead: cur.Ahead, Behind: cur.Behind, Error: cur.Error, Remote: cur.RemoteStatus})
+			continue
+		}
+		d := RepoDiff{
+			Path:      cur.Path,
+			OldBranch: prev.Branch,
+			Branch:    cur.Branch,
+			OldAhead:  prev.Ahead,
+			Ahead:     cur.Ahead,
+			OldBehind: prev.Behind,
+			Behind:    cur.Behind,
+			OldError:  prev.Error,
+			Error:     cur.Error,
+			OldRemote: prev.RemoteStatus,
+			Remote:    cur.RemoteStatus,
+		}
+		d.NewFiles, d.GoneFiles = diffFiles(prev.Files, cur.Files)
+		if d.Changed() {
+			diffs = append(diffs, d)
+		}
+	}
+	for _, prev := range old {
+		diffs = append(diffs, RepoDiff{Path: prev.Path, Removed: true, OldBranch: prev.Branch, GoneFiles: prev.Files,
+			OldAhead: prev.Ahead, OldBehind: prev.Behind, OldError: prev.Error, OldRemote: prev.RemoteStatus})
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+	return diffs
+}
+
+// Changed reports whether anything differs.
+func (d RepoDiff) Changed() bool {
+	return d.Added || d.Removed || d.OldBranch != d.Branch || len(d.NewFiles) > 0 || len(d.GoneFiles) > 0 ||
+		d.OldAhead != d.Ahead || d.OldBehind != d.Behind || d.OldError != d.Error || d.OldRemote != d.Remote
+}
+
+// diffFiles returns the files in after that aren't in before with the same
+// status, and the files in before whose paths aren't in after.
+func diffFiles(before, after []File) (added, gone []File) {
+	old := make(map[string]string, len(before))
+	for _, f := range before {
+		old[f.Path] = f.Status
+	}
+	cur := make(map[string]bool, len(after))
+	for _, f := range after {
+		cur[f.Path] = true
+		if status, ok := old[f.Path]; !ok || status != f.Status {
+			added = append(added, f)
+		}
+	}
+	for _, f := range before {
+		if !cur[f.Path] {
+			gone = append(gone, f)
+		}
+	}
+	return added, gone
+}
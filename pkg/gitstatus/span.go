@@ -0,0 +1,90 @@
+package gitstatus
+
+import (
+	"context"
+	"crypto/rand"
+	"sync/atomic"
+	"time"
+)
+
+// Span is a timed operation for distributed tracing: a git command, a
+// status check or fetch of a repository, or a refresh cycle or fetch batch
+// across repositories. Spans started while another is in the context are
+// its children and share its trace.
+type Span struct {
+	TraceID [16]byte
+	ID      [8]byte
+	Parent  [8]byte // zero for a root span
+	Name    string
+	Start   time.Time
+	End     time.Time
+	Attrs   map[string]string
+	Err     error
+}
+
+// SpanExporter receives spans as they end. It must be safe for concurrent
+// use and shouldn't block.
+type SpanExporter interface {
+	ExportSpan(s *Span)
+}
+
+var spanExporter atomic.Pointer[SpanExporter]
+
+// SetSpanExporter makes every span end up in e; nil stops tracing. Without
+// an exporter spans aren't even created, so tracing costs nothing.
+func SetSpanExporter(e SpanExporter) {
+	if e == nil {
+		spanExporter.Store(nil)
+		return
+	}
+	spanExporter.Store(&e)
+}
+
+type spanKey struct{}
+
+// StartSpan starts a span named name as a child of the span in ctx, if any,
+// and returns a context carrying it. attrs are key-value pairs. It returns
+// a nil span, whose End does nothing, if there is no exporter.
+func StartSpan(ctx context.Context, name string, attrs ...string) (context.Context, *Span) {
+	if spanExporter.Load() == nil {
+		return ctx, nil
+	}
+	s := &Span{Name: name, Start: time.Now(), Attrs: make(map[string]string, len(attrs)/2)}
+	for i := 0; i+1 < len(attrs); i += 2 {
+		s.Attrs[attrs[i]] = attrs[i+1]
+	}
+	if parent, ok := ctx.Value(spanKey{}).(*Span); ok {
+		s.TraceID, s.Parent = parent.TraceID, parent.ID
+	} else {
+		rand.Read(s.TraceID[:])
+	}
+	rand.Read(s.ID[:])
+	return WithSpan(ctx, s), s
+}
+
+// WithSpan returns a context carrying s, so spans started with it are s's
+// children. It returns ctx unchanged if s is nil.
+func WithSpan(ctx context.Context, s *Span) context.Context {
+	if s == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, spanKey{}, s)
+}
+
+// SetAttr records an attribute of the span.
+func (s *Span) SetAttr(key, value string) {
+	if s != nil {
+		s.Attrs[key] = value
+	}
+}
+
+// Finish ends the span, recording err as its failure, and exports it.
+func (s *Span) Finish(err error) {
+	if s == nil {
+		return
+	}
+	s.End, s.Err = time.Now(), err
+	if e := spanExporter.Load(); e != nil {
+		(*e).ExportSpan(s)
+	}
+}
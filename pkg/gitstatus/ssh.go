@@ -0,0 +1,156 @@
+package gitstatus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// Repositories on other machines are given as ssh://[user@]host[:port]/path
+// URLs. Their git commands run on the host through the ssh client, so the
+// user's ssh config, keys, and agent apply; a path starting with /~/ is
+// relative to the remote user's home directory.
+
+// sshTimeout is ssh's ConnectTimeout, in seconds.
+const sshTimeout = "10"
+
+// IsSSH reports whether repo is an ssh:// URL rather than a local path.
+func IsSSH(repo string) bool {
+	return strings.HasPrefix(repo, "ssh://")
+}
+
+// splitSSH returns the ssh destination and the remote directory of an ssh://
+// repository.
+func splitSSH(repo string) (dest, dir string, err error) {
+	u, err := url.Parse(repo)
+	if err != nil {
+		return "", "", err
+	}
+	if u.Host == "" || u.Path == "" || u.Path == "/" {
+		return "", "", fmt.Errorf("invalid ssh repository %q: want ssh://host/path", repo)
+	}
+	dest = "ssh://" + u.Host
+	if u.User != nil {
+		dest = "ssh://" + u.User.String() + "@" + u.Host
+	}
+	dir = u.Path
+	if rest, ok := strings.CutPrefix(dir, "/~/"); ok {
+		dir = rest
+	}
+	return dest, dir, nil
+}
+
+// sshCommand returns a command running git with args in the repository at
+// repo on its host. Nothing may prompt, as there is no terminal to answer.
+func sshCommand(ctx context.Context, repo string, args ...string) (*exec.Cmd, error) {
+	dest, dir, err := splitSSH(repo)
+	if err != nil {
+		return nil, err
+	}
+	// The remote shell joins and re-splits the command, so quote every word
+	remote := []string{"git", "-C", shellQuote(dir)}
+	for _, arg := range args {
+		remote = append(remote, shellQuote(arg))
+	}
+	return exec.CommandContext(ctx, "ssh",
+		"-o", "BatchMode=yes",
+		"-o", "ConnectTimeout="+sshTimeout,
+		dest, "--", strings.Join(remote, " "),
+	), nil
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// branchHeader matches the first line of "git status --porcelain --branch",
+// e.g. "## main...origin/main [ahead 1, behind 2]".
+var branchHeader = regexp.MustCompile(`^## (?:No commits yet on )?(.+?)(?:\.\.\.(\S+))?(?: \[(.*)\])?$`)
+
+// checkSSH is Check for a repository on another host. Every round trip
+// costs a connection, so the branch and upstream are read from the header
+// of a single "git status" rather than separate commands.
+func checkSSH(ctx context.Context, repo string) Status {
+	status := Status{Path: repo, Files: []File{}}
+	output, err := RunContext(ctx, repo, append([]string{"--no-optional-locks", "status", "--porcelain", "--branch"}, pathspec(repo)...)...)
+	if err != nil {
+		status.HasError = true
+		status.Error = ErrorSummary(err)
+		status.ErrorKind = ClassifyError(err)
+		errors.As(err, &status.ErrorDetail)
+		return status
+	}
+	status.IsRepo = true
+
+	lines := strings.Split(strings.TrimRight(string(output), "\n"), "\n")
+	header, lines := lines[0], lines[1:]
+	for _, line := range lines {
+		if len(line) < 3 {
+			continue
+		}
+		path := strings.TrimSpace(line[2:])
+		if strings.HasPrefix(path, "\"") && strings.HasSuffix(path, "\"") {
+			path = path[1 : len(path)-1]
+		}
+		status.Files = append(status.Files, File{Path: path, Status: strings.TrimSpace(line[:2])})
+	}
+
+	m := branchHeader.FindStringSubmatch(header)
+	if m == nil || strings.HasPrefix(m[1], "HEAD ") {
+		// Detached HEAD; the upstream can't be known without another command
+		status.HasRemote = true
+		status.RemoteStatus = "No current branch"
+		return status
+	}
+	status.Branch = m[1]
+	if m[2] == "" {
+		// Whether the repository has remotes at all would take another
+		// round trip; a branch without an upstream can't be behind anyway
+		status.RemoteStatus = "No upstream branch"
+		return status
+	}
+
+	status.HasRemote = true
+	status.RemoteStatus = "Up to date"
+	for _, part := range strings.Split(m[3], ", ") {
+		fmt.Sscanf(part, "ahead %d", &status.Ahead)
+		fmt.Sscanf(part, "behind %d", &status.Behind)
+		if part == "gone" {
+			status.RemoteStatus = "Upstream branch is gone"
+		}
+	}
+	if status.Behind > 0 {
+		status.NeedsPull = true
+		if status.Behind == 1 {
+			status.RemoteStatus = "1 commit behind"
+		} else {
+			status.RemoteStatus = fmt.Sprintf("%d commits behind", status.Behind)
+		}
+	}
+	return status
+}
+
+// sshFileDiff is FileDiff for a repository on another host. Untracked files
+// are diffed against /dev/null, as their content can't be read directly.
+func sshFileDiff(ctx context.Context, repo, file string) (string, error) {
+	output, err := RunContext(ctx, repo, "diff", "HEAD", "--", file)
+	if err != nil || len(output) == 0 {
+		output, err = RunContext(ctx, repo, "diff", "--cached", "--", file)
+	}
+	if err == nil && len(output) == 0 {
+		// --no-index exits 1 when the files differ, which they always do
+		output, _ = RunContext(ctx, repo, "diff", "--no-index", "--", "/dev/null", file)
+	}
+	if err != nil {
+		return "", err
+	}
+	if isBinary(output) {
+		return fmt.Sprintf("Binary file: %s", file), nil
+	}
+	return string(output), nil
+}
@@ -0,0 +1,49 @@
+package gitstatus
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StashEntry is a single entry in a repository's stash.
+type StashEntry struct {
+	Ref     string // e.g. "stash@{0}"
+	Hash    string
+	Date    time.Time
+	Subject string // e.g. "WIP on main: 1a2b3c4 Fix typo"
+}
+
+// Stashes returns the repo's stash entries, newest first.
+func Stashes(repoPath string) ([]StashEntry, error) {
+	output, err := Run(repoPath, "stash", "list", "--format=%gd%x1f%H%x1f%ct%x1f%gs")
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []StashEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+		unix, _ := strconv.ParseInt(fields[2], 10, 64)
+		entries = append(entries, StashEntry{
+			Ref:     fields[0],
+			Hash:    fields[1],
+			Date:    time.Unix(unix, 0),
+			Subject: fields[3],
+		})
+	}
+	return entries, nil
+}
+
+// StashDiff returns the stat and patch of a stash entry, including any
+// untracked files it saved.
+func StashDiff(repoPath, ref string) (string, error) {
+	output, err := Run(repoPath, "stash", "show", "--stat", "--patch", "--include-untracked", ref)
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
@@ -0,0 +1,350 @@
+package gitstatus
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Event reports a change to a repository's status in a Store.
+type Event struct {
+	Repo    string
+	Status  Status
+	Removed bool // the repository is no longer tracked by the store
+}
+
+// Store is the central record of repository statuses. Background workers
+// update it through Refresh, Fetch, and Run, and every change is delivered
+// to subscribers as an Event, so frontends only need to redraw what
+// changed. A Store is safe for concurrent use.
+type Store struct {
+	mu        sync.RWMutex
+	statuses  map[string]Status
+	subs      map[*Subscription]struct{}
+	ledger    *FetchLedger
+	limiter   *FetchLimiter
+	trace     *Trace
+	dirty     *DirtyTracker
+	history   *History
+	ignore    func(repo string) []string
+	untracked func(repo string) bool
+	runner    Runner
+}
+
+// NewStore returns an empty store.
+func NewStore() *Store {
+	return &Store{
+		statuses: make(map[string]Status),
+		subs:     make(map[*Subscription]struct{}),
+	}
+}
+
+// ShareFetches makes Fetch coordinate through ledger, so a repository that
+// another process has just fetched is only re-checked. It must be called
+// before the store is used.
+func (s *Store) ShareFetches(ledger *FetchLedger) {
+	s.ledger = ledger
+}
+
+// LimitFetches makes Fetch wait for limiter before fetching, so fetches
+// from the same host are spread out. It must be called before the store is
+// used.
+func (s *Store) LimitFetches(limiter *FetchLimiter) {
+	s.limiter = limiter
+}
+
+// SetTrace makes the store record the duration of every check, fetch, and
+// refresh cycle in trace. It must be called before the store is used.
+func (s *Store) SetTrace(trace *Trace) {
+	s.trace = trace
+}
+
+// TrackDirty makes the store date uncommitted changes with tracker, setting
+// DirtySince on every status it records. It must be called before the store
+// is used.
+func (s *Store) TrackDirty(tracker *DirtyTracker) {
+	s.dirty = tracker
+}
+
+// RecordHistory makes the store record the changes between the statuses it
+// records in history. It must be called before the store is used.
+func (s *Store) RecordHistory(history *History) {
+	s.history = history
+}
+
+// IgnoreFiles makes the store leave out the changed files of each
+// repository that match the patterns ignore returns for it, as Status.Ignore
+// does. It must be called before the store is used.
+func (s *Store) IgnoreFiles(ignore func(repo string) []string) {
+	s.ignore = ignore
+}
+
+// IgnoreUntracked makes the store leave out the untracked files of the
+// repositories hide reports, as Status.IgnoreUntracked does. It must be
+// called before the store is used.
+func (s *Store) IgnoreUntracked(hide func(repo string) bool) {
+	s.untracked = hide
+}
+
+// UseRunner makes the store run git through r rather than ExecRunner, for
+// its checks and fetches and the actions given to Run. It must be called
+// before the store is used.
+func (s *Store) UseRunner(r Runner) {
+	s.runner = r
+}
+
+// withRunner returns ctx carrying the store's runner, if it has one.
+func (s *Store) withRunner(ctx context.Context) context.Context {
+	if s.runner == nil {
+		return ctx
+	}
+	return WithRunner(ctx, s.runner)
+}
+
+// FileDiff is FileDiff run through the store's runner.
+func (s *Store) FileDiff(repo, filePath string) (string, error) {
+	return FileDiffContext(s.withRunner(context.Background()), repo, filePath)
+}
+
+// FileChanges is FileChanges run through the store's runner.
+func (s *Store) FileChanges(repo string, files []File) (map[string]FileChange, error) {
+	return FileChangesContext(s.withRunner(context.Background()), repo, files)
+}
+
+// Status returns the last known status of repo and whether it is tracked.
+func (s *Store) Status(repo string) (Status, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	status, ok := s.statuses[repo]
+	return status, ok
+}
+
+// Statuses returns a copy of all tracked statuses keyed by path.
+func (s *Store) Statuses() map[string]Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	statuses := make(map[string]Status, len(s.statuses))
+	for repo, status := range s.statuses {
+		statuses[repo] = status
+	}
+	return statuses
+}
+
+// Set records status for its repository and notifies subscribers.
+func (s *Store) Set(status Status) {
+	if s.ignore != nil {
+		status.Ignore(s.ignore(status.Path))
+	}
+	if s.untracked != nil && s.untracked(status.Path) {
+		status.IgnoreUntracked()
+	}
+	if s.dirty != nil {
+		s.dirty.Observe(&status)
+	}
+	s.mu.Lock()
+	prev, seen := s.statuses[status.Path]
+	s.statuses[status.Path] = status
+	s.mu.Unlock()
+	if s.history != nil {
+		s.history.Observe(prev, seen, status)
+	}
+	s.publish(Event{Repo: status.Path, Status: status})
+}
+
+// Remove stops tracking repo and notifies subscribers.
+func (s *Store) Remove(repo string) {
+	s.mu.Lock()
+	delete(s.statuses, repo)
+	s.mu.Unlock()
+	s.publish(Event{Repo: repo, Removed: true})
+}
+
+// Refresh re-checks repo and records the result.
+func (s *Store) Refresh(repo string) Status {
+	status := s.check(context.Background(), repo)
+	s.Set(status)
+	return status
+}
+
+// RefreshAll re-checks every repository in repos concurrently.
+func (s *Store) RefreshAll(repos []string) {
+	start := time.Now()
+	ctx, span := StartSpan(context.Background(), TraceRefreshCycle, "repos", strconv.Itoa(len(repos)))
+	statuses := checkAll(repos, func(repo string) Status {
+		return s.check(ctx, repo)
+	})
+	span.Finish(nil)
+	s.record(TraceRefreshCycle, "", start)
+	for _, status := range statuses {
+		s.Set(status)
+	}
+}
+
+func (s *Store) check(ctx context.Context, repo string) Status {
+	start := time.Now()
+	ctx, span := StartSpan(s.withRunner(ctx), TraceCheck, "repo", repo)
+	status := CheckContext(ctx, repo)
+	if status.HasError {
+		span.Finish(errors.New(status.Error))
+	} else {
+		span.Finish(nil)
+	}
+	s.record(TraceCheck, repo, start)
+	return status
+}
+
+func (s *Store) record(op, repo string, start time.Time) {
+	if s.trace != nil {
+		s.trace.Record(op, repo, time.Since(start))
+	}
+}
+
+// Fetch fetches repo's remotes and refreshes its status. If the fetch
+// fails, the status's RemoteStatus describes the failure. Nothing is
+// recorded if ctx was cancelled.
+func (s *Store) Fetch(ctx context.Context, repo string) (err error) {
+	start := time.Now()
+	ctx, span := StartSpan(s.withRunner(ctx), TraceFetch, "repo", repo)
+	defer func() { span.Finish(err) }()
+	if s.ledger == nil {
+		err = s.fetch(ctx, span, repo)
+	} else if release, fetch := s.ledger.Begin(ctx, repo); fetch {
+		err = s.fetch(ctx, span, repo)
+		release(err == nil)
+	} else {
+		span.SetAttr("fetch.shared", "true")
+	}
+	s.record(TraceFetch, repo, start)
+	if ctx.Err() != nil {
+		return err
+	}
+	status := s.check(ctx, repo)
+	// A repository locked by another process, or on a host that just
+	// refused another repository's credentials, is left for the next fetch
+	// rather than reported as failing
+	if err != nil && !status.HasError && !IsSkipped(err) {
+		status.RemoteStatus = "Fetch failed: " + ErrorSummary(err)
+		status.ErrorKind = ClassifyError(err)
+	}
+	s.Set(status)
+	return err
+}
+
+// fetch fetches repo once the limiter, if any, lets it, recording how long
+// it waited in span.
+func (s *Store) fetch(ctx context.Context, span *Span, repo string) error {
+	if s.limiter == nil {
+		return Fetch(ctx, repo)
+	}
+	start := time.Now()
+	done, err := s.limiter.Wait(ctx, repo)
+	if waited := time.Since(start); waited >= time.Millisecond {
+		span.SetAttr("fetch.waited", waited.Round(time.Millisecond).String())
+	}
+	if err != nil {
+		return err
+	}
+	err = Fetch(ctx, repo)
+	done(err)
+	return err
+}
+
+// Run runs action against repo and refreshes its status afterwards, whether
+// or not the action succeeded, unless ctx was cancelled.
+func (s *Store) Run(ctx context.Context, repo string, action func(ctx context.Context, repo string) error) error {
+	err := action(s.withRunner(ctx), repo)
+	if ctx.Err() == nil {
+		s.Refresh(repo)
+	}
+	return err
+}
+
+// Subscribe returns a subscription that receives every change made to the
+// store from now on. Close it when it is no longer needed.
+func (s *Store) Subscribe() *Subscription {
+	sub := &Subscription{
+		pending: make(map[string]Event),
+		ready:   make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	s.mu.Lock()
+	s.subs[sub] = struct{}{}
+	s.mu.Unlock()
+	sub.store = s
+	return sub
+}
+
+func (s *Store) publish(e Event) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for sub := range s.subs {
+		sub.push(e)
+	}
+}
+
+// Subscription is a stream of store events. Events for the same repository
+// that arrive before the subscriber catches up are coalesced into the most
+// recent one, so a slow subscriber never blocks the workers updating the
+// store.
+type Subscription struct {
+	store   *Store
+	mu      sync.Mutex
+	pending map[string]Event
+	order   []string
+	ready   chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+func (sub *Subscription) push(e Event) {
+	sub.mu.Lock()
+	if _, queued := sub.pending[e.Repo]; !queued {
+		sub.order = append(sub.order, e.Repo)
+	}
+	sub.pending[e.Repo] = e
+	sub.mu.Unlock()
+
+	select {
+	case sub.ready <- struct{}{}:
+	default:
+	}
+}
+
+// Next blocks until at least one event is available and returns all pending
+// events in the order their repositories first changed. It returns nil once
+// the subscription is closed.
+func (sub *Subscription) Next() []Event {
+	for {
+		select {
+		case <-sub.ready:
+		case <-sub.done:
+			return nil
+		}
+
+		// A wake-up may arrive after its event was already taken by the
+		// previous call, so wait again if nothing is pending.
+		sub.mu.Lock()
+		events := make([]Event, 0, len(sub.order))
+		for _, repo := range sub.order {
+			events = append(events, sub.pending[repo])
+		}
+		clear(sub.pending)
+		sub.order = sub.order[:0]
+		sub.mu.Unlock()
+		if len(events) > 0 {
+			return events
+		}
+	}
+}
+
+// Close unsubscribes from the store and wakes any pending Next call.
+func (sub *Subscription) Close() {
+	sub.once.Do(func() {
+		sub.store.mu.Lock()
+		delete(sub.store.subs, sub)
+		sub.store.mu.Unlock()
+		close(sub.done)
+	})
+}
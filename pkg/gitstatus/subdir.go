@@ -0,0 +1,68 @@
+package gitstatus
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SplitSubdir splits a configured repository such as
+// "/src/monorepo:services/api" into the repository's path and the
+// subdirectory its status is scoped to, relative to the repository's root.
+// Entries without a subdirectory return it empty. The colons of Windows
+// drive letters and of ssh:// ports don't count, and neither do those of a
+// local entry that is an existing directory, such as "/home/me/a:b".
+func SplitSubdir(entry string) (repo, subdir string) {
+	start := 0
+	switch {
+	case IsSSH(entry):
+		// Skip the host, which may have a port
+		rest := strings.TrimPrefix(entry, "ssh://")
+		if i := strings.Index(rest, "/"); i >= 0 {
+			start = len("ssh://") + i
+		}
+	case len(entry) >= 2 && entry[1] == ':':
+		start = 2
+	}
+	i := strings.LastIndex(entry[start:], ":")
+	if i < 0 {
+		return entry, ""
+	}
+	if !IsSSH(entry) {
+		if info, err := os.Stat(entry); err == nil && info.IsDir() {
+			return entry, ""
+		}
+	}
+	i += start
+	subdir = strings.Trim(filepath.ToSlash(entry[i+1:]), "/")
+	if subdir == "" {
+		return entry[:i], ""
+	}
+	return entry[:i], subdir
+}
+
+// RepoDir returns the path of the repository an entry refers to, without
+// any subdirectory. git runs there, so paths in statuses are relative to it.
+func RepoDir(entry string) string {
+	repo, _ := SplitSubdir(entry)
+	return repo
+}
+
+// WorkDir returns the directory tools are started in for an entry: its
+// subdirectory if it is scoped to one, or else the repository.
+func WorkDir(entry string) string {
+	repo, subdir := SplitSubdir(entry)
+	if subdir == "" || IsSSH(repo) {
+		return repo
+	}
+	return filepath.Join(repo, filepath.FromSlash(subdir))
+}
+
+// pathspec returns the arguments limiting a git command to the subdirectory
+// entry is scoped to, or nil if it isn't.
+func pathspec(entry string) []string {
+	if _, subdir := SplitSubdir(entry); subdir != "" {
+		return []string{"--", subdir}
+	}
+	return nil
+}
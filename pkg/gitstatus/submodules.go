@@ -0,0 +1,68 @@
+package gitstatus
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Submodule is a submodule of a repository and the state of its checkout.
+type Submodule struct {
+	Path        string
+	Pinned      string // commit recorded in the superproject's index
+	Checkout    string // commit checked out in the submodule, if initialized
+	Initialized bool
+	Conflict    bool
+	Changes     int // number of changed files in the submodule, or -1 if unknown
+}
+
+// OutOfDate reports whether the submodule's checkout differs from the
+// commit the superproject pins.
+func (s Submodule) OutOfDate() bool {
+	return s.Initialized && s.Checkout != s.Pinned
+}
+
+// Submodules returns the repo's submodules with their pinned and
+// checked-out commits and dirty state.
+func Submodules(repoPath string) ([]Submodule, error) {
+	checkedOut, err := Run(repoPath, "submodule", "status")
+	if err != nil {
+		return nil, err
+	}
+	cached, err := Run(repoPath, "submodule", "status", "--cached")
+	if err != nil {
+		return nil, err
+	}
+
+	// Each line is "<state><hash> <path> [(<describe>)]", where state is
+	// ' ' (up to date), '+' (different commit), '-' (not initialized), or
+	// 'U' (merge conflict).
+	pinned := make(map[string]string)
+	for _, line := range strings.Split(string(cached), "\n") {
+		if fields := strings.Fields(line); len(line) > 1 && len(fields) >= 2 {
+			pinned[fields[1]] = strings.TrimLeft(fields[0], " +-U")
+		}
+	}
+
+	var submodules []Submodule
+	for _, line := range strings.Split(string(checkedOut), "\n") {
+		fields := strings.Fields(line)
+		if len(line) < 2 || len(fields) < 2 {
+			continue
+		}
+		s := Submodule{
+			Path:        fields[1],
+			Pinned:      pinned[fields[1]],
+			Initialized: line[0] != '-',
+			Conflict:    line[0] == 'U',
+			Changes:     -1,
+		}
+		if s.Initialized {
+			s.Checkout = strings.TrimLeft(fields[0], " +-U")
+			if status, err := Run(filepath.Join(RepoDir(repoPath), s.Path), "--no-optional-locks", "status", "--porcelain"); err == nil {
+				s.Changes = countLines(string(status))
+			}
+		}
+		submodules = append(submodules, s)
+	}
+	return submodules, nil
+}
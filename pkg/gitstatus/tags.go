@@ -0,0 +1,43 @@
+package gitstatus
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tag is a tag in a repository.
+type Tag struct {
+	Name      string
+	Short     string // abbreviated hash of the tag object
+	Date      time.Time
+	Annotated bool
+	Subject   string // first line of the tag message, or of the commit for lightweight tags
+}
+
+// Tags returns the repo's tags, newest first.
+func Tags(repoPath string) ([]Tag, error) {
+	output, err := Run(repoPath, "for-each-ref", "--sort=-creatordate",
+		"--format=%(refname:short)%1f%(objectname:short)%1f%(creatordate:unix)%1f%(objecttype)%1f%(contents:subject)",
+		"refs/tags")
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []Tag
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 5 {
+			continue
+		}
+		unix, _ := strconv.ParseInt(fields[2], 10, 64)
+		tags = append(tags, Tag{
+			Name:      fields[0],
+			Short:     fields[1],
+			Date:      time.Unix(unix, 0),
+			Annotated: fields[3] == "tag",
+			Subject:   fields[4],
+		})
+	}
+	return tags, nil
+}
@@ -0,0 +1,102 @@
+package gitstatus
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Trace operation names.
+const (
+	TraceCheck        = "check"         // status check of one repository
+	TraceFetch        = "fetch"         // fetch of one repository
+	TraceRefreshCycle = "refresh cycle" // status check of every repository
+)
+
+// Trace collects timings of a Store's status checks, fetches, and refresh
+// cycles, to find out where time goes with many repositories. A Trace is
+// safe for concurrent use.
+type Trace struct {
+	mu    sync.Mutex
+	ops   map[string]*traceStats
+	repos map[string]time.Duration
+}
+
+type traceStats struct {
+	count int
+	total time.Duration
+	max   time.Duration
+}
+
+// NewTrace returns an empty trace.
+func NewTrace() *Trace {
+	return &Trace{
+		ops:   make(map[string]*traceStats),
+		repos: make(map[string]time.Duration),
+	}
+}
+
+// Record adds one timing for op. Timings with a repository also count
+// towards that repository's total.
+func (t *Trace) Record(op, repo string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	stats := t.ops[op]
+	if stats == nil {
+		stats = &traceStats{}
+		t.ops[op] = stats
+	}
+	stats.count++
+	stats.total += d
+	stats.max = max(stats.max, d)
+	if repo != "" {
+		t.repos[repo] += d
+	}
+}
+
+// WriteSummary writes per-operation counts, average and maximum durations,
+// and the repositories that took the longest in total.
+func (t *Trace) WriteSummary(w io.Writer) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "operation\tcount\ttotal\taverage\tmax\t")
+	for _, op := range []string{TraceRefreshCycle, TraceCheck, TraceFetch} {
+		stats := t.ops[op]
+		if stats == nil {
+			continue
+		}
+		avg := stats.total / time.Duration(stats.count)
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t\n", op, stats.count, round(stats.total), round(avg), round(stats.max))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	repos := make([]string, 0, len(t.repos))
+	for repo := range t.repos {
+		repos = append(repos, repo)
+	}
+	slices.SortFunc(repos, func(a, b string) int {
+		return cmp.Compare(t.repos[b], t.repos[a])
+	})
+	if len(repos) > 10 {
+		repos = repos[:10]
+	}
+	if len(repos) > 0 {
+		fmt.Fprintln(w, "\nslowest repositories (check + fetch time):")
+	}
+	for _, repo := range repos {
+		fmt.Fprintf(w, "%10s  %s\n", round(t.repos[repo]), repo)
+	}
+	return nil
+}
+
+func round(d time.Duration) time.Duration {
+	return d.Round(time.Millisecond)
+}
@@ -0,0 +1,71 @@
+package gitstatus
+
+import (
+	"strings"
+)
+
+// Worktree is a working tree attached to a repository, as reported by
+// git worktree list.
+type Worktree struct {
+	Path     string
+	Head     string
+	Branch   string // empty when detached
+	Main     bool   // the repository's main working tree
+	Locked   bool
+	Prunable bool // the directory no longer exists
+	Changes  int  // number of changed files, or -1 if unknown
+}
+
+// Worktrees returns the repo's worktrees, main worktree first, with the
+// number of changed files in each.
+func Worktrees(repoPath string) ([]Worktree, error) {
+	output, err := Run(repoPath, "worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+
+	// Porcelain output is one block of "key value" lines per worktree,
+	// separated by blank lines.
+	var worktrees []Worktree
+	for _, block := range strings.Split(strings.TrimSpace(string(output)), "\n\n") {
+		var wt Worktree
+		for _, line := range strings.Split(block, "\n") {
+			key, value, _ := strings.Cut(line, " ")
+			switch key {
+			case "worktree":
+				wt.Path = value
+			case "HEAD":
+				wt.Head = value
+			case "branch":
+				wt.Branch = strings.TrimPrefix(value, "refs/heads/")
+			case "locked":
+				wt.Locked = true
+			case "prunable":
+				wt.Prunable = true
+			}
+		}
+		if wt.Path == "" {
+			continue
+		}
+		wt.Main = len(worktrees) == 0
+		wt.Changes = -1
+		if !wt.Prunable {
+			if status, err := Run(wt.Path, "--no-optional-locks", "status", "--porcelain"); err == nil {
+				wt.Changes = countLines(string(status))
+			}
+		}
+		worktrees = append(worktrees, wt)
+	}
+	return worktrees, nil
+}
+
+// countLines counts the non-empty lines in s.
+func countLines(s string) int {
+	n := 0
+	for _, line := range strings.Split(s, "\n") {
+		if strings.TrimSpace(line) != "" {
+			n++
+		}
+	}
+	return n
+}
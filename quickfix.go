@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/cwsaylor/gitmoni/internal/server"
+	"github.com/cwsaylor/gitmoni/pkg/config"
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// runQuickfix implements "gitmoni quickfix": it prints the changed files of
+// every configured repository, or of one, as "file:line:col: message" lines
+// that vim's quickfix list and most editors can load. Deleted files are
+// left out, as there is nothing to open.
+func runQuickfix(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("quickfix", flag.ExitOnError)
+	lines := fs.Bool("lines", false, "Point at each file's first changed line instead of its first line")
+	fs.Parse(args)
+
+	repos := cfg.Repositories
+	if fs.NArg() > 0 {
+		repo, err := quickfixRepo(cfg.Repositories, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		repos = []string{repo}
+	}
+
+	// The editor can't open files on other machines
+	repos = slices.DeleteFunc(slices.Clone(repos), gitstatus.IsSSH)
+	statuses := gitstatus.CheckAll(repos)
+	for _, repo := range repos {
+		status := statuses[repo]
+		status.Ignore(cfg.Settings(repo).Ignore)
+		if cfg.HidesUntracked(repo) {
+			status.IgnoreUntracked()
+		}
+		if status.HasError {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", repo, status.Error)
+			continue
+		}
+		for _, f := range status.Files {
+			path := f.Path
+			if _, to, ok := strings.Cut(path, " -> "); ok {
+				path = to
+			}
+			if strings.Contains(f.Status, "D") && !strings.Contains(f.Status, "U") {
+				continue
+			}
+			line := 1
+			if *lines && f.Status != "??" {
+				line = gitstatus.FirstChangedLine(repo, path)
+			}
+			fmt.Printf("%s:%d:1: %s: %s (%s)\n", filepath.Join(gitstatus.RepoDir(repo), path), line, filepath.Base(repo), describeChange(f.Status), f.Status)
+		}
+	}
+	return nil
+}
+
+// quickfixRepo resolves the repository argument: a configured repository's
+// path or name, or the path of any repository, such as ".".
+func quickfixRepo(repos []string, arg string) (string, error) {
+	if repo, err := server.Lookup(repos, arg); err == nil {
+		return repo, nil
+	}
+	if abs, err := gitstatus.ValidateRepository(arg); err == nil {
+		return abs, nil
+	}
+	return "", errors.New("unknown repository: " + arg)
+}
+
+// describeChange names a porcelain status code.
+func describeChange(code string) string {
+	switch {
+	case code == "??":
+		return "untracked"
+	case strings.Contains(code, "U") || code == "AA" || code == "DD":
+		return "conflict"
+	}
+	switch code[0] {
+	case 'A':
+		return "added"
+	case 'R':
+		return "renamed"
+	case 'C':
+		return "copied"
+	case 'T':
+		return "type changed"
+	}
+	return "modified"
+}
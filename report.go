@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cwsaylor/gitmoni/internal/report"
+	"github.com/cwsaylor/gitmoni/internal/repostore"
+	"github.com/cwsaylor/gitmoni/internal/timefmt"
+	"github.com/cwsaylor/gitmoni/pkg/config"
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// runReport implements "gitmoni report": it looks back over a period and
+// prints what changed in each repository, comparing the current state with
+// the recorded history.
+func runReport(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	since := fs.String("since", "7d", "Start of the period: a duration before now (e.g. 7d, 12h) or a date (2006-01-02)")
+	all := fs.Bool("all-authors", false, "Count everyone's commits, not just those by git's user.email")
+	asJSON := fs.Bool("json", false, "Print the report as JSON")
+	fs.Parse(args)
+
+	from, err := parseSince(*since)
+	if err != nil {
+		return err
+	}
+
+	// Check through a store so the statuses are dated and any changes
+	// since the last run are recorded before they are compared
+	store := repostore.New(cfg)
+	historyPath, err := gitstatus.DefaultHistoryPath()
+	if err != nil {
+		return err
+	}
+	store.RefreshAll(cfg.Repositories)
+	events, err := gitstatus.ReadHistory(historyPath)
+	if err != nil {
+		return err
+	}
+
+	opts := report.Options{Since: from, StaleAfter: time.Duration(cfg.StaleRepoDays) * 24 * time.Hour, OwnCommits: !*all}
+	repos := report.Collect(store.Statuses(), cfg.Repositories, events, opts)
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(repos)
+	}
+	if len(events) == 0 {
+		fmt.Fprintln(os.Stderr, "No history recorded yet; problems are compared from the next run of gitmoni on.")
+	}
+	return report.WriteText(os.Stdout, repos, from, timefmt.New(cfg.TimeFormat, cfg.Clock))
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cwsaylor/gitmoni/pkg/config"
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// runScan implements "gitmoni scan": it looks for git repositories in the
+// given directories and adds them to the configuration. Repositories nested
+// inside another one, such as vendored checkouts and submodule working
+// copies, are asked about instead of added along with their parent, unless
+// --nested says what to do with them.
+func runScan(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("scan", flag.ExitOnError)
+	depth := fs.Int("depth", gitstatus.ScanDepth, "How many directories deep to look for repositories")
+	nested := fs.String("nested", "ask", "Repositories nested inside another: ask, add (both), skip (both), or parent (add only the parent)")
+	dryRun := fs.Bool("n", false, "List the repositories found without adding them")
+	fs.Parse(args)
+	switch *nested {
+	case "ask", "add", "skip", "parent":
+	default:
+		return fmt.Errorf("--nested must be ask, add, skip, or parent, not %q", *nested)
+	}
+	if *depth < 0 {
+		return fmt.Errorf("--depth must not be negative")
+	}
+	dirs := fs.Args()
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	var add []string
+	for _, dir := range dirs {
+		found, err := gitstatus.Scan(ctx, dir, *depth)
+		if err != nil {
+			return err
+		}
+
+		// Decide for each outermost repository and everything nested in it
+		// at once
+		for i := 0; i < len(found); {
+			end := i + 1
+			for end < len(found) && found[end].Parent != "" {
+				end++
+			}
+			group := found[i:end]
+			i = end
+
+			choice := "add"
+			if len(group) > 1 {
+				choice = *nested
+				if choice == "ask" {
+					if choice, err = askNested(in, group); err != nil {
+						return err
+					}
+				}
+			}
+			switch choice {
+			case "add":
+				for _, r := range group {
+					add = append(add, r.Path)
+				}
+			case "parent":
+				add = append(add, group[0].Path)
+			}
+		}
+	}
+
+	added := 0
+	for _, path := range add {
+		if *dryRun {
+			fmt.Println(path)
+		} else if cfg.AddRepository(path) {
+			fmt.Printf("Added repository: %s\n", path)
+			added++
+		}
+	}
+	if *dryRun {
+		return nil
+	}
+	if added == 0 {
+		fmt.Println("No new repositories found")
+		return nil
+	}
+	if err := cfg.Save(); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	return nil
+}
+
+// askNested asks what to do with a repository and the repositories nested
+// inside it, and returns "add", "skip", or "parent". Only the parent is
+// added when there is no answer.
+func askNested(in *bufio.Reader, group []gitstatus.FoundRepo) (string, error) {
+	fmt.Printf("%s contains %d nested repositories:\n", group[0].Path, len(group)-1)
+	for _, r := range group[1:] {
+		rel, _ := filepath.Rel(group[0].Path, r.Path)
+		if r.Submodule {
+			rel += " (submodule)"
+		}
+		fmt.Printf("  %s\n", rel)
+	}
+	for {
+		fmt.Print("Add them [a], skip them all [s], or add only the parent [P]? ")
+		line, err := in.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "a", "add":
+			return "add", nil
+		case "s", "skip":
+			return "skip", nil
+		case "", "p", "parent":
+			if err == io.EOF {
+				fmt.Println()
+			}
+			return "parent", nil
+		}
+		if err == io.EOF {
+			return "parent", nil
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/cwsaylor/gitmoni/internal/control"
+	"github.com/cwsaylor/gitmoni/internal/mqtt"
+	"github.com/cwsaylor/gitmoni/internal/repostore"
+	"github.com/cwsaylor/gitmoni/internal/server"
+	"github.com/cwsaylor/gitmoni/pkg/config"
+)
+
+// runServe implements "gitmoni serve": it serves the configured
+// repositories' status over HTTP until ctx is cancelled, re-checking them
+// periodically.
+func runServe(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	listen := fs.String("listen", "localhost:8090", "Address to listen on, e.g. :8090 for every interface")
+	token := fs.String("token", "", "Require this bearer token (overrides serve_token and $GITMONI_TOKEN)")
+	refresh := fs.Duration("refresh", time.Minute, "How often to re-check every repository; 0 disables")
+	readOnly := fs.Bool("read-only", cfg.ServeReadOnly, "Refuse requests that fetch, from the API, the dashboard, or webhooks")
+	tlsCert := fs.String("tls-cert", cfg.ServeTLSCert, "Serve HTTPS with this certificate file (PEM)")
+	tlsKey := fs.String("tls-key", cfg.ServeTLSKey, "Private key file (PEM) of --tls-cert")
+	fs.Parse(args)
+
+	var tlsConfig *tls.Config
+	if *tlsCert != "" || *tlsKey != "" {
+		if *tlsCert == "" || *tlsKey == "" {
+			return errors.New("--tls-cert and --tls-key must be given together")
+		}
+		cert, err := tls.LoadX509KeyPair(*tlsCert, *tlsKey)
+		if err != nil {
+			return fmt.Errorf("loading TLS certificate: %w", err)
+		}
+		tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	}
+
+	if *token == "" {
+		*token = cfg.ServeToken
+	}
+	if *token == "" {
+		*token = os.Getenv("GITMONI_TOKEN")
+	}
+
+	store := repostore.New(cfg)
+	store.RefreshAll(cfg.Repositories)
+
+	mqtt.Start(ctx, cfg, store)
+	srv := server.New(ctx, store, cfg.Repositories, *token)
+	srv.AutoPull = cfg.AutoPulls
+	srv.HookSecret = hookSecret(cfg)
+	srv.ReadOnly = *readOnly
+	if path := control.SocketPath(cfg.ControlSocket); path != "" {
+		ctl, err := control.Listen(path)
+		if err != nil {
+			slog.Warn("control socket unavailable", "path", path, "err", err)
+		} else {
+			defer ctl.Close()
+			go control.Serve(ctx, ctl, serveControl(store, srv))
+		}
+	}
+	ln, err := net.Listen("tcp", *listen)
+	if err != nil {
+		return err
+	}
+	httpServer := &http.Server{Handler: srv.Handler(), TLSConfig: tlsConfig, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdown)
+	}()
+	if *refresh > 0 {
+		go func() {
+			ticker := time.NewTicker(*refresh)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					store.RefreshAll(cfg.Repositories)
+				}
+			}
+		}()
+	}
+
+	scheme := "http"
+	if tlsConfig != nil {
+		scheme = "https"
+	}
+	fmt.Printf("Serving %d repositories on %s://%s\n", len(cfg.Repositories), scheme, ln.Addr())
+	if *token == "" {
+		fmt.Println("Warning: no token set, the API is open to anyone who can reach it")
+	} else if scheme == "http" && !isLoopback(ln.Addr()) {
+		fmt.Println("Warning: the token is sent unencrypted; use --tls-cert and --tls-key on shared networks")
+	}
+	if *readOnly {
+		fmt.Println("Read-only: fetches are refused")
+	}
+	slog.Info("serving", "addr", ln.Addr().String(), "repos", len(cfg.Repositories), "auth", *token != "", "tls", tlsConfig != nil, "read_only", *readOnly)
+	if tlsConfig != nil {
+		err = httpServer.ServeTLS(ln, "", "")
+	} else {
+		err = httpServer.Serve(ln)
+	}
+	srv.Wait()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// isLoopback reports whether addr only accepts connections from this
+// machine.
+func isLoopback(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	return ok && tcp.IP.IsLoopback()
+}
+
+// hookSecret returns the secret push webhooks are validated with.
+func hookSecret(cfg *config.Config) string {
+	if cfg.HookSecret != "" {
+		return cfg.HookSecret
+	}
+	return os.Getenv("GITMONI_HOOK_SECRET")
+}
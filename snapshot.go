@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/cwsaylor/gitmoni/internal/repostore"
+	"github.com/cwsaylor/gitmoni/internal/timefmt"
+	"github.com/cwsaylor/gitmoni/pkg/config"
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// runSnapshot implements "gitmoni snapshot": it saves the status of every
+// repository under a name, and later shows what changed since, such as
+// before and after a holiday.
+func runSnapshot(ctx context.Context, cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("snapshot", flag.ExitOnError)
+	fetch := fs.Bool("fetch", false, "Fetch every repository first, to see upstream changes")
+	asJSON := fs.Bool("json", false, "Print the changes as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gitmoni snapshot save|diff [--fetch] [--json] <name> | list | delete <name>")
+		fs.PrintDefaults()
+	}
+	if len(args) == 0 {
+		fs.Usage()
+		return errors.New("missing command")
+	}
+	command := args[0]
+	fs.Parse(args[1:])
+
+	if command == "list" {
+		return listSnapshots(timefmt.New(cfg.TimeFormat, cfg.Clock))
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("missing snapshot name")
+	}
+	name := fs.Arg(0)
+	path, err := gitstatus.NamedSnapshotPath(name)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "save":
+		if err := gitstatus.WriteSnapshot(path, currentStatuses(ctx, cfg, *fetch)); err != nil {
+			return err
+		}
+		fmt.Printf("Saved snapshot %q of %s.\n", name, repositories(len(cfg.Repositories)))
+		return nil
+	case "diff":
+		before, err := gitstatus.ReadSnapshot(path)
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no snapshot named %q", name)
+		} else if err != nil {
+			return err
+		}
+		after := gitstatus.NewSnapshot(currentStatuses(ctx, cfg, *fetch))
+		diffs := gitstatus.CompareSnapshots(before, after)
+		if *asJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(diffs)
+		}
+		writeSnapshotDiff(name, before, after, diffs, timefmt.New(cfg.TimeFormat, cfg.Clock))
+		return nil
+	case "delete":
+		if err := os.Remove(path); os.IsNotExist(err) {
+			return fmt.Errorf("no snapshot named %q", name)
+		} else if err != nil {
+			return err
+		}
+		fmt.Printf("Deleted snapshot %q.\n", name)
+		return nil
+	}
+	fs.Usage()
+	return fmt.Errorf("unknown command %q", command)
+}
+
+// currentStatuses checks every repository, optionally fetching first,
+// through a store so the statuses are filtered and dated as in the TUI.
+func currentStatuses(ctx context.Context, cfg *config.Config, fetch bool) map[string]gitstatus.Status {
+	if fetch {
+		fetchAll(ctx, cfg)
+	}
+	store := repostore.New(cfg)
+	store.RefreshAll(cfg.Repositories)
+	return store.Statuses()
+}
+
+// listSnapshots prints the saved snapshots, oldest first.
+func listSnapshots(tf timefmt.Format) error {
+	dir, err := gitstatus.SnapshotDir()
+	if err != nil {
+		return err
+	}
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	type saved struct {
+		name string
+		snap gitstatus.Snapshot
+	}
+	var snaps []saved
+	for _, path := range paths {
+		snap, err := gitstatus.ReadSnapshot(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping %s: %v\n", path, err)
+			continue
+		}
+		snaps = append(snaps, saved{strings.TrimSuffix(filepath.Base(path), ".json"), snap})
+	}
+	if len(snaps) == 0 {
+		fmt.Println("No snapshots saved; create one with gitmoni snapshot save <name>.")
+		return nil
+	}
+	slices.SortFunc(snaps, func(a, b saved) int { return a.snap.Time.Compare(b.snap.Time) })
+	for _, s := range snaps {
+		fmt.Printf("%-20s  %s  %s\n", s.name, tf.DateTime(s.snap.Time), repositories(len(s.snap.Statuses)))
+	}
+	return nil
+}
+
+// writeSnapshotDiff prints what changed in each repository since the
+// snapshot called name.
+func writeSnapshotDiff(name string, before, after gitstatus.Snapshot, diffs []gitstatus.RepoDiff, tf timefmt.Format) {
+	ago := fmt.Sprintf("%d hours ago", int(after.Time.Sub(before.Time).Hours()))
+	if days := int(after.Time.Sub(before.Time).Hours() / 24); days > 0 {
+		ago = fmt.Sprintf("%d days ago", days)
+	}
+	fmt.Printf("Changes since snapshot %q of %s (%s):\n", name, before.Time.Local().Format("Mon")+" "+tf.DateTime(before.Time), ago)
+	if len(diffs) == 0 {
+		fmt.Println("\nNothing changed.")
+		return
+	}
+	for _, d := range diffs {
+		fmt.Printf("\n%s (%s)\n", filepath.Base(d.Path), d.Path)
+		switch {
+		case d.Added:
+			fmt.Println("  now monitored")
+		case d.Removed:
+			fmt.Println("  no longer monitored")
+			continue
+		}
+		if d.OldBranch != d.Branch && !d.Added {
+			fmt.Printf("  branch: %s → %s\n", d.OldBranch, d.Branch)
+		}
+		if d.OldError != d.Error {
+			if d.Error == "" {
+				fmt.Printf("  error resolved: %s\n", d.OldError)
+			} else {
+				fmt.Printf("  error: %s\n", d.Error)
+			}
+		}
+		for _, f := range d.NewFiles {
+			fmt.Printf("  + %-2s %s\n", f.Status, f.Path)
+		}
+		for _, f := range d.GoneFiles {
+			fmt.Printf("  - %-2s %s\n", f.Status, f.Path)
+		}
+		switch {
+		case d.Behind > d.OldBehind && d.OldBehind == 0:
+			fmt.Printf("  fell behind: %d commits to pull\n", d.Behind)
+		case d.Behind != d.OldBehind:
+			fmt.Printf("  behind: %d → %d\n", d.OldBehind, d.Behind)
+		}
+		if d.Ahead != d.OldAhead {
+			fmt.Printf("  ahead: %d → %d\n", d.OldAhead, d.Ahead)
+		}
+		// Fetch failures don't show in the counts
+		if d.OldRemote != d.Remote && (strings.HasPrefix(d.OldRemote, "Fetch failed") || strings.HasPrefix(d.Remote, "Fetch failed")) {
+			fmt.Printf("  remote: %s → %s\n", d.OldRemote, d.Remote)
+		}
+	}
+	fmt.Printf("\n%d of %s changed.\n", len(diffs), repositories(max(len(before.Statuses), len(after.Statuses))))
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/cwsaylor/gitmoni/internal/timefmt"
+	"github.com/cwsaylor/gitmoni/pkg/config"
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// staleRepo is a repository without activity for a while.
+type staleRepo struct {
+	Path       string    `json:"path"`
+	LastActive time.Time `json:"last_active"`
+	Days       int       `json:"days"` // days since LastActive
+}
+
+// runStale implements "gitmoni stale": it lists the repositories without
+// activity for stale_repo_days, longest idle first, so they can be archived,
+// and with --remove stops monitoring them.
+func runStale(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("stale", flag.ExitOnError)
+	days := fs.Int("days", cfg.StaleRepoDays, "Days without new commits, local or fetched, after which a repository is stale")
+	remove := fs.Bool("remove", false, "Remove the stale repositories from the configuration")
+	asJSON := fs.Bool("json", false, "Print the repositories as JSON")
+	fs.Parse(args)
+	if *days <= 0 {
+		return fmt.Errorf("--days must be positive")
+	}
+
+	var stale []staleRepo
+	for _, repo := range cfg.Repositories {
+		last, err := gitstatus.LastActivity(repo)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping %s: %s\n", repo, gitstatus.ErrorSummary(err))
+			continue
+		}
+		idle := int(time.Since(last).Hours() / 24)
+		// Repositories without commits yet are new rather than stale
+		if !last.IsZero() && idle >= *days {
+			stale = append(stale, staleRepo{Path: repo, LastActive: last, Days: idle})
+		}
+	}
+	slices.SortStableFunc(stale, func(a, b staleRepo) int { return a.LastActive.Compare(b.LastActive) })
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(stale); err != nil {
+			return err
+		}
+	} else if len(stale) == 0 {
+		fmt.Printf("No repositories without activity in %d days.\n", *days)
+	} else {
+		for _, r := range stale {
+			fmt.Printf("%5d days  %-20s  %s (last activity %s)\n", r.Days, filepath.Base(r.Path), r.Path, timefmt.New(cfg.TimeFormat, cfg.Clock).Date(r.LastActive))
+		}
+	}
+
+	if len(stale) == 0 {
+		return nil
+	}
+	if !*remove {
+		if !*asJSON {
+			fmt.Println("\nRun gitmoni stale --remove to stop monitoring them.")
+		}
+		return nil
+	}
+	for _, r := range stale {
+		cfg.RemoveRepository(r.Path)
+	}
+	if err := cfg.Save(); err != nil {
+		return err
+	}
+	if len(stale) == 1 {
+		fmt.Fprintln(os.Stderr, "Removed 1 stale repository from monitoring.")
+	} else {
+		fmt.Fprintf(os.Stderr, "Removed %d stale repositories from monitoring.\n", len(stale))
+	}
+	return nil
+}
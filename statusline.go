@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cwsaylor/gitmoni/internal/timefmt"
+	"github.com/cwsaylor/gitmoni/pkg/config"
+	"github.com/cwsaylor/gitmoni/pkg/gitstatus"
+)
+
+// readSnapshot loads the status snapshot saved by "gitmoni daemon" and
+// reports whether it is older than maxAge, i.e. the daemon isn't running.
+func readSnapshot(maxAge time.Duration) (snap gitstatus.Snapshot, stale bool, err error) {
+	path, err := gitstatus.DefaultSnapshotPath()
+	if err != nil {
+		return snap, false, err
+	}
+	snap, err = gitstatus.ReadSnapshot(path)
+	if err != nil {
+		return snap, false, err
+	}
+	return snap, time.Since(snap.Time) > maxAge, nil
+}
+
+// shortSummary returns a summary such as "●3 ↓2 ✗1", or "" if every
+// repository is clean.
+func shortSummary(sum gitstatus.Summary) string {
+	var parts []string
+	if sum.Dirty > 0 {
+		parts = append(parts, fmt.Sprintf("●%d", sum.Dirty))
+	}
+	if sum.Behind > 0 {
+		parts = append(parts, fmt.Sprintf("↓%d", sum.Behind))
+	}
+	if sum.Errors > 0 {
+		parts = append(parts, fmt.Sprintf("✗%d", sum.Errors))
+	}
+	return strings.Join(parts, " ")
+}
+
+// runTmuxStatus implements "gitmoni tmux-status": it prints a summary such
+// as "●3 ↓2 ✗1" in tmux's style syntax, for status-right. It only reads
+// the daemon's snapshot, so it returns immediately.
+func runTmuxStatus(args []string) error {
+	fs := flag.NewFlagSet("tmux-status", flag.ExitOnError)
+	noColor := fs.Bool("no-color", false, "Print without tmux style attributes")
+	maxAge := fs.Duration("max-age", 15*time.Minute, "Mark the summary as out of date when the snapshot is older than this")
+	fs.Parse(args)
+
+	style := func(color, text string) string {
+		if *noColor {
+			return text
+		}
+		return fmt.Sprintf("#[fg=%s]%s#[default]", color, text)
+	}
+
+	snap, stale, err := readSnapshot(*maxAge)
+	if err != nil {
+		// Keep the status line tidy; "gitmoni daemon" isn't running
+		fmt.Println(style("#737994", "gitmoni ?")) // Overlay0
+		return nil
+	}
+
+	sum := snap.Summary()
+	var parts []string
+	if sum.Dirty > 0 {
+		parts = append(parts, style("#e5c890", fmt.Sprintf("●%d", sum.Dirty))) // Yellow
+	}
+	if sum.Behind > 0 {
+		parts = append(parts, style("#8caaee", fmt.Sprintf("↓%d", sum.Behind))) // Blue
+	}
+	if sum.Errors > 0 {
+		parts = append(parts, style("#e78284", fmt.Sprintf("✗%d", sum.Errors))) // Red
+	}
+	if len(parts) == 0 {
+		parts = append(parts, style("#a6d189", "✓")) // Green
+	}
+	if stale {
+		parts = append(parts, style("#737994", "?")) // Overlay0
+	}
+	fmt.Println(strings.Join(parts, " "))
+	return nil
+}
+
+// Exit statuses of "gitmoni prompt".
+const (
+	promptClean     = 0
+	promptAttention = 1
+	promptNoData    = 2
+)
+
+// runPrompt implements "gitmoni prompt": it prints a tiny summary of the
+// daemon's snapshot for shell prompt segments and returns the exit status:
+// 0 if every repository is clean, 1 if any needs attention, or 2 if there is
+// no up-to-date snapshot.
+func runPrompt(args []string) (int, error) {
+	fs := flag.NewFlagSet("prompt", flag.ExitOnError)
+	format := fs.String("format", "short", `Output: "short" ("●3 ↓2 ✗1", empty when clean), "counts" ("dirty behind errors repos"), or "none"`)
+	maxAge := fs.Duration("max-age", 15*time.Minute, "Treat a snapshot older than this as missing")
+	fs.Parse(args)
+
+	snap, stale, err := readSnapshot(*maxAge)
+	if err != nil || stale {
+		return promptNoData, nil
+	}
+	sum := snap.Summary()
+
+	switch *format {
+	case "short":
+		if text := shortSummary(sum); text != "" {
+			fmt.Println(text)
+		}
+	case "counts":
+		fmt.Println(sum.Dirty, sum.Behind, sum.Errors, sum.Repos)
+	case "none":
+	default:
+		return promptNoData, fmt.Errorf("unknown format %q", *format)
+	}
+
+	if sum.Clean() {
+		return promptClean, nil
+	}
+	return promptAttention, nil
+}
+
+// runBar implements "gitmoni bar": it prints the daemon's snapshot for a
+// status bar module, either as the JSON object of a waybar custom module or
+// as a line of text for polybar's script module.
+func runBar(args []string) error {
+	fs := flag.NewFlagSet("bar", flag.ExitOnError)
+	format := fs.String("format", "waybar", `Output: "waybar" (JSON) or "polybar" (text)`)
+	maxAge := fs.Duration("max-age", 15*time.Minute, "Mark the summary as out of date when the snapshot is older than this")
+	fs.Parse(args)
+	if *format != "waybar" && *format != "polybar" {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	// class is the severity, for styling the module in waybar's CSS
+	var text, tooltip, class string
+	snap, stale, err := readSnapshot(*maxAge)
+	if err != nil {
+		text, tooltip, class = "gitmoni ?", "No status snapshot; is \"gitmoni daemon\" running?", "stale"
+	} else {
+		sum := snap.Summary()
+		text = shortSummary(sum)
+		switch {
+		case sum.Errors > 0:
+			class = "error"
+		case !sum.Clean():
+			class = "warning"
+		default:
+			text, class = "✓", "clean"
+		}
+		if stale {
+			text += " ?"
+			class = "stale"
+		}
+		cfg := config.Read()
+		tooltip = barTooltip(snap, timefmt.New(cfg.TimeFormat, cfg.Clock))
+	}
+
+	if *format == "polybar" {
+		fmt.Println(text)
+		return nil
+	}
+	// waybar renders the tooltip as Pango markup
+	return json.NewEncoder(os.Stdout).Encode(map[string]string{
+		"text":    text,
+		"tooltip": html.EscapeString(tooltip),
+		"class":   class,
+		"alt":     class,
+	})
+}
+
+// barTooltip describes each repository in snap on a line of its own, after
+// when it was taken in tf.
+func barTooltip(snap gitstatus.Snapshot, tf timefmt.Format) string {
+	lines := []string{"Updated " + tf.Clock(snap.Time)}
+	for _, status := range snap.Statuses {
+		line := filepath.Base(status.Path)
+		if status.Branch != "" {
+			line += " (" + status.Branch + ")"
+		}
+		var details []string
+		switch {
+		case status.HasError:
+			details = append(details, status.Error)
+		case len(status.Files) == 1:
+			details = append(details, "1 changed file")
+		case len(status.Files) > 1:
+			details = append(details, fmt.Sprintf("%d changed files", len(status.Files)))
+		}
+		if status.NeedsPull {
+			details = append(details, status.RemoteStatus)
+		}
+		if len(details) == 0 {
+			details = append(details, "clean")
+		}
+		lines = append(lines, line+": "+strings.Join(details, ", "))
+	}
+	return strings.Join(lines, "\n")
+}